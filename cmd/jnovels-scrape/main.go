@@ -2,12 +2,23 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"git.skobk.in/skobkin/jnovel-scrape/internal/app"
 )
 
+// exitInterrupted is the exit status of a run cut short by SIGINT or
+// SIGTERM. The partial output has been written by then.
+const exitInterrupted = 3
+
+// exitWarnings is the exit status of a run whose warnings matched
+// --strict or --fail-on. The output has been written by then.
+const exitWarnings = 4
+
 func main() {
 	logger := app.NewLogger(os.Stderr)
 
@@ -24,8 +35,27 @@ func main() {
 		logger.Errorf("%v", err)
 		os.Exit(2)
 	}
+	logger = app.NewLoggerFor(os.Stderr, cfg)
+
+	// The first signal cancels the crawl so partial output can be
+	// written; stop restores the default handling, so a second one
+	// kills the process.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
 
-	if err := app.Run(context.Background(), cfg, logger); err != nil {
+	err = app.Run(ctx, cfg, logger)
+	stop()
+	switch {
+	case errors.Is(err, app.ErrInterrupted), err != nil && ctx.Err() != nil:
+		logger.Errorf("%v", err)
+		os.Exit(exitInterrupted)
+	case errors.Is(err, app.ErrWarnings):
+		logger.Errorf("%v", err)
+		os.Exit(exitWarnings)
+	case err != nil:
 		logger.Errorf("%v", err)
 		os.Exit(1)
 	}
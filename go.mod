@@ -3,10 +3,13 @@ module git.skobk.in/skobkin/jnovel-scrape
 go 1.25.3
 
 require (
+	github.com/andybalholm/cascadia v1.3.3
 	github.com/knadh/koanf/providers/basicflag v1.1.1
 	github.com/knadh/koanf/providers/confmap v1.0.1
 	github.com/knadh/koanf/providers/env v1.1.0
 	github.com/knadh/koanf/v2 v2.3.6
+	golang.org/x/net v0.57.0
+	golang.org/x/term v0.45.0
 	golang.org/x/text v0.40.0
 )
 
@@ -15,4 +18,5 @@ require (
 	github.com/knadh/koanf/maps v0.1.2 // indirect
 	github.com/mitchellh/copystructure v1.2.0 // indirect
 	github.com/mitchellh/reflectwalk v1.0.2 // indirect
+	golang.org/x/sys v0.47.0 // indirect
 )
@@ -49,3 +49,37 @@ func TestParseArgsMultipleTitleFilters(t *testing.T) {
 		})
 	}
 }
+
+func TestParseArgsLabelFilters(t *testing.T) {
+	cfg, err := ParseArgs([]string{
+		"--until", "2025-02-01",
+		"--has-tag", "isekai,fantasy",
+		"--has-category", "Light Novel",
+		"--exclude-tag", "harem",
+		"--exclude-tag", "ecchi",
+		"--exclude-category", "Manga",
+	}, nil)
+	if err != nil {
+		t.Fatalf("ParseArgs() unexpected error: %v", err)
+	}
+	if len(cfg.TagFilters) != 2 || cfg.TagFilters[1] != "fantasy" {
+		t.Fatalf("unexpected tag filters: %v", cfg.TagFilters)
+	}
+	if len(cfg.CategoryFilters) != 1 || cfg.CategoryFilters[0] != "Light Novel" {
+		t.Fatalf("unexpected category filters: %v", cfg.CategoryFilters)
+	}
+	if len(cfg.ExcludeTags) != 2 || cfg.ExcludeTags[0] != "harem" || cfg.ExcludeTags[1] != "ecchi" {
+		t.Fatalf("unexpected tag exclusions: %v", cfg.ExcludeTags)
+	}
+	if len(cfg.ExcludeCategories) != 1 || cfg.ExcludeCategories[0] != "Manga" {
+		t.Fatalf("unexpected category exclusions: %v", cfg.ExcludeCategories)
+	}
+
+	cfg, err = ParseArgs([]string{"--until", "2025-02-01"}, nil)
+	if err != nil {
+		t.Fatalf("ParseArgs() unexpected error: %v", err)
+	}
+	if cfg.TagFilters != nil || cfg.CategoryFilters != nil || cfg.ExcludeTags != nil || cfg.ExcludeCategories != nil {
+		t.Fatalf("label filters must be nil when absent: %+v", cfg)
+	}
+}
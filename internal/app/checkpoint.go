@@ -0,0 +1,126 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.skobk.in/skobkin/jnovel-scrape/internal/collect"
+)
+
+// checkpointInterval is the least time between two checkpoint writes.
+// Progress is also written when the crawl fails.
+const checkpointInterval = 30 * time.Second
+
+// checkpointKey fingerprints the parameters that decide which pages a
+// crawl visits and what it keeps from them. Filters applied after the
+// crawl are left out, so they can change between a run and its resume.
+func checkpointKey(cfg Config, options collect.Options) string {
+	parts := []string{
+		string(cfg.Mode),
+		cfg.Cutoff.Format(time.DateOnly),
+		options.BaseURL,
+		string(options.APIStrategy),
+		strings.Join(options.Search, ","),
+		strconv.FormatBool(options.FetchCovers),
+		strconv.FormatBool(options.FetchMetadata),
+		strconv.FormatBool(options.ForceDetail),
+		cfg.SelectorsPath,
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x1f")))
+
+	return hex.EncodeToString(sum[:8])
+}
+
+// loadCheckpoint reads the --checkpoint file. A missing file holds no
+// progress.
+func loadCheckpoint(path string) (collect.Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return collect.Checkpoint{}, nil
+	}
+	if err != nil {
+		return collect.Checkpoint{}, fmt.Errorf("read checkpoint: %w", err)
+	}
+	var cp collect.Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return collect.Checkpoint{}, fmt.Errorf("parse checkpoint %s: %w", path, err)
+	}
+
+	return cp, nil
+}
+
+func saveCheckpoint(path string, cp collect.Checkpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return fmt.Errorf("encode checkpoint: %w", err)
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+
+	return nil
+}
+
+// openCheckpoint loads the --checkpoint file for a scrape run. It
+// returns nil when no checkpoint was asked for.
+func openCheckpoint(cfg Config, options collect.Options, logger *Logger) (*collect.Checkpointer, error) {
+	if cfg.CheckpointPath == "" {
+		return nil, nil
+	}
+	cp, err := resumeCheckpoint(cfg, options, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return collect.NewCheckpointer(cp, checkpointInterval, func(cp collect.Checkpoint) {
+		if err := saveCheckpoint(cfg.CheckpointPath, cp); err != nil {
+			logger.Warn("Saving the checkpoint failed", "error", err)
+		}
+	}), nil
+}
+
+// resumeCheckpoint returns the progress to start from: the saved one
+// when it was written with the same parameters, an empty one otherwise.
+func resumeCheckpoint(cfg Config, options collect.Options, logger *Logger) (collect.Checkpoint, error) {
+	key := checkpointKey(cfg, options)
+	cp, err := loadCheckpoint(cfg.CheckpointPath)
+	if err != nil {
+		return collect.Checkpoint{}, err
+	}
+	switch {
+	case cp.Key == "":
+	case cp.Key != key:
+		logger.Warn("Checkpoint was saved with different parameters; starting over", "path", cfg.CheckpointPath)
+		cp = collect.Checkpoint{}
+	default:
+		logger.Info("Resuming from checkpoint", "path", cfg.CheckpointPath, "saved", cp.UpdatedAt.Format(time.DateTime))
+	}
+	cp.Key = key
+
+	return cp, nil
+}
+
+// closeCheckpoint removes the checkpoint of a finished crawl, or saves
+// the latest progress of one that failed so the next run resumes it.
+func closeCheckpoint(cfg Config, checkpoints *collect.Checkpointer, complete bool, logger *Logger) {
+	if checkpoints == nil {
+		return
+	}
+	if !complete {
+		checkpoints.Flush()
+		logger.Info("Crawl progress saved; run again with the same parameters to resume", "path", cfg.CheckpointPath)
+
+		return
+	}
+	if err := os.Remove(cfg.CheckpointPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		logger.Warn("Removing the checkpoint failed", "path", cfg.CheckpointPath, "error", err)
+	}
+}
@@ -0,0 +1,88 @@
+// Test code reads a t.TempDir()-controlled path; gosec G304 is a
+// false positive here.
+//
+//nolint:gosec
+package app
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"git.skobk.in/skobkin/jnovel-scrape/internal/collect"
+)
+
+func TestResumeCheckpointMatchesParameters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "crawl.json")
+	cutoff := time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC)
+	cfg := Config{Mode: ModeHTML, Cutoff: cutoff, CheckpointPath: path}
+	options := collect.Options{BaseURL: collect.DefaultBaseURL}
+	logger := NewLogger(io.Discard)
+
+	cp, err := resumeCheckpoint(cfg, options, logger)
+	if err != nil {
+		t.Fatalf("resumeCheckpoint() error: %v", err)
+	}
+	if cp.HTML != nil || cp.Key == "" {
+		t.Fatalf("expected empty progress for a missing file, got %+v", cp)
+	}
+	cp.HTML = &collect.HTMLCheckpoint{Page: 4, NextURL: "https://example.com/page/5/"}
+	if err := saveCheckpoint(path, cp); err != nil {
+		t.Fatalf("saveCheckpoint() error: %v", err)
+	}
+
+	cp, err = resumeCheckpoint(cfg, options, logger)
+	if err != nil {
+		t.Fatalf("resumeCheckpoint() error: %v", err)
+	}
+	if cp.HTML == nil || cp.HTML.Page != 4 {
+		t.Fatalf("expected saved progress, got %+v", cp)
+	}
+
+	// Filters do not change which pages are crawled.
+	filtered := cfg
+	filtered.TitleFilters = []string{"spice"}
+	if checkpointKey(filtered, options) != checkpointKey(cfg, options) {
+		t.Fatalf("filters must not change the checkpoint key")
+	}
+
+	other := cfg
+	other.Cutoff = cutoff.AddDate(0, -1, 0)
+	cp, err = resumeCheckpoint(other, options, logger)
+	if err != nil {
+		t.Fatalf("resumeCheckpoint() error: %v", err)
+	}
+	if cp.HTML != nil {
+		t.Fatalf("progress of another cutoff must be dropped, got %+v", cp.HTML)
+	}
+}
+
+func TestCloseCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "crawl.json")
+	cfg := Config{CheckpointPath: path}
+	logger := NewLogger(io.Discard)
+
+	var saved []collect.Checkpoint
+	checkpoints := collect.NewCheckpointer(collect.Checkpoint{Key: "k"}, time.Hour, func(cp collect.Checkpoint) {
+		saved = append(saved, cp)
+	})
+	if err := saveCheckpoint(path, collect.Checkpoint{Key: "k"}); err != nil {
+		t.Fatalf("saveCheckpoint() error: %v", err)
+	}
+
+	closeCheckpoint(cfg, checkpoints, false, logger)
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("an incomplete crawl must keep its checkpoint: %v", err)
+	}
+	closeCheckpoint(cfg, checkpoints, true, logger)
+	if _, err := os.Stat(path); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("a complete crawl must remove its checkpoint: %v", err)
+	}
+	if len(saved) != 0 {
+		t.Fatalf("nothing changed, nothing should be saved: %+v", saved)
+	}
+}
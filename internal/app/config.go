@@ -4,6 +4,8 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"log/slog"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -13,6 +15,8 @@ import (
 	koanfenv "github.com/knadh/koanf/providers/env"
 	"github.com/knadh/koanf/v2"
 
+	"git.skobk.in/skobkin/jnovel-scrape/internal/collect"
+	"git.skobk.in/skobkin/jnovel-scrape/internal/markdown"
 	"git.skobk.in/skobkin/jnovel-scrape/internal/model"
 )
 
@@ -34,6 +38,84 @@ const (
 	ModeAPI Mode = "api"
 	// ModeHTML forces HTML scraping only.
 	ModeHTML Mode = "html"
+	// ModeVerify runs both collectors and reports where they disagree.
+	ModeVerify Mode = "verify"
+)
+
+// Command selects what a run does.
+type Command string
+
+const (
+	// CommandScrape collects posts newer than --until and writes the
+	// table. It is the default when no command is given.
+	CommandScrape Command = "scrape"
+	// CommandRefresh re-reads the posts recorded in --state and reports
+	// which ones changed or disappeared.
+	CommandRefresh Command = "refresh"
+	// CommandRetryFailed re-attempts the fetches recorded in
+	// --dead-letter and merges what it recovers into the output.
+	CommandRetryFailed Command = "retry-failed"
+)
+
+// Format selects the output format.
+type Format string
+
+const (
+	// FormatMarkdown writes the Markdown table. It is the default.
+	FormatMarkdown Format = "markdown"
+	// FormatJSONL writes one JSON object per post and line.
+	FormatJSONL Format = "jsonl"
+	// FormatCSV writes a CSV table with a header row.
+	FormatCSV Format = "csv"
+)
+
+// LogLevel is the least severe level that is logged.
+type LogLevel string
+
+const (
+	// LogLevelDebug also logs skipped posts and second-pass retries.
+	LogLevelDebug LogLevel = "debug"
+	// LogLevelInfo logs crawl progress. It is the default.
+	LogLevelInfo LogLevel = "info"
+	// LogLevelWarn logs warnings and errors only.
+	LogLevelWarn LogLevel = "warn"
+	// LogLevelError logs errors only.
+	LogLevelError LogLevel = "error"
+)
+
+func (l LogLevel) slogLevel() slog.Level {
+	switch l {
+	case LogLevelDebug:
+		return slog.LevelDebug
+	case LogLevelWarn:
+		return slog.LevelWarn
+	case LogLevelError:
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// LogFormat selects how log records are written.
+type LogFormat string
+
+const (
+	// LogFormatText writes key=value lines. It is the default.
+	LogFormatText LogFormat = "text"
+	// LogFormatJSON writes one JSON object per record.
+	LogFormatJSON LogFormat = "json"
+)
+
+// RefreshBy selects how the refresh command finds changed posts.
+type RefreshBy string
+
+const (
+	// RefreshByIDs re-reads every known post by ID. It is the only
+	// strategy that notices deleted posts.
+	RefreshByIDs RefreshBy = "ids"
+	// RefreshByModified lists posts edited since the newest modified
+	// timestamp in the state file, which is cheaper on large states.
+	RefreshByModified RefreshBy = "modified"
 )
 
 // GroupMode defines how posts are grouped before output.
@@ -79,24 +161,57 @@ const (
 // rename; add a `koanf:"<key>"` tag to make the field unmarshallable
 // from a flat koanf instance. Use `koanf:"-"` to skip a field.
 type Config struct {
-	Cutoff       time.Time                   `koanf:"-"`
-	TypeFilters  map[model.PostType]struct{} `koanf:"-"`
-	TypeList     []model.PostType            `koanf:"type"`
-	TitleFilters []string                    `koanf:"title"`
-	TitleMode    TitleMode                   `koanf:"title-mode"`
-	VolumeFilter *float64                    `koanf:"volume"`
-	OutputPath   string                      `koanf:"out"`
-	MaxPages     int                         `koanf:"max-pages"`
-	Concurrency  int                         `koanf:"concurrency"`
-	ReqInterval  time.Duration               `koanf:"req-interval"`
-	LimitWait    time.Duration               `koanf:"limit-wait"`
-	UserAgent    string                      `koanf:"-"`
-	Mode         Mode                        `koanf:"mode"`
-	GroupMode    GroupMode                   `koanf:"group"`
-	GroupSort    GroupSort                   `koanf:"group-sort"`
+	Command       Command                     `koanf:"-"`
+	Cutoff        time.Time                   `koanf:"-"`
+	TypeFilters   map[model.PostType]struct{} `koanf:"-"`
+	TypeList      []model.PostType            `koanf:"type"`
+	TitleFilters  []string                    `koanf:"title"`
+	TitleMode     TitleMode                   `koanf:"title-mode"`
+	TitleSearch   bool                        `koanf:"title-search"`
+	AuthorFilters []string                    `koanf:"author"`
+	// Tag and category filters match whole labels under the same
+	// Unicode folding as --title. Include lists keep posts carrying
+	// any of the labels; exclude lists drop posts carrying any.
+	TagFilters        []string              `koanf:"has-tag"`
+	CategoryFilters   []string              `koanf:"has-category"`
+	ExcludeTags       []string              `koanf:"exclude-tag"`
+	ExcludeCategories []string              `koanf:"exclude-category"`
+	VolumeFilter      *float64              `koanf:"volume"`
+	Limit             int                   `koanf:"limit"`
+	LimitPerTitle     int                   `koanf:"limit-per-title"`
+	OutputPath        string                `koanf:"out"`
+	Format            Format                `koanf:"format"`
+	Columns           []markdown.Column     `koanf:"-"`
+	SelectorsPath     string                `koanf:"selectors"`
+	ForceDetail       bool                  `koanf:"force-detail"`
+	NoProgress        bool                  `koanf:"no-progress"`
+	LogLevel          LogLevel              `koanf:"log-level"`
+	LogFormat         LogFormat             `koanf:"log-format"`
+	Quiet             bool                  `koanf:"quiet"`
+	WarningsOut       string                `koanf:"warnings-out"`
+	SuppressWarnings  []collect.WarningCode `koanf:"-"`
+	Strict            bool                  `koanf:"strict"`
+	FailOn            []collect.WarningCode `koanf:"-"`
+	SummaryOut        string                `koanf:"summary-out"`
+	StatePath         string                `koanf:"state"`
+	DeadLetterPath    string                `koanf:"dead-letter"`
+	CheckpointPath    string                `koanf:"checkpoint"`
+	RefreshBy         RefreshBy             `koanf:"refresh-by"`
+	MaxPages          int                   `koanf:"max-pages"`
+	Concurrency       int                   `koanf:"concurrency"`
+	StopAfter         int                   `koanf:"stop-after"`
+	ReqInterval       time.Duration         `koanf:"req-interval"`
+	RetryBackoff      time.Duration         `koanf:"retry-backoff"`
+	LimitWait         time.Duration         `koanf:"limit-wait"`
+	UserAgent         string                `koanf:"-"`
+	Mode              Mode                  `koanf:"mode"`
+	APIStrategy       collect.APIStrategy   `koanf:"api-strategy"`
+	GroupMode         GroupMode             `koanf:"group"`
+	GroupSort         GroupSort             `koanf:"group-sort"`
 }
 
-// ParseArgs parses CLI flags into a Config.
+// ParseArgs parses CLI flags into a Config. An optional leading command
+// ("scrape", "refresh" or "retry-failed") selects Config.Command.
 //
 // It is preserved as the public entry point for callers in
 // cmd/jnovels-scrape. Internally it delegates to loadConfig, which
@@ -108,7 +223,22 @@ func ParseArgs(args []string, output io.Writer) (Config, error) {
 		fs.SetOutput(output)
 	}
 
-	return loadConfig(fs, args)
+	command, args := splitCommand(args)
+
+	return loadConfig(fs, command, args)
+}
+
+// splitCommand peels a leading command word off args. Anything else,
+// including a leading flag, means CommandScrape.
+func splitCommand(args []string) (Command, []string) {
+	if len(args) > 0 {
+		switch Command(args[0]) {
+		case CommandScrape, CommandRefresh, CommandRetryFailed:
+			return Command(args[0]), args[1:]
+		}
+	}
+
+	return CommandScrape, args
 }
 
 // loadConfig binds CLI flags to the FlagSet, then layers
@@ -122,7 +252,7 @@ func ParseArgs(args []string, output io.Writer) (Config, error) {
 // *string or *[]string via stdlib flag (which already supports this).
 // The basicflag callback (below) remaps flag names to canonical
 // koanf keys.
-func loadConfig(fs *flag.FlagSet, args []string) (Config, error) {
+func loadConfig(fs *flag.FlagSet, command Command, args []string) (Config, error) {
 	keys := configKeys()
 
 	// 1. Defaults via confmap. All defaults are stringified so the
@@ -130,19 +260,34 @@ func loadConfig(fs *flag.FlagSet, args []string) (Config, error) {
 	// strongly-typed fields (Mode, GroupMode, etc.) and the numeric /
 	// duration fields round-trip through koanf.Unmarshal.
 	defaults := map[string]any{
-		keys["mode"]:         string(ModeAuto),
-		keys["group"]:        string(GroupNone),
-		keys["group-sort"]:   string(GroupSortAsc),
-		keys["title-mode"]:   string(TitleModeSubstring),
-		keys["req-interval"]: defaultReqInterval.String(),
-		keys["limit-wait"]:   defaultLimitWait.String(),
-		keys["max-pages"]:    strconv.Itoa(defaultMaxPages),
-		keys["concurrency"]:  strconv.Itoa(defaultConcurrency),
+		keys["mode"]:            string(ModeAuto),
+		keys["api-strategy"]:    string(collect.APIStrategyEmbed),
+		keys["group"]:           string(GroupNone),
+		keys["group-sort"]:      string(GroupSortAsc),
+		keys["title-mode"]:      string(TitleModeSubstring),
+		keys["title-search"]:    "false",
+		keys["force-detail"]:    "false",
+		keys["no-progress"]:     "false",
+		keys["log-level"]:       string(LogLevelInfo),
+		keys["log-format"]:      string(LogFormatText),
+		keys["quiet"]:           "false",
+		keys["strict"]:          "false",
+		keys["req-interval"]:    defaultReqInterval.String(),
+		keys["limit-wait"]:      defaultLimitWait.String(),
+		keys["retry-backoff"]:   collect.DefaultRetryBackoff.String(),
+		keys["max-pages"]:       strconv.Itoa(defaultMaxPages),
+		keys["concurrency"]:     strconv.Itoa(defaultConcurrency),
+		keys["stop-after"]:      strconv.Itoa(collect.DefaultStopAfter),
+		keys["limit"]:           "0",
+		keys["limit-per-title"]: "0",
+		keys["columns"]:         joinColumns(markdown.DefaultColumns),
+		keys["format"]:          string(FormatMarkdown),
+		keys["refresh-by"]:      string(RefreshByIDs),
 	}
 
 	// 2. Bind CLI flags. Aliases share a single *string variable;
 	// stdlib flag.StringVar already supports this.
-	fs.String("until", "", "Cutoff date (YYYY-MM-DD). Required.")
+	fs.String("until", "", "Cutoff date (YYYY-MM-DD). Required, except for refresh where it limits which known posts are checked.")
 
 	typePtr := fs.String("type", "", "Comma separated content types (epub,pdf,manga,unknown).")
 	fs.String("t", *typePtr, "Alias for --type.")
@@ -151,18 +296,50 @@ func loadConfig(fs *flag.FlagSet, args []string) (Config, error) {
 	stringListFlagAlias(fs, titlePtr, "name", "Alias for --title; may be repeated or comma-separated.")
 	stringListFlagAlias(fs, titlePtr, "n", "Alias for --title; may be repeated or comma-separated.")
 
+	fs.Bool("title-search", false, "Push --title needles to the site search instead of crawling the whole archive.")
+
+	stringListFlag(fs, "author", "Case-insensitive author substring filter; may be repeated or comma-separated.")
+
+	stringListFlag(fs, "has-tag", "Keep posts carrying any of these tags; may be repeated or comma-separated.")
+	stringListFlag(fs, "has-category", "Keep posts in any of these categories; may be repeated or comma-separated.")
+	stringListFlag(fs, "exclude-tag", "Drop posts carrying any of these tags; may be repeated or comma-separated.")
+	stringListFlag(fs, "exclude-category", "Drop posts in any of these categories; may be repeated or comma-separated.")
+
 	volumePtr := fs.String("volume", "", "Filter by volume number (integer or decimal).")
 	fs.String("v", *volumePtr, "Alias for --volume.")
 
-	fs.String("out", "", "Output path for Markdown (default stdout).")
-	fs.String("mode", defaults[keys["mode"]].(string), "Fetch mode: auto, api, html.")
+	fs.String("limit", defaults[keys["limit"]].(string), "Write at most this many posts, the newest that pass the filters; the crawl stops once it has them (0 = no limit).")
+	fs.String("limit-per-title", defaults[keys["limit-per-title"]].(string), "Write at most this many posts per title, the newest first (0 = no limit).")
+
+	fs.String("out", "", "Output path (default stdout).")
+	fs.String("format", defaults[keys["format"]].(string), "Output format: markdown, jsonl (JSON Lines) or csv. jsonl and csv stream rows while the crawl runs.")
+	fs.String("columns", defaults[keys["columns"]].(string), "Comma separated table columns ("+joinColumns(markdown.KnownColumns)+").")
+	fs.String("selectors", "", "JSON file with CSS selectors for HTML mode (fields left out use the built-in defaults).")
+	fs.Bool("force-detail", false, "HTML mode: load every detail page even when the archive already shows the date and type.")
+	fs.Bool("no-progress", false, "Log INFO lines instead of the live progress line shown when stderr is a terminal.")
+	fs.String("log-level", defaults[keys["log-level"]].(string), "Least severe level logged: debug, info, warn or error.")
+	fs.String("log-format", defaults[keys["log-format"]].(string), "Log format: text (key=value lines) or json (one object per line).")
+	fs.Bool("quiet", false, "Log errors only; overrides --log-level and hides the progress line.")
+	fs.String("warnings-out", "", "JSON file listing the warnings of the run with their codes.")
+	stringListFlag(fs, "suppress-warnings", "Warning codes to neither log, record nor fail on; may be repeated or comma-separated.")
+	fs.Bool("strict", false, "Exit with status 4 when the run produced any warning that is not suppressed.")
+	stringListFlag(fs, "fail-on", "Exit with status 4 when the run produced a warning with one of these codes; may be repeated or comma-separated.")
+	fs.String("summary-out", "", "JSON file with a machine-readable summary of the run: requests, bytes, retries, post counts and warnings.")
+	fs.String("state", "", "JSON file recording every collected post; scrape runs update it and refresh re-checks it.")
+	fs.String("dead-letter", "", "JSON file collecting fetches that still failed after the retry pass; retry-failed re-attempts them.")
+	fs.String("checkpoint", "", "JSON file saving crawl progress; a rerun with the same parameters resumes from it.")
+	fs.String("refresh-by", defaults[keys["refresh-by"]].(string), "How refresh finds changes: ids (re-read every known post, detects deletions) or modified (posts edited since the newest recorded edit).")
+	fs.String("mode", defaults[keys["mode"]].(string), "Fetch mode: auto, api, html, verify (compare API and HTML results).")
+	fs.String("api-strategy", defaults[keys["api-strategy"]].(string), "API request strategy: full, fields (trim with _fields), embed (_fields + inline taxonomy names).")
 	fs.String("group", defaults[keys["group"]].(string), "Grouping strategy (none,title).")
 	fs.String("group-sort", defaults[keys["group-sort"]].(string), "Sort order within groups (asc,desc).")
 	fs.String("title-mode", defaults[keys["title-mode"]].(string), "Title match mode: substring (default) or word (whole-token).")
 	fs.String("req-interval", defaults[keys["req-interval"]].(string), "Delay between HTTP requests (time.ParseDuration).")
 	fs.String("limit-wait", defaults[keys["limit-wait"]].(string), "Delay when server rate limits without Retry-After.")
+	fs.String("retry-backoff", defaults[keys["retry-backoff"]].(string), "Delay before the second pass over failed pages and detail fetches (time.ParseDuration).")
 	fs.String("max-pages", defaults[keys["max-pages"]].(string), "Maximum number of pages to traverse (API or HTML).")
 	fs.String("concurrency", defaults[keys["concurrency"]].(string), "Number of concurrent fetches for detail pages/taxonomies.")
+	fs.String("stop-after", defaults[keys["stop-after"]].(string), "Stop paging after this many consecutive non-sticky posts older than --until.")
 
 	// 3. Parse the CLI args. ContinueOnError is set on the FlagSet
 	// already by ParseArgs; flag.Parse returns an error which we
@@ -192,6 +369,7 @@ func loadConfig(fs *flag.FlagSet, args []string) (Config, error) {
 	if err := k.Unmarshal("", &cfg); err != nil {
 		return Config{}, fmt.Errorf("unmarshal: %w", err)
 	}
+	cfg.Command = command
 
 	cfg, err := parseRawConfig(k, cfg)
 	if err != nil {
@@ -280,6 +458,22 @@ func (s *stringListValue) Values() []string {
 	return s.values
 }
 
+// splitList flattens repeated and comma-separated list values, trimming
+// each element and skipping empties. It returns nil when nothing is left
+// so an absent flag keeps its zero value.
+func splitList(values []string) []string {
+	var out []string
+	for _, raw := range values {
+		for _, part := range strings.Split(raw, ",") {
+			if item := strings.TrimSpace(part); item != "" {
+				out = append(out, item)
+			}
+		}
+	}
+
+	return out
+}
+
 func parseTypeList(raw string) ([]model.PostType, error) {
 	items := strings.Split(raw, ",")
 	seen := make(map[model.PostType]struct{})
@@ -308,6 +502,41 @@ func parseTypeList(raw string) ([]model.PostType, error) {
 	return result, nil
 }
 
+// parseColumns validates a comma-separated column list. An empty value
+// selects markdown.DefaultColumns.
+func parseColumns(raw string) ([]markdown.Column, error) {
+	var columns []markdown.Column
+	seen := make(map[markdown.Column]struct{})
+	for _, item := range strings.Split(raw, ",") {
+		column := markdown.Column(strings.ToLower(strings.TrimSpace(item)))
+		if column == "" {
+			continue
+		}
+		if !slices.Contains(markdown.KnownColumns, column) {
+			return nil, fmt.Errorf("invalid column %q (allowed: %s)", column, joinColumns(markdown.KnownColumns))
+		}
+		if _, ok := seen[column]; ok {
+			continue
+		}
+		seen[column] = struct{}{}
+		columns = append(columns, column)
+	}
+	if len(columns) == 0 {
+		return markdown.DefaultColumns, nil
+	}
+
+	return columns, nil
+}
+
+func joinColumns(columns []markdown.Column) string {
+	parts := make([]string, len(columns))
+	for i, column := range columns {
+		parts[i] = string(column)
+	}
+
+	return strings.Join(parts, ",")
+}
+
 func parseMode(raw string) (Mode, error) {
 	switch strings.ToLower(strings.TrimSpace(raw)) {
 	case string(ModeAuto):
@@ -316,8 +545,100 @@ func parseMode(raw string) (Mode, error) {
 		return ModeAPI, nil
 	case string(ModeHTML):
 		return ModeHTML, nil
+	case string(ModeVerify):
+		return ModeVerify, nil
+	default:
+		return "", fmt.Errorf("invalid --mode %q (expected auto, api, html, verify)", raw)
+	}
+}
+
+func parseFormat(raw string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case string(FormatMarkdown), "":
+		return FormatMarkdown, nil
+	case string(FormatJSONL):
+		return FormatJSONL, nil
+	case string(FormatCSV):
+		return FormatCSV, nil
+	default:
+		return "", fmt.Errorf("invalid --format %q (expected markdown, jsonl, csv)", raw)
+	}
+}
+
+func parseLogLevel(raw string) (LogLevel, error) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case string(LogLevelInfo), "":
+		return LogLevelInfo, nil
+	case string(LogLevelDebug):
+		return LogLevelDebug, nil
+	case string(LogLevelWarn), "warning":
+		return LogLevelWarn, nil
+	case string(LogLevelError):
+		return LogLevelError, nil
+	default:
+		return "", fmt.Errorf("invalid --log-level %q (expected debug, info, warn, error)", raw)
+	}
+}
+
+func parseLogFormat(raw string) (LogFormat, error) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case string(LogFormatText), "":
+		return LogFormatText, nil
+	case string(LogFormatJSON):
+		return LogFormatJSON, nil
+	default:
+		return "", fmt.Errorf("invalid --log-format %q (expected text, json)", raw)
+	}
+}
+
+// parseWarningCodes parses a comma-separated list of warning codes for
+// the named flag.
+func parseWarningCodes(name, raw string) ([]collect.WarningCode, error) {
+	var codes []collect.WarningCode
+	for _, item := range splitList([]string{raw}) {
+		code := collect.WarningCode(strings.ToLower(item))
+		if !slices.Contains(collect.WarningCodes, code) {
+			return nil, fmt.Errorf("invalid --%s code %q (expected %s)", name, item, joinWarningCodes(collect.WarningCodes))
+		}
+		if !slices.Contains(codes, code) {
+			codes = append(codes, code)
+		}
+	}
+
+	return codes, nil
+}
+
+func joinWarningCodes(codes []collect.WarningCode) string {
+	parts := make([]string, len(codes))
+	for i, code := range codes {
+		parts[i] = string(code)
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func parseRefreshBy(raw string) (RefreshBy, error) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case string(RefreshByIDs), "":
+		return RefreshByIDs, nil
+	case string(RefreshByModified):
+		return RefreshByModified, nil
 	default:
-		return "", fmt.Errorf("invalid --mode %q (expected auto, api, html)", raw)
+		return "", fmt.Errorf("invalid --refresh-by %q (expected ids, modified)", raw)
+	}
+}
+
+func parseAPIStrategy(raw string) (collect.APIStrategy, error) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case string(collect.APIStrategyFull):
+		return collect.APIStrategyFull, nil
+	case string(collect.APIStrategyFields):
+		return collect.APIStrategyFields, nil
+	case string(collect.APIStrategyEmbed), "":
+		// Empty means "use the default", as with --title-mode.
+		return collect.APIStrategyEmbed, nil
+	default:
+		return "", fmt.Errorf("invalid --api-strategy %q (expected full, fields, embed)", raw)
 	}
 }
 
@@ -369,19 +690,47 @@ func parseTitleMode(raw string) (TitleMode, error) {
 // struct tags used by koanf.Unmarshal.
 func configKeys() map[string]string {
 	return map[string]string{
-		"until":        "UNTIL",
-		"type":         "TYPE",
-		"title":        "TITLE",
-		"title-mode":   "TITLE_MODE",
-		"volume":       "VOLUME",
-		"mode":         "MODE",
-		"group":        "GROUP",
-		"group-sort":   "GROUP_SORT",
-		"req-interval": "REQ_INTERVAL",
-		"limit-wait":   "LIMIT_WAIT",
-		"max-pages":    "MAX_PAGES",
-		"concurrency":  "CONCURRENCY",
-		"out":          "OUT",
+		"until":             "UNTIL",
+		"type":              "TYPE",
+		"title":             "TITLE",
+		"title-mode":        "TITLE_MODE",
+		"title-search":      "TITLE_SEARCH",
+		"author":            "AUTHOR",
+		"has-tag":           "HAS_TAG",
+		"has-category":      "HAS_CATEGORY",
+		"exclude-tag":       "EXCLUDE_TAG",
+		"exclude-category":  "EXCLUDE_CATEGORY",
+		"volume":            "VOLUME",
+		"limit":             "LIMIT",
+		"limit-per-title":   "LIMIT_PER_TITLE",
+		"mode":              "MODE",
+		"api-strategy":      "API_STRATEGY",
+		"group":             "GROUP",
+		"group-sort":        "GROUP_SORT",
+		"req-interval":      "REQ_INTERVAL",
+		"limit-wait":        "LIMIT_WAIT",
+		"retry-backoff":     "RETRY_BACKOFF",
+		"max-pages":         "MAX_PAGES",
+		"concurrency":       "CONCURRENCY",
+		"stop-after":        "STOP_AFTER",
+		"out":               "OUT",
+		"columns":           "COLUMNS",
+		"format":            "FORMAT",
+		"selectors":         "SELECTORS",
+		"force-detail":      "FORCE_DETAIL",
+		"no-progress":       "NO_PROGRESS",
+		"log-level":         "LOG_LEVEL",
+		"log-format":        "LOG_FORMAT",
+		"quiet":             "QUIET",
+		"warnings-out":      "WARNINGS_OUT",
+		"suppress-warnings": "SUPPRESS_WARNINGS",
+		"strict":            "STRICT",
+		"fail-on":           "FAIL_ON",
+		"summary-out":       "SUMMARY_OUT",
+		"state":             "STATE",
+		"refresh-by":        "REFRESH_BY",
+		"dead-letter":       "DEAD_LETTER",
+		"checkpoint":        "CHECKPOINT",
 	}
 }
 
@@ -424,7 +773,7 @@ func unmarshalConfig(raw map[string]any) (Config, error) {
 // validation).
 //
 // Behaviour parity with the pre-koanf ParseArgs:
-//   - --until is required.
+//   - --until is required, except for the refresh command.
 //   - --volume is optional; an empty string leaves VolumeFilter as nil.
 //   - --type is optional; an empty string leaves TypeList and
 //     TypeFilters as empty.
@@ -436,17 +785,47 @@ func unmarshalConfig(raw map[string]any) (Config, error) {
 //   - --req-interval and --limit-wait must be valid durations > 0.
 //   - --max-pages and --concurrency must be positive.
 //   - --mode, --group, --group-sort accept the same set of values.
+//   - --api-strategy must be one of full, fields, embed.
+//   - --columns accepts a comma-separated subset of the known table
+//     columns; empty selects the default layout.
+//   - --format must be markdown, jsonl or csv.
+//   - --limit and --limit-per-title must not be negative.
+//   - --log-level must be debug, info, warn or error; --log-format
+//     must be text or json.
+//   - --suppress-warnings and --fail-on accept known warning codes
+//     only.
+//   - refresh needs --state; --refresh-by must be ids or modified.
+//   - retry-failed needs --dead-letter; --retry-backoff must not be
+//     negative.
 func parseRawConfig(k *koanf.Koanf, cfg Config) (Config, error) {
 	// --until
+	if cfg.Command == "" {
+		cfg.Command = CommandScrape
+	}
 	until := k.String("until")
-	if until == "" {
+	switch {
+	case until != "":
+		cutoff, err := time.Parse("2006-01-02", until)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid --until value: %w", err)
+		}
+		cfg.Cutoff = time.Date(cutoff.Year(), cutoff.Month(), cutoff.Day(), 0, 0, 0, 0, time.UTC)
+	case cfg.Command != CommandRefresh:
 		return cfg, fmt.Errorf("--until is required")
 	}
-	cutoff, err := time.Parse("2006-01-02", until)
+
+	// refresh / --state / --refresh-by
+	if cfg.Command == CommandRefresh && cfg.StatePath == "" {
+		return cfg, fmt.Errorf("refresh requires --state")
+	}
+	if cfg.Command == CommandRetryFailed && cfg.DeadLetterPath == "" {
+		return cfg, fmt.Errorf("retry-failed requires --dead-letter")
+	}
+	refreshBy, err := parseRefreshBy(k.String("refresh-by"))
 	if err != nil {
-		return cfg, fmt.Errorf("invalid --until value: %w", err)
+		return cfg, err
 	}
-	cfg.Cutoff = time.Date(cutoff.Year(), cutoff.Month(), cutoff.Day(), 0, 0, 0, 0, time.UTC)
+	cfg.RefreshBy = refreshBy
 
 	// --type
 	if typeRaw := k.String("type"); typeRaw != "" {
@@ -467,15 +846,17 @@ func parseRawConfig(k *koanf.Koanf, cfg Config) (Config, error) {
 	// separated. The pre-koanf code did strings.Join + strings.Split,
 	// which had the same effect. We iterate, split, trim, and skip
 	// empties so `--title " dragon , spice "` produces ["dragon", "spice"].
-	var titles []string
-	for _, raw := range cfg.TitleFilters {
-		for _, part := range strings.Split(raw, ",") {
-			if t := strings.TrimSpace(part); t != "" {
-				titles = append(titles, t)
-			}
-		}
-	}
-	cfg.TitleFilters = titles
+	cfg.TitleFilters = splitList(cfg.TitleFilters)
+
+	// --author uses the same list convention as --title.
+	cfg.AuthorFilters = splitList(cfg.AuthorFilters)
+
+	// --has-tag / --has-category / --exclude-tag / --exclude-category
+	// follow the same repeated-or-comma-separated convention.
+	cfg.TagFilters = splitList(cfg.TagFilters)
+	cfg.CategoryFilters = splitList(cfg.CategoryFilters)
+	cfg.ExcludeTags = splitList(cfg.ExcludeTags)
+	cfg.ExcludeCategories = splitList(cfg.ExcludeCategories)
 
 	// --volume
 	//
@@ -503,6 +884,10 @@ func parseRawConfig(k *koanf.Koanf, cfg Config) (Config, error) {
 	if cfg.LimitWait <= 0 {
 		return cfg, fmt.Errorf("invalid --limit-wait: %s", k.String("limit-wait"))
 	}
+	// --retry-backoff: zero (absent in a raw map) retries right away.
+	if cfg.RetryBackoff < 0 {
+		return cfg, fmt.Errorf("invalid --retry-backoff: %s", k.String("retry-backoff"))
+	}
 
 	// --max-pages / --concurrency
 	if cfg.MaxPages <= 0 {
@@ -511,6 +896,18 @@ func parseRawConfig(k *koanf.Koanf, cfg Config) (Config, error) {
 	if cfg.Concurrency <= 0 {
 		return cfg, fmt.Errorf("--concurrency must be positive")
 	}
+	// --stop-after: zero (absent in a raw map) means the collector
+	// default.
+	if cfg.StopAfter < 0 {
+		return cfg, fmt.Errorf("--stop-after must not be negative")
+	}
+	// --limit / --limit-per-title: zero means no limit.
+	if cfg.Limit < 0 {
+		return cfg, fmt.Errorf("--limit must not be negative")
+	}
+	if cfg.LimitPerTitle < 0 {
+		return cfg, fmt.Errorf("--limit-per-title must not be negative")
+	}
 
 	// --mode / --group / --group-sort
 	mode, err := parseMode(k.String("mode"))
@@ -519,6 +916,12 @@ func parseRawConfig(k *koanf.Koanf, cfg Config) (Config, error) {
 	}
 	cfg.Mode = mode
 
+	apiStrategy, err := parseAPIStrategy(k.String("api-strategy"))
+	if err != nil {
+		return cfg, err
+	}
+	cfg.APIStrategy = apiStrategy
+
 	groupMode, err := parseGroupMode(k.String("group"))
 	if err != nil {
 		return cfg, err
@@ -537,5 +940,41 @@ func parseRawConfig(k *koanf.Koanf, cfg Config) (Config, error) {
 	}
 	cfg.TitleMode = titleMode
 
+	columns, err := parseColumns(k.String("columns"))
+	if err != nil {
+		return cfg, err
+	}
+	cfg.Columns = columns
+
+	format, err := parseFormat(k.String("format"))
+	if err != nil {
+		return cfg, err
+	}
+	cfg.Format = format
+
+	logLevel, err := parseLogLevel(k.String("log-level"))
+	if err != nil {
+		return cfg, err
+	}
+	cfg.LogLevel = logLevel
+
+	logFormat, err := parseLogFormat(k.String("log-format"))
+	if err != nil {
+		return cfg, err
+	}
+	cfg.LogFormat = logFormat
+
+	suppressed, err := parseWarningCodes("suppress-warnings", k.String("suppress-warnings"))
+	if err != nil {
+		return cfg, err
+	}
+	cfg.SuppressWarnings = suppressed
+
+	failOn, err := parseWarningCodes("fail-on", k.String("fail-on"))
+	if err != nil {
+		return cfg, err
+	}
+	cfg.FailOn = failOn
+
 	return cfg, nil
 }
@@ -1,10 +1,13 @@
 package app
 
 import (
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
+	"git.skobk.in/skobkin/jnovel-scrape/internal/collect"
+	"git.skobk.in/skobkin/jnovel-scrape/internal/markdown"
 	"git.skobk.in/skobkin/jnovel-scrape/internal/model"
 )
 
@@ -17,6 +20,7 @@ func TestParseMode(t *testing.T) {
 		{"auto", ModeAuto, true},
 		{"API", ModeAPI, true},
 		{"html", ModeHTML, true},
+		{"verify", ModeVerify, true},
 		{"invalid", "", false},
 	}
 
@@ -34,6 +38,33 @@ func TestParseMode(t *testing.T) {
 	}
 }
 
+func TestParseFormat(t *testing.T) {
+	cases := []struct {
+		input string
+		want  Format
+		ok    bool
+	}{
+		{"", FormatMarkdown, true},
+		{"markdown", FormatMarkdown, true},
+		{"JSONL", FormatJSONL, true},
+		{"csv", FormatCSV, true},
+		{"json", "", false},
+	}
+
+	for _, tc := range cases {
+		got, err := parseFormat(tc.input)
+		if tc.ok && err != nil {
+			t.Fatalf("parseFormat(%q) unexpected error: %v", tc.input, err)
+		}
+		if !tc.ok && err == nil {
+			t.Fatalf("parseFormat(%q) expected error", tc.input)
+		}
+		if tc.ok && got != tc.want {
+			t.Fatalf("parseFormat(%q) = %v, want %v", tc.input, got, tc.want)
+		}
+	}
+}
+
 func TestParseGroupMode(t *testing.T) {
 	cases := []struct {
 		input string
@@ -238,3 +269,282 @@ func TestParseArgsMissingUntil(t *testing.T) {
 		t.Fatalf("expected error when --until is missing")
 	}
 }
+
+func TestParseArgsTitleSearch(t *testing.T) {
+	cfg, err := ParseArgs([]string{"--until", "2025-02-01"}, nil)
+	if err != nil {
+		t.Fatalf("ParseArgs() unexpected error: %v", err)
+	}
+	if cfg.TitleSearch {
+		t.Fatalf("title search must be off by default")
+	}
+
+	cfg, err = ParseArgs([]string{"--until", "2025-02-01", "--title", "dragon", "--title-search"}, nil)
+	if err != nil {
+		t.Fatalf("ParseArgs() unexpected error: %v", err)
+	}
+	if !cfg.TitleSearch {
+		t.Fatalf("--title-search was not applied")
+	}
+
+	t.Setenv("JN_TITLE_SEARCH", "true")
+	cfg, err = ParseArgs([]string{"--until", "2025-02-01"}, nil)
+	if err != nil {
+		t.Fatalf("ParseArgs() unexpected error: %v", err)
+	}
+	if !cfg.TitleSearch {
+		t.Fatalf("JN_TITLE_SEARCH=true was not applied")
+	}
+}
+
+func TestParseAPIStrategy(t *testing.T) {
+	cases := []struct {
+		input string
+		want  collect.APIStrategy
+		ok    bool
+	}{
+		{"full", collect.APIStrategyFull, true},
+		{"FIELDS", collect.APIStrategyFields, true},
+		{"embed", collect.APIStrategyEmbed, true},
+		{"", collect.APIStrategyEmbed, true},
+		{"graphql", "", false},
+	}
+	for _, tc := range cases {
+		got, err := parseAPIStrategy(tc.input)
+		if tc.ok && err != nil {
+			t.Fatalf("parseAPIStrategy(%q) unexpected error: %v", tc.input, err)
+		}
+		if !tc.ok && err == nil {
+			t.Fatalf("parseAPIStrategy(%q) expected error", tc.input)
+		}
+		if tc.ok && got != tc.want {
+			t.Fatalf("parseAPIStrategy(%q) = %v, want %v", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestParseColumns(t *testing.T) {
+	got, err := parseColumns(" Cover, title,link,title ")
+	if err != nil {
+		t.Fatalf("parseColumns() unexpected error: %v", err)
+	}
+	want := []markdown.Column{markdown.ColumnCover, markdown.ColumnTitle, markdown.ColumnLink}
+	if !slices.Equal(got, want) {
+		t.Fatalf("parseColumns() = %v, want %v", got, want)
+	}
+
+	got, err = parseColumns("")
+	if err != nil || !slices.Equal(got, markdown.DefaultColumns) {
+		t.Fatalf("empty columns should select defaults, got %v (%v)", got, err)
+	}
+
+	if _, err := parseColumns("title,rating"); err == nil {
+		t.Fatalf("expected error for unknown column")
+	}
+}
+
+func TestParseArgsColumns(t *testing.T) {
+	cfg, err := ParseArgs([]string{"--until", "2025-02-01"}, nil)
+	if err != nil {
+		t.Fatalf("ParseArgs() unexpected error: %v", err)
+	}
+	if !slices.Equal(cfg.Columns, markdown.DefaultColumns) {
+		t.Fatalf("expected default columns, got %v", cfg.Columns)
+	}
+
+	cfg, err = ParseArgs([]string{"--until", "2025-02-01", "--columns", "cover,title"}, nil)
+	if err != nil {
+		t.Fatalf("ParseArgs() unexpected error: %v", err)
+	}
+	if want := []markdown.Column{markdown.ColumnCover, markdown.ColumnTitle}; !slices.Equal(cfg.Columns, want) {
+		t.Fatalf("--columns was not applied: %v", cfg.Columns)
+	}
+
+	t.Setenv("JN_COLUMNS", "title,link")
+	cfg, err = ParseArgs([]string{"--until", "2025-02-01"}, nil)
+	if err != nil {
+		t.Fatalf("ParseArgs() unexpected error: %v", err)
+	}
+	if want := []markdown.Column{markdown.ColumnTitle, markdown.ColumnLink}; !slices.Equal(cfg.Columns, want) {
+		t.Fatalf("JN_COLUMNS was not applied: %v", cfg.Columns)
+	}
+}
+
+func TestParseArgsStopAfter(t *testing.T) {
+	cfg, err := ParseArgs([]string{"--until", "2025-02-01"}, nil)
+	if err != nil {
+		t.Fatalf("ParseArgs() unexpected error: %v", err)
+	}
+	if cfg.StopAfter != collect.DefaultStopAfter {
+		t.Fatalf("expected default stop-after %d, got %d", collect.DefaultStopAfter, cfg.StopAfter)
+	}
+
+	cfg, err = ParseArgs([]string{"--until", "2025-02-01", "--stop-after", "5"}, nil)
+	if err != nil {
+		t.Fatalf("ParseArgs() unexpected error: %v", err)
+	}
+	if cfg.StopAfter != 5 {
+		t.Fatalf("--stop-after was not applied: %d", cfg.StopAfter)
+	}
+
+	if _, err := ParseArgs([]string{"--until", "2025-02-01", "--stop-after", "-1"}, nil); err == nil {
+		t.Fatalf("expected error for negative --stop-after")
+	}
+}
+
+func TestParseArgsRefreshCommand(t *testing.T) {
+	cfg, err := ParseArgs([]string{"refresh", "--state", "state.json"}, nil)
+	if err != nil {
+		t.Fatalf("ParseArgs() unexpected error: %v", err)
+	}
+	if cfg.Command != CommandRefresh || cfg.RefreshBy != RefreshByIDs || !cfg.Cutoff.IsZero() {
+		t.Fatalf("unexpected refresh config: command=%s by=%s cutoff=%v", cfg.Command, cfg.RefreshBy, cfg.Cutoff)
+	}
+
+	cfg, err = ParseArgs([]string{"--until", "2025-02-01"}, nil)
+	if err != nil {
+		t.Fatalf("ParseArgs() unexpected error: %v", err)
+	}
+	if cfg.Command != CommandScrape {
+		t.Fatalf("expected scrape command by default, got %s", cfg.Command)
+	}
+
+	if _, err := ParseArgs([]string{"refresh"}, nil); err == nil {
+		t.Fatalf("expected error for refresh without --state")
+	}
+	if _, err := ParseArgs([]string{"refresh", "--state", "s.json", "--refresh-by", "magic"}, nil); err == nil {
+		t.Fatalf("expected error for invalid --refresh-by")
+	}
+	if _, err := ParseArgs([]string{"scrape", "--state", "s.json"}, nil); err == nil {
+		t.Fatalf("expected scrape to still require --until")
+	}
+}
+
+func TestParseArgsRetryFailedCommand(t *testing.T) {
+	cfg, err := ParseArgs([]string{"retry-failed", "--until", "2025-02-01", "--dead-letter", "failed.json"}, nil)
+	if err != nil {
+		t.Fatalf("ParseArgs() unexpected error: %v", err)
+	}
+	if cfg.Command != CommandRetryFailed || cfg.DeadLetterPath != "failed.json" {
+		t.Fatalf("unexpected config: command=%s dead-letter=%q", cfg.Command, cfg.DeadLetterPath)
+	}
+	if cfg.RetryBackoff != collect.DefaultRetryBackoff {
+		t.Fatalf("expected default retry backoff, got %s", cfg.RetryBackoff)
+	}
+
+	if _, err := ParseArgs([]string{"retry-failed", "--until", "2025-02-01"}, nil); err == nil {
+		t.Fatalf("expected error for retry-failed without --dead-letter")
+	}
+	if _, err := ParseArgs([]string{"--until", "2025-02-01", "--retry-backoff", "-1s"}, nil); err == nil {
+		t.Fatalf("expected error for negative --retry-backoff")
+	}
+}
+
+func TestParseArgsForceDetail(t *testing.T) {
+	cfg, err := ParseArgs([]string{"--until", "2025-02-01"}, nil)
+	if err != nil {
+		t.Fatalf("ParseArgs() unexpected error: %v", err)
+	}
+	if cfg.ForceDetail {
+		t.Fatalf("--force-detail should default to false")
+	}
+	cfg, err = ParseArgs([]string{"--until", "2025-02-01", "--force-detail"}, nil)
+	if err != nil {
+		t.Fatalf("ParseArgs() unexpected error: %v", err)
+	}
+	if !cfg.ForceDetail {
+		t.Fatalf("--force-detail was not applied")
+	}
+}
+
+func TestParseArgsNoProgress(t *testing.T) {
+	cfg, err := ParseArgs([]string{"--until", "2025-02-01"}, nil)
+	if err != nil {
+		t.Fatalf("ParseArgs() unexpected error: %v", err)
+	}
+	if cfg.NoProgress {
+		t.Fatalf("--no-progress should default to false")
+	}
+	t.Setenv("JN_NO_PROGRESS", "true")
+	cfg, err = ParseArgs([]string{"--until", "2025-02-01"}, nil)
+	if err != nil {
+		t.Fatalf("ParseArgs() unexpected error: %v", err)
+	}
+	if !cfg.NoProgress {
+		t.Fatalf("JN_NO_PROGRESS was not applied")
+	}
+}
+
+func TestParseArgsLogging(t *testing.T) {
+	cfg, err := ParseArgs([]string{"--until", "2025-02-01"}, nil)
+	if err != nil {
+		t.Fatalf("ParseArgs() unexpected error: %v", err)
+	}
+	if cfg.LogLevel != LogLevelInfo || cfg.LogFormat != LogFormatText || cfg.Quiet {
+		t.Fatalf("unexpected logging defaults: %s %s %v", cfg.LogLevel, cfg.LogFormat, cfg.Quiet)
+	}
+
+	t.Setenv("JN_LOG_FORMAT", "JSON")
+	cfg, err = ParseArgs([]string{"--until", "2025-02-01", "--log-level", "debug", "--quiet"}, nil)
+	if err != nil {
+		t.Fatalf("ParseArgs() unexpected error: %v", err)
+	}
+	if cfg.LogLevel != LogLevelDebug || cfg.LogFormat != LogFormatJSON || !cfg.Quiet {
+		t.Fatalf("logging flags were not applied: %s %s %v", cfg.LogLevel, cfg.LogFormat, cfg.Quiet)
+	}
+
+	if _, err := ParseArgs([]string{"--until", "2025-02-01", "--log-level", "verbose"}, nil); err == nil {
+		t.Fatalf("expected error for an unknown --log-level")
+	}
+	if _, err := ParseArgs([]string{"--until", "2025-02-01", "--log-format", "xml"}, nil); err == nil {
+		t.Fatalf("expected error for an unknown --log-format")
+	}
+}
+
+func TestParseArgsWarningFlags(t *testing.T) {
+	cfg, err := ParseArgs([]string{"--until", "2025-02-01", "--fail-on", "missing_volume,Unknown_Type", "--fail-on", "missing_volume", "--suppress-warnings", "before_cutoff", "--strict", "--warnings-out", "w.json"}, nil)
+	if err != nil {
+		t.Fatalf("ParseArgs() unexpected error: %v", err)
+	}
+	if len(cfg.FailOn) != 2 || cfg.FailOn[0] != collect.WarningMissingVolume || cfg.FailOn[1] != collect.WarningUnknownType {
+		t.Fatalf("unexpected --fail-on codes: %v", cfg.FailOn)
+	}
+	if len(cfg.SuppressWarnings) != 1 || cfg.SuppressWarnings[0] != collect.WarningBeforeCutoff {
+		t.Fatalf("unexpected --suppress-warnings codes: %v", cfg.SuppressWarnings)
+	}
+	if !cfg.Strict || cfg.WarningsOut != "w.json" {
+		t.Fatalf("--strict or --warnings-out was not applied: %+v", cfg)
+	}
+
+	t.Setenv("JN_FAIL_ON", "fetch_failed")
+	cfg, err = ParseArgs([]string{"--until", "2025-02-01"}, nil)
+	if err != nil {
+		t.Fatalf("ParseArgs() unexpected error: %v", err)
+	}
+	if len(cfg.FailOn) != 1 || cfg.FailOn[0] != collect.WarningFetchFailed {
+		t.Fatalf("JN_FAIL_ON was not applied: %v", cfg.FailOn)
+	}
+
+	if _, err := ParseArgs([]string{"--until", "2025-02-01", "--fail-on", "bogus"}, nil); err == nil {
+		t.Fatalf("expected error for an unknown --fail-on code")
+	}
+}
+
+func TestParseArgsSummaryOut(t *testing.T) {
+	cfg, err := ParseArgs([]string{"--until", "2025-02-01", "--summary-out", "summary.json"}, nil)
+	if err != nil {
+		t.Fatalf("ParseArgs() unexpected error: %v", err)
+	}
+	if cfg.SummaryOut != "summary.json" {
+		t.Fatalf("--summary-out was not applied: %q", cfg.SummaryOut)
+	}
+
+	t.Setenv("JN_SUMMARY_OUT", "env.json")
+	cfg, err = ParseArgs([]string{"--until", "2025-02-01"}, nil)
+	if err != nil {
+		t.Fatalf("ParseArgs() unexpected error: %v", err)
+	}
+	if cfg.SummaryOut != "env.json" {
+		t.Fatalf("JN_SUMMARY_OUT was not applied: %q", cfg.SummaryOut)
+	}
+}
@@ -0,0 +1,155 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"git.skobk.in/skobkin/jnovel-scrape/internal/collect"
+	"git.skobk.in/skobkin/jnovel-scrape/internal/model"
+)
+
+// loadDeadLetter reads the --dead-letter file. A missing file holds no
+// failures.
+func loadDeadLetter(path string) ([]collect.FailedFetch, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read dead-letter file: %w", err)
+	}
+	var items []collect.FailedFetch
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("parse dead-letter file %s: %w", path, err)
+	}
+
+	return items, nil
+}
+
+// saveDeadLetter replaces the dead-letter file with items, removing it
+// when nothing is left to retry.
+func saveDeadLetter(path string, items []collect.FailedFetch) error {
+	if len(items) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("remove dead-letter file: %w", err)
+		}
+
+		return nil
+	}
+	data, err := json.MarshalIndent(items, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode dead-letter file: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("write dead-letter file: %w", err)
+	}
+
+	return nil
+}
+
+// recordFailures adds the failures of a scrape run to the dead-letter
+// file, keeping entries from earlier runs that were not retried yet. A
+// URL already listed is replaced by the newer entry.
+func recordFailures(cfg Config, failures []collect.FailedFetch, logger *Logger) error {
+	if len(failures) == 0 {
+		return nil
+	}
+	if cfg.DeadLetterPath == "" {
+		logger.Warn("Fetches still failed after the retry pass; pass --dead-letter to keep them for retry-failed", "failed", len(failures))
+
+		return nil
+	}
+	items, err := loadDeadLetter(cfg.DeadLetterPath)
+	if err != nil {
+		return err
+	}
+	index := make(map[string]int, len(items))
+	for i, item := range items {
+		index[item.URL] = i
+	}
+	for _, item := range failures {
+		if i, ok := index[item.URL]; ok {
+			items[i] = item
+
+			continue
+		}
+		index[item.URL] = len(items)
+		items = append(items, item)
+	}
+	if err := saveDeadLetter(cfg.DeadLetterPath, items); err != nil {
+		return err
+	}
+	logger.Warn("Fetches still failed after the retry pass", "failed", len(failures), "dead_letter", cfg.DeadLetterPath)
+
+	return nil
+}
+
+// runRetryFailed re-attempts the dead-letter entries once, keeps the ones
+// that fail again, and writes the table. With --state the recovered posts
+// are merged into it and the table covers every recorded post since the
+// cutoff; without it the table lists only the recovered posts.
+func runRetryFailed(ctx context.Context, cfg Config, options collect.Options, summary *runSummary, logger *Logger) error {
+	items, err := loadDeadLetter(cfg.DeadLetterPath)
+	if err != nil {
+		return err
+	}
+	logger.Info("Retrying failed fetches", "fetches", len(items), "dead_letter", cfg.DeadLetterPath)
+
+	failures := &collect.Failures{}
+	options.Failures = failures
+	posts, warnings, err := collect.FetchFailed(ctx, cfg.Cutoff, items, options)
+	if err != nil {
+		logger.Error("Retry failed", "error", err)
+
+		return err
+	}
+	if warnings, err = reportWarnings(cfg, warnings, logger); err != nil {
+		return err
+	}
+	summary.recordWarnings(warnings)
+	left := failures.Items()
+	if err := saveDeadLetter(cfg.DeadLetterPath, left); err != nil {
+		return err
+	}
+	logger.Info("Retry finished", "recovered", len(posts), "still_failing", len(left))
+
+	if cfg.StatePath != "" {
+		if err := updateState(cfg.StatePath, posts, logger); err != nil {
+			return err
+		}
+		state, err := loadState(cfg.StatePath)
+		if err != nil {
+			return err
+		}
+		var recorded model.Posts
+		for _, post := range state.Posts {
+			if !post.Date.Before(cfg.Cutoff) {
+				recorded = append(recorded, post)
+			}
+		}
+		posts = recorded
+	}
+
+	collected := len(posts)
+	posts, _ = dedupePosts(posts)
+	filtered, stats := filterPosts(posts, cfg)
+	filtered = limitPosts(filtered, cfg, &stats)
+	summary.recordPosts(collected, len(posts), len(filtered), stats)
+	filtered = applyGrouping(filtered, cfg.GroupMode, cfg.GroupSort)
+
+	// Entries not reached before an interruption are still in the
+	// dead-letter file; the table is written but marked.
+	interrupted := ctx.Err() != nil
+	if err := writeOutput(cfg, filtered, interrupted, logger); err != nil {
+		return err
+	}
+	if interrupted {
+		return ErrInterrupted
+	}
+
+	return checkWarnings(cfg, warnings)
+}
@@ -0,0 +1,271 @@
+// Test code reads a t.TempDir()-controlled path; gosec G304 is a
+// false positive here.
+//
+//nolint:gosec
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"git.skobk.in/skobkin/jnovel-scrape/internal/collect"
+	"git.skobk.in/skobkin/jnovel-scrape/internal/httpx"
+	"git.skobk.in/skobkin/jnovel-scrape/internal/markdown"
+	"git.skobk.in/skobkin/jnovel-scrape/internal/model"
+)
+
+func TestRecordFailuresMergesByURL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "failed.json")
+	cfg := Config{DeadLetterPath: path}
+	logger := NewLogger(io.Discard)
+
+	first := []collect.FailedFetch{
+		{Kind: collect.FailureHTMLDetail, URL: "https://example.com/a/", Error: "503"},
+		{Kind: collect.FailureHTMLDetail, URL: "https://example.com/b/", Error: "503"},
+	}
+	if err := recordFailures(cfg, first, logger); err != nil {
+		t.Fatalf("recordFailures() error: %v", err)
+	}
+	second := []collect.FailedFetch{{Kind: collect.FailureHTMLDetail, URL: "https://example.com/b/", Error: "timeout"}}
+	if err := recordFailures(cfg, second, logger); err != nil {
+		t.Fatalf("recordFailures() error: %v", err)
+	}
+
+	items, err := loadDeadLetter(path)
+	if err != nil {
+		t.Fatalf("loadDeadLetter() error: %v", err)
+	}
+	if len(items) != 2 || items[1].Error != "timeout" {
+		t.Fatalf("unexpected dead-letter entries: %+v", items)
+	}
+
+	if err := saveDeadLetter(path, nil); err != nil {
+		t.Fatalf("saveDeadLetter() error: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected empty dead-letter file to be removed, stat err=%v", err)
+	}
+}
+
+func TestRunRetryFailedMergesIntoState(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/recovered-volume-2-epub/" {
+			fmt.Fprint(w, `<html><body><time datetime="2025-10-14T00:00:00Z"></time></body></html>`)
+
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	statePath := filepath.Join(dir, "state.json")
+	deadLetter := filepath.Join(dir, "failed.json")
+	outPath := filepath.Join(dir, "out.md")
+
+	day := time.Date(2025, time.October, 15, 0, 0, 0, 0, time.UTC)
+	if err := saveState(statePath, State{Posts: model.Posts{{Title: "Known", Link: "https://example.com/known-volume-1-epub/", Date: day, Type: model.TypeEPUB}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveDeadLetter(deadLetter, []collect.FailedFetch{
+		{Kind: collect.FailureHTMLDetail, URL: server.URL + "/recovered-volume-2-epub/", Title: "Recovered Volume 2 EPUB"},
+		{Kind: collect.FailureHTMLDetail, URL: server.URL + "/still-broken/", Title: "Still Broken"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := Config{
+		Command:        CommandRetryFailed,
+		Cutoff:         time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC),
+		DeadLetterPath: deadLetter,
+		StatePath:      statePath,
+		OutputPath:     outPath,
+		Columns:        markdown.DefaultColumns,
+		TypeFilters:    map[model.PostType]struct{}{},
+	}
+	client := httpx.NewClient(time.Millisecond, 5*time.Millisecond, httpx.WithHTTPClient(server.Client()), httpx.WithJitterFactor(0))
+	options := collect.Options{BaseURL: server.URL, Client: client}
+
+	if err := runRetryFailed(context.Background(), cfg, options, nil, NewLogger(io.Discard)); err != nil {
+		t.Fatalf("runRetryFailed() error: %v", err)
+	}
+
+	out, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "| Known |") || !strings.Contains(string(out), "| Recovered |") {
+		t.Fatalf("output should hold recorded and recovered posts:\n%s", out)
+	}
+	left, err := loadDeadLetter(deadLetter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(left) != 1 || left[0].Title != "Still Broken" {
+		t.Fatalf("unexpected remaining failures: %+v", left)
+	}
+}
+
+func TestRunScrapeRecordsFailedArchivePage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/", "":
+			fmt.Fprint(w, `<html><body>
+				<article id="post-1"><h2 class="entry-title"><a href="/first-volume-1-epub/">First Volume 1 EPUB</a></h2>
+					<time datetime="2025-10-15T00:00:00Z"></time></article>
+			</body></html>`)
+		default:
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	deadLetter := filepath.Join(dir, "failed.json")
+	outPath := filepath.Join(dir, "out.md")
+	cfg := Config{
+		Command:        CommandScrape,
+		Mode:           ModeHTML,
+		Cutoff:         time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC),
+		DeadLetterPath: deadLetter,
+		OutputPath:     outPath,
+		Columns:        markdown.DefaultColumns,
+		TypeFilters:    map[model.PostType]struct{}{},
+	}
+	client := httpx.NewClient(time.Millisecond, 5*time.Millisecond, httpx.WithHTTPClient(server.Client()), httpx.WithJitterFactor(0))
+	options := collect.Options{BaseURL: server.URL, MaxPages: 3, Concurrency: 1, Client: client, RetryBackoff: time.Millisecond}
+
+	err := runScrape(context.Background(), cfg, options, nil, NewLogger(io.Discard))
+	var partial *collect.PartialError
+	if !errors.As(err, &partial) {
+		t.Fatalf("expected the failed page to fail the run, got %v", err)
+	}
+
+	out, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("the output must be written: %v", err)
+	}
+	if !strings.Contains(string(out), "| First |") {
+		t.Fatalf("output should hold the posts collected:\n%s", out)
+	}
+	failed, err := loadDeadLetter(deadLetter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failed) != 1 || failed[0].Kind != collect.FailureHTMLPage || failed[0].URL != server.URL+"/page/2/" {
+		t.Fatalf("expected the archive page in the dead-letter file, got %+v", failed)
+	}
+}
+
+func TestRunScrapeKeepsAPIPostsWhenFallbackFails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/wp-json/wp/v2/posts" && r.URL.Query().Get("page") == "1":
+			w.Header().Set("X-WP-TotalPages", "2")
+			fmt.Fprint(w, `[{"id":1,"date":"2025-10-20T00:00:00","date_gmt":"2025-10-20T00:00:00",
+				"link":"https://example.com/api-volume-1-epub/","title":{"rendered":"API Volume 1 EPUB"}}]`)
+		case strings.HasPrefix(r.URL.Path, "/wp-json/wp/v2/categories"), strings.HasPrefix(r.URL.Path, "/wp-json/wp/v2/tags"):
+			fmt.Fprint(w, `[]`)
+		default:
+			// The second API page and every HTML archive fail.
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	deadLetter := filepath.Join(dir, "failed.json")
+	outPath := filepath.Join(dir, "out.md")
+	cfg := Config{
+		Command:        CommandScrape,
+		Mode:           ModeAuto,
+		Cutoff:         time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC),
+		DeadLetterPath: deadLetter,
+		OutputPath:     outPath,
+		Columns:        markdown.DefaultColumns,
+		TypeFilters:    map[model.PostType]struct{}{},
+	}
+	client := httpx.NewClient(time.Millisecond, 5*time.Millisecond, httpx.WithHTTPClient(server.Client()), httpx.WithJitterFactor(0))
+	options := collect.Options{BaseURL: server.URL, MaxPages: 3, Concurrency: 1, Client: client, RetryBackoff: time.Millisecond}
+
+	if err := runScrape(context.Background(), cfg, options, nil, NewLogger(io.Discard)); err == nil {
+		t.Fatalf("expected the failed fallback to fail the run")
+	}
+
+	out, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("the output must be written: %v", err)
+	}
+	if !strings.Contains(string(out), "| API |") {
+		t.Fatalf("output should keep the API posts:\n%s", out)
+	}
+	failed, err := loadDeadLetter(deadLetter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.ContainsFunc(failed, func(f collect.FailedFetch) bool { return f.Kind == collect.FailureAPIPage }) {
+		t.Fatalf("expected the failed API page in the dead-letter file, got %+v", failed)
+	}
+}
+
+func TestRunStreamRecordsFailedArchivePage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/", "":
+			fmt.Fprint(w, `<html><body>
+				<article id="post-1"><h2 class="entry-title"><a href="/first-volume-1-epub/">First Volume 1 EPUB</a></h2>
+					<time datetime="2025-10-15T00:00:00Z"></time></article>
+			</body></html>`)
+		default:
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	deadLetter := filepath.Join(dir, "failed.json")
+	outPath := filepath.Join(dir, "out.jsonl")
+	cfg := Config{
+		Command:        CommandScrape,
+		Mode:           ModeHTML,
+		Format:         FormatJSONL,
+		Cutoff:         time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC),
+		DeadLetterPath: deadLetter,
+		OutputPath:     outPath,
+		TypeFilters:    map[model.PostType]struct{}{},
+	}
+	if !streamable(cfg) {
+		t.Fatalf("expected a streamed run")
+	}
+	client := httpx.NewClient(time.Millisecond, 5*time.Millisecond, httpx.WithHTTPClient(server.Client()), httpx.WithJitterFactor(0))
+	options := collect.Options{BaseURL: server.URL, MaxPages: 3, Concurrency: 1, Client: client, RetryBackoff: time.Millisecond}
+
+	if err := runScrape(context.Background(), cfg, options, nil, NewLogger(io.Discard)); err == nil {
+		t.Fatalf("expected the failed page to fail the run")
+	}
+
+	out, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("the output must be written: %v", err)
+	}
+	if !strings.Contains(string(out), "first-volume-1-epub") {
+		t.Fatalf("output should hold the rows streamed before the failure:\n%s", out)
+	}
+	failed, err := loadDeadLetter(deadLetter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failed) != 1 || failed[0].Kind != collect.FailureHTMLPage {
+		t.Fatalf("expected the archive page in the dead-letter file, got %+v", failed)
+	}
+}
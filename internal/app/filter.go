@@ -1,15 +1,37 @@
 package app
 
 import (
+	"strings"
+
 	"git.skobk.in/skobkin/jnovel-scrape/internal/model"
 	"git.skobk.in/skobkin/jnovel-scrape/internal/util"
 )
 
 // FilterStats captures how many posts were removed per filter type.
 type FilterStats struct {
-	TypeDropped   int
-	TitleDropped  int
-	VolumeDropped int
+	TypeDropped     int `json:"type_dropped"`
+	TitleDropped    int `json:"title_dropped"`
+	VolumeDropped   int `json:"volume_dropped"`
+	TagDropped      int `json:"tag_dropped"`
+	CategoryDropped int `json:"category_dropped"`
+	AuthorDropped   int `json:"author_dropped"`
+	// LimitDropped counts posts past --limit or --limit-per-title.
+	LimitDropped int `json:"limit_dropped"`
+}
+
+// add sums other into s, for runs that filter several result sets.
+func (s *FilterStats) add(other FilterStats) {
+	s.TypeDropped += other.TypeDropped
+	s.TitleDropped += other.TitleDropped
+	s.VolumeDropped += other.VolumeDropped
+	s.TagDropped += other.TagDropped
+	s.CategoryDropped += other.CategoryDropped
+	s.AuthorDropped += other.AuthorDropped
+	s.LimitDropped += other.LimitDropped
+}
+
+func logFilterStats(logger *Logger, stats FilterStats) {
+	logger.Info("Filter stats", "type", stats.TypeDropped, "title", stats.TitleDropped, "author", stats.AuthorDropped, "tag", stats.TagDropped, "category", stats.CategoryDropped, "volume", stats.VolumeDropped, "limit", stats.LimitDropped)
 }
 
 func filterPosts(posts model.Posts, cfg Config) (model.Posts, FilterStats) {
@@ -20,52 +42,176 @@ func filterPosts(posts model.Posts, cfg Config) (model.Posts, FilterStats) {
 		filtered model.Posts
 		stats    FilterStats
 	)
-
 	for _, post := range posts {
-		if len(cfg.TypeFilters) > 0 {
-			if _, ok := cfg.TypeFilters[post.Type]; !ok {
-				stats.TypeDropped++
+		if keepPost(post, cfg, &stats) {
+			filtered = append(filtered, post)
+		}
+	}
 
-				continue
-			}
+	filtered.Sort()
+
+	return filtered, stats
+}
+
+// keepPost reports whether post passes the filters in cfg, counting the
+// filter that dropped it in stats. Streaming output applies it to posts
+// one at a time as they are collected.
+func keepPost(post model.Post, cfg Config, stats *FilterStats) bool {
+	if len(cfg.TypeFilters) > 0 {
+		if _, ok := cfg.TypeFilters[post.Type]; !ok {
+			stats.TypeDropped++
+
+			return false
 		}
+	}
 
-		if len(cfg.TitleFilters) > 0 {
-			matched := false
-			for _, title := range cfg.TitleFilters {
-				switch cfg.TitleMode {
-				case TitleModeWord:
-					if util.FoldedWordContains(post.Title, title) {
-						matched = true
-					}
-				default:
-					if util.FoldedContains(post.Title, title) {
-						matched = true
-					}
+	if len(cfg.TitleFilters) > 0 {
+		matched := false
+		for _, title := range cfg.TitleFilters {
+			switch cfg.TitleMode {
+			case TitleModeWord:
+				if util.FoldedWordContains(post.Title, title) {
+					matched = true
 				}
-				if matched {
-					break
+			default:
+				if util.FoldedContains(post.Title, title) {
+					matched = true
 				}
 			}
-			if !matched {
-				stats.TitleDropped++
-
-				continue
+			if matched {
+				break
 			}
 		}
+		if !matched {
+			stats.TitleDropped++
 
-		if cfg.VolumeFilter != nil {
-			if !post.VolumeEqual(*cfg.VolumeFilter) {
-				stats.VolumeDropped++
+			return false
+		}
+	}
 
-				continue
-			}
+	if len(cfg.AuthorFilters) > 0 && !matchesAnyFolded(post.Author, cfg.AuthorFilters) {
+		stats.AuthorDropped++
+
+		return false
+	}
+
+	if !matchesLabels(post.Tags, cfg.TagFilters, cfg.ExcludeTags) {
+		stats.TagDropped++
+
+		return false
+	}
+
+	if !matchesLabels(post.Categories, cfg.CategoryFilters, cfg.ExcludeCategories) {
+		stats.CategoryDropped++
+
+		return false
+	}
+
+	if cfg.VolumeFilter != nil {
+		if !post.VolumeEqual(*cfg.VolumeFilter) {
+			stats.VolumeDropped++
+
+			return false
 		}
+	}
+
+	return true
+}
 
-		filtered = append(filtered, post)
+// limitPosts applies --limit and --limit-per-title to posts sorted
+// newest first.
+func limitPosts(posts model.Posts, cfg Config, stats *FilterStats) model.Posts {
+	if cfg.Limit == 0 && cfg.LimitPerTitle == 0 {
+		return posts
+	}
+	limiter := newRowLimiter(cfg)
+	var limited model.Posts
+	for _, post := range posts {
+		if limiter.take(post, stats) {
+			limited = append(limited, post)
+		}
 	}
 
-	filtered.Sort()
+	return limited
+}
 
-	return filtered, stats
+// rowLimiter counts the posts taken against --limit and
+// --limit-per-title. Posts must be offered newest first, so the ones
+// that fit are the most recent.
+type rowLimiter struct {
+	limit    int
+	perTitle int
+	titles   map[string]int
+	taken    int
+}
+
+func newRowLimiter(cfg Config) *rowLimiter {
+	return &rowLimiter{limit: cfg.Limit, perTitle: cfg.LimitPerTitle, titles: make(map[string]int)}
+}
+
+// take reports whether post still fits within the limits and, if so,
+// counts it.
+func (l *rowLimiter) take(post model.Post, stats *FilterStats) bool {
+	if l.full() {
+		stats.LimitDropped++
+
+		return false
+	}
+	if l.perTitle > 0 {
+		// Titles match case-insensitively, as in --group=title.
+		key := strings.ToLower(post.Title)
+		if l.titles[key] >= l.perTitle {
+			stats.LimitDropped++
+
+			return false
+		}
+		l.titles[key]++
+	}
+	l.taken++
+
+	return true
+}
+
+// full reports whether --limit has been reached.
+func (l *rowLimiter) full() bool {
+	return l.limit > 0 && l.taken >= l.limit
+}
+
+// matchesAnyFolded reports whether any needle is a folded substring of
+// value. An empty value never matches, so posts without parsed metadata
+// are dropped by metadata filters.
+func matchesAnyFolded(value string, needles []string) bool {
+	if value == "" {
+		return false
+	}
+	for _, needle := range needles {
+		if util.FoldedContains(value, needle) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesLabels applies include/exclude label filters to a post's tags
+// or categories. With an include list the post must carry at least one
+// of the labels; any label from the exclude list drops it.
+func matchesLabels(labels, include, exclude []string) bool {
+	if len(include) > 0 && !hasAnyLabel(labels, include) {
+		return false
+	}
+
+	return !hasAnyLabel(labels, exclude)
+}
+
+func hasAnyLabel(labels, needles []string) bool {
+	for _, label := range labels {
+		for _, needle := range needles {
+			if util.FoldedEqual(label, needle) {
+				return true
+			}
+		}
+	}
+
+	return false
 }
@@ -343,3 +343,83 @@ func TestFilterPosts_TitleFilterSubstringModeUnchanged(t *testing.T) {
 		t.Fatalf("expected 1 drop, got %d", stats.TitleDropped)
 	}
 }
+
+func TestFilterPosts_TagAndCategoryFilters(t *testing.T) {
+	now := time.Now()
+	posts := model.Posts{
+		{Title: "Isekai Story", Tags: []string{"Isekai", "Fantasy"}, Categories: []string{"Light Novel"}, Date: now, Link: "https://example.com/1"},
+		{Title: "Harem Story", Tags: []string{"Isekai", "Harem"}, Categories: []string{"Light Novel"}, Date: now, Link: "https://example.com/2"},
+		{Title: "School Story", Tags: []string{"School Life"}, Categories: []string{"Manga"}, Date: now, Link: "https://example.com/3"},
+		{Title: "Reverse Story", Tags: []string{"Reverse Isekai"}, Categories: []string{"Light Novel"}, Date: now, Link: "https://example.com/4"},
+	}
+
+	cfg := Config{
+		TagFilters:        []string{"ISEKAI"},
+		ExcludeTags:       []string{"harem"},
+		ExcludeCategories: []string{"manga"},
+	}
+	filtered, stats := filterPosts(posts, cfg)
+	if got := titles(filtered); len(got) != 1 || got[0] != "Isekai Story" {
+		t.Fatalf("unexpected titles: %v", got)
+	}
+	if stats.TagDropped != 3 {
+		t.Fatalf("expected 3 tag drops, got %d", stats.TagDropped)
+	}
+
+	cfg = Config{CategoryFilters: []string{"mánga"}}
+	filtered, stats = filterPosts(posts, cfg)
+	if got := titles(filtered); len(got) != 1 || got[0] != "School Story" {
+		t.Fatalf("unexpected titles for category filter: %v", got)
+	}
+	if stats.CategoryDropped != 3 {
+		t.Fatalf("expected 3 category drops, got %d", stats.CategoryDropped)
+	}
+}
+
+func TestFilterPosts_AuthorFilter(t *testing.T) {
+	now := time.Now()
+	posts := model.Posts{
+		{Title: "A", Author: "Kisetsu Morita", Date: now, Link: "https://example.com/a"},
+		{Title: "B", Author: "Reki Kawahara", Date: now, Link: "https://example.com/b"},
+		{Title: "C", Date: now, Link: "https://example.com/c"},
+	}
+
+	filtered, stats := filterPosts(posts, Config{AuthorFilters: []string{"morita", "KAWAHARA"}})
+	if got := titles(filtered); len(got) != 2 || got[0] != "A" || got[1] != "B" {
+		t.Fatalf("unexpected titles: %v", got)
+	}
+	if stats.AuthorDropped != 1 {
+		t.Fatalf("expected 1 author drop, got %d", stats.AuthorDropped)
+	}
+}
+
+func TestLimitPosts(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, time.March, d, 0, 0, 0, 0, time.UTC) }
+	posts := model.Posts{
+		{Title: "Alpha", Date: day(9), Link: "https://example.com/a3"},
+		{Title: "alpha", Date: day(8), Link: "https://example.com/a2"},
+		{Title: "Beta", Date: day(7), Link: "https://example.com/b2"},
+		{Title: "Alpha", Date: day(6), Link: "https://example.com/a1"},
+		{Title: "Beta", Date: day(5), Link: "https://example.com/b1"},
+		{Title: "Gamma", Date: day(4), Link: "https://example.com/g1"},
+	}
+
+	var stats FilterStats
+	got := limitPosts(posts, Config{LimitPerTitle: 1, Limit: 2}, &stats)
+	if len(got) != 2 || got[0].Link != "https://example.com/a3" || got[1].Link != "https://example.com/b2" {
+		t.Fatalf("expected the newest Alpha and Beta, got %+v", got)
+	}
+	if stats.LimitDropped != 4 {
+		t.Fatalf("LimitDropped = %d, want 4", stats.LimitDropped)
+	}
+
+	stats = FilterStats{}
+	got = limitPosts(posts, Config{LimitPerTitle: 2}, &stats)
+	if len(got) != 5 || stats.LimitDropped != 1 {
+		t.Fatalf("expected only the third Alpha dropped, got %+v (dropped %d)", got, stats.LimitDropped)
+	}
+
+	if got := limitPosts(posts, Config{}, &stats); len(got) != len(posts) {
+		t.Fatalf("no limit must keep every post, got %d", len(got))
+	}
+}
@@ -1,41 +1,127 @@
 package app
 
 import (
+	"fmt"
 	"io"
-	"log"
+	"log/slog"
+	"os"
 	"sync"
+
+	"golang.org/x/term"
 )
 
-// Logger provides leveled logging onto stderr/stdout.
+// Logger provides leveled logging on top of log/slog. Debug, Info, Warn
+// and Error take slog key-value attributes; Errorf logs a formatted
+// message without any.
 type Logger struct {
-	mu  sync.Mutex
-	log *log.Logger
+	slog  *slog.Logger
+	out   *switchWriter
+	level *slog.LevelVar
+	// base is the configured level; divert raises level above INFO
+	// while a progress view shows the same information.
+	base slog.Level
 }
 
-// NewLogger builds a Logger that writes using the provided io.Writer.
+// NewLogger builds a Logger that writes text at info level using the
+// provided io.Writer.
 func NewLogger(w io.Writer) *Logger {
-	return &Logger{
-		log: log.New(w, "", log.LstdFlags),
+	return newLogger(w, slog.LevelInfo, LogFormatText)
+}
+
+// NewLoggerFor builds a Logger writing to w that follows cfg's
+// --log-level, --log-format and --quiet.
+func NewLoggerFor(w io.Writer, cfg Config) *Logger {
+	level := cfg.LogLevel.slogLevel()
+	if cfg.Quiet {
+		level = slog.LevelError
+	}
+
+	return newLogger(w, level, cfg.LogFormat)
+}
+
+func newLogger(w io.Writer, level slog.Level, format LogFormat) *Logger {
+	l := &Logger{
+		out:   &switchWriter{w: w, orig: w},
+		level: &slog.LevelVar{},
+		base:  level,
+	}
+	l.level.Set(level)
+	options := &slog.HandlerOptions{Level: l.level}
+	if format == LogFormatJSON {
+		l.slog = slog.New(slog.NewJSONHandler(l.out, options))
+	} else {
+		l.slog = slog.New(slog.NewTextHandler(l.out, options))
+	}
+
+	return l
+}
+
+// switchWriter passes writes on to a writer that divert can swap.
+type switchWriter struct {
+	mu   sync.Mutex
+	w    io.Writer
+	orig io.Writer
+}
+
+func (s *switchWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.w.Write(p)
+}
+
+// terminal returns the file the logger writes to when it is a terminal.
+func (l *Logger) terminal() (*os.File, bool) {
+	f, ok := l.out.orig.(*os.File)
+	if !ok || !isTerminal(f) {
+		return nil, false
 	}
+
+	return f, true
+}
+
+// divert sends the messages to w instead and drops INFO and DEBUG ones
+// until restore is called.
+func (l *Logger) divert(w io.Writer) {
+	l.out.mu.Lock()
+	defer l.out.mu.Unlock()
+	l.out.w = w
+	l.level.Set(max(l.base, slog.LevelWarn))
 }
 
-func (l *Logger) output(level, format string, args ...any) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	l.log.Printf(level+" "+format, args...)
+// restore undoes divert.
+func (l *Logger) restore() {
+	l.out.mu.Lock()
+	defer l.out.mu.Unlock()
+	l.out.w = l.out.orig
+	l.level.Set(l.base)
 }
 
-// Infof prints informational message.
-func (l *Logger) Infof(format string, args ...any) {
-	l.output("INFO", format, args...)
+// Debug logs msg with attributes at debug level.
+func (l *Logger) Debug(msg string, args ...any) {
+	l.slog.Debug(msg, args...)
 }
 
-// Warnf prints warning message.
-func (l *Logger) Warnf(format string, args ...any) {
-	l.output("WARN", format, args...)
+// Info logs msg with attributes at info level.
+func (l *Logger) Info(msg string, args ...any) {
+	l.slog.Info(msg, args...)
+}
+
+// Warn logs msg with attributes at warn level.
+func (l *Logger) Warn(msg string, args ...any) {
+	l.slog.Warn(msg, args...)
+}
+
+// Error logs msg with attributes at error level.
+func (l *Logger) Error(msg string, args ...any) {
+	l.slog.Error(msg, args...)
 }
 
 // Errorf prints error message.
 func (l *Logger) Errorf(format string, args ...any) {
-	l.output("ERROR", format, args...)
+	l.slog.Error(fmt.Sprintf(format, args...))
+}
+
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd())) //nolint:gosec // G115: file descriptors fit in an int.
 }
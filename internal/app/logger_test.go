@@ -2,6 +2,7 @@ package app
 
 import (
 	"bytes"
+	"encoding/json"
 	"strings"
 	"testing"
 )
@@ -10,14 +11,40 @@ func TestLoggerLevels(t *testing.T) {
 	var buf bytes.Buffer
 	logger := NewLogger(&buf)
 
-	logger.Infof("info %d", 1)
-	logger.Warnf("warn %s", "two")
-	logger.Errorf("error %s", "three")
+	logger.Debug("debug is off by default")
+	logger.Info("info one", "count", 1)
+	logger.Warn("warn two", "code", "missing_volume")
+	logger.Error("error three", "url", "https://example.com/a")
 
 	output := buf.String()
-	for _, token := range []string{"INFO info 1", "WARN warn two", "ERROR error three"} {
+	if strings.Contains(output, "debug") {
+		t.Fatalf("debug messages must be dropped at info level, got %q", output)
+	}
+	for _, token := range []string{`level=INFO msg="info one" count=1`, `level=WARN msg="warn two" code=missing_volume`, `level=ERROR msg="error three" url=https://example.com/a`} {
 		if !strings.Contains(output, token) {
 			t.Fatalf("expected log output to contain %q, got %q", token, output)
 		}
 	}
 }
+
+func TestNewLoggerFor(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLoggerFor(&buf, Config{LogLevel: LogLevelDebug, LogFormat: LogFormatJSON})
+	logger.Debug("API page fetched", "page", 3, "url", "https://example.com/p")
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("expected a JSON record, got %q: %v", buf.String(), err)
+	}
+	if record["level"] != "DEBUG" || record["msg"] != "API page fetched" || record["page"] != 3.0 || record["url"] != "https://example.com/p" {
+		t.Fatalf("unexpected record: %v", record)
+	}
+
+	buf.Reset()
+	quiet := NewLoggerFor(&buf, Config{LogLevel: LogLevelDebug, Quiet: true})
+	quiet.Warn("dropped")
+	quiet.Error("kept")
+	if strings.Contains(buf.String(), "dropped") || !strings.Contains(buf.String(), "kept") {
+		t.Fatalf("--quiet must log errors only, got %q", buf.String())
+	}
+}
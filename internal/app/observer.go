@@ -0,0 +1,31 @@
+package app
+
+import (
+	"time"
+
+	"git.skobk.in/skobkin/jnovel-scrape/internal/event"
+)
+
+// logObserver logs the crawl events nothing else reports: retries and
+// rate-limit waits inside the HTTP client, and, at debug level, skipped
+// posts and second-pass retries. The collectors log their own pages and
+// fallbacks.
+type logObserver struct {
+	logger *Logger
+}
+
+// Observe implements event.Observer.
+func (o logObserver) Observe(e event.Event) {
+	switch e := e.(type) {
+	case event.RateLimited:
+		o.logger.Warn("Rate limited", "url", e.URL, "status", e.Status, "wait", e.Wait.Round(time.Millisecond))
+	case event.RetryScheduled:
+		if e.SecondPass {
+			o.logger.Debug("Queued for the second pass", "url", e.URL, "error", e.Err)
+		} else {
+			o.logger.Info("Request failed; retrying", "url", e.URL, "attempt", e.Attempt, "error", e.Err, "wait", e.Wait.Round(time.Millisecond))
+		}
+	case event.PostSkipped:
+		o.logger.Debug("Post skipped", "source", e.Source, "url", e.URL, "reason", e.Reason)
+	}
+}
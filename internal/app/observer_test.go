@@ -0,0 +1,33 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"git.skobk.in/skobkin/jnovel-scrape/internal/event"
+)
+
+func TestLogObserver(t *testing.T) {
+	var buf bytes.Buffer
+	observer := logObserver{logger: NewLogger(&buf)}
+
+	observer.Observe(event.RateLimited{URL: "https://example.com/a", Status: 429, Wait: 1500 * time.Millisecond})
+	observer.Observe(event.RetryScheduled{URL: "https://example.com/b", Attempt: 2, Wait: time.Second, Err: errors.New("server error: 502 Bad Gateway")})
+	observer.Observe(event.RetryScheduled{URL: "https://example.com/c", Attempt: 1, Err: errors.New("403"), SecondPass: true})
+	observer.Observe(event.PageFetched{Page: 1})
+	observer.Observe(event.PostSkipped{URL: "https://example.com/d", Reason: "date before cutoff"})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected only the client retry and rate limit to be logged at info level, got:\n%s", buf.String())
+	}
+	if !strings.Contains(lines[0], `level=WARN msg="Rate limited" url=https://example.com/a status=429 wait=1.5s`) {
+		t.Fatalf("unexpected rate-limit line: %s", lines[0])
+	}
+	if !strings.Contains(lines[1], `level=INFO msg="Request failed; retrying" url=https://example.com/b attempt=2 error="server error: 502 Bad Gateway" wait=1s`) {
+		t.Fatalf("unexpected retry line: %s", lines[1])
+	}
+}
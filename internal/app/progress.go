@@ -0,0 +1,217 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+
+	"git.skobk.in/skobkin/jnovel-scrape/internal/event"
+)
+
+// progressInterval is how often the progress line is redrawn, which
+// also drives the rate-limit countdown.
+const progressInterval = 250 * time.Millisecond
+
+// clearLine returns the cursor to the start of the line and erases it.
+const clearLine = "\r\x1b[K"
+
+// progress is the live status line shown instead of the INFO log when
+// stderr is a terminal. It follows the crawl through its events; WARN
+// and ERROR messages are still printed, above the line.
+type progress struct {
+	mu  sync.Mutex
+	out io.Writer
+	cfg Config
+	// width returns the terminal width; zero leaves lines untruncated.
+	width func() int
+	now   func() time.Time
+
+	mode     string
+	page     int
+	total    int
+	inFlight int
+	kept     int
+	seen     map[string]struct{}
+	retries  int
+	// status and resumeAt describe the rate-limit wait in progress.
+	status   int
+	resumeAt time.Time
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newProgress(out io.Writer, cfg Config) *progress {
+	mode := string(cfg.Mode)
+	if cfg.Command != CommandScrape && cfg.Command != "" {
+		mode = string(cfg.Command)
+	}
+
+	return &progress{
+		out:   out,
+		cfg:   cfg,
+		width: func() int { return 0 },
+		now:   time.Now,
+		mode:  mode,
+		seen:  make(map[string]struct{}),
+	}
+}
+
+// startProgress shows the progress line on the logger's terminal and
+// hides INFO messages until stop is called. It returns nil when
+// --no-progress or --quiet is set, logs are JSON, stderr is not a
+// terminal, or the output goes to the same terminal, where the line
+// would be drawn over it.
+func startProgress(cfg Config, logger *Logger) *progress {
+	if cfg.NoProgress || cfg.Quiet || cfg.LogFormat == LogFormatJSON {
+		return nil
+	}
+	f, ok := logger.terminal()
+	if !ok || (cfg.OutputPath == "" && isTerminal(os.Stdout)) {
+		return nil
+	}
+
+	p := newProgress(f, cfg)
+	p.width = func() int {
+		width, _, err := term.GetSize(int(f.Fd())) //nolint:gosec // G115: file descriptors fit in an int.
+		if err != nil {
+			return 0
+		}
+
+		return width
+	}
+	p.done = make(chan struct{})
+	logger.divert(p)
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+		for {
+			p.mu.Lock()
+			p.draw()
+			p.mu.Unlock()
+			select {
+			case <-ticker.C:
+			case <-p.done:
+				return
+			}
+		}
+	}()
+
+	return p
+}
+
+// stop leaves the last state of the line on screen and gives the
+// terminal back to the logger.
+func (p *progress) stop(logger *Logger) {
+	close(p.done)
+	p.wg.Wait()
+	p.mu.Lock()
+	p.draw()
+	fmt.Fprintln(p.out)
+	p.mu.Unlock()
+	logger.restore()
+}
+
+// Write prints a log message above the progress line.
+func (p *progress) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, err := io.WriteString(p.out, clearLine); err != nil {
+		return 0, err
+	}
+	n, err := p.out.Write(b)
+	p.draw()
+
+	return n, err
+}
+
+// Observe implements event.Observer.
+func (p *progress) Observe(e event.Event) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch e := e.(type) {
+	case event.PageFetched:
+		p.mode = string(e.Source)
+		p.page = e.Page
+		p.total = e.Total
+	case event.FallbackTriggered:
+		if e.To == string(ModeHTML) {
+			p.mode = e.To
+			p.page, p.total = 0, 0
+		}
+	case event.DetailStarted:
+		p.inFlight++
+	case event.DetailFinished:
+		p.inFlight--
+	case event.PostParsed:
+		p.keep(e)
+	case event.RetryScheduled:
+		p.retries++
+	case event.RateLimited:
+		p.retries++
+		p.status = e.Status
+		p.resumeAt = p.now().Add(e.Wait)
+	}
+}
+
+// keep counts a parsed post the run will keep, as far as the cutoff
+// and filters tell; --limit is not applied.
+func (p *progress) keep(e event.PostParsed) {
+	if e.Post.Date.Before(p.cfg.Cutoff) {
+		return
+	}
+	if e.Post.Link != "" {
+		if _, ok := p.seen[e.Post.Link]; ok {
+			return
+		}
+		p.seen[e.Post.Link] = struct{}{}
+	}
+	var stats FilterStats
+	if keepPost(e.Post, p.cfg, &stats) {
+		p.kept++
+	}
+}
+
+// render formats the progress line at now.
+func (p *progress) render(now time.Time) string {
+	fields := []string{"mode=" + p.mode}
+	switch {
+	case p.page == 0:
+		fields = append(fields, "page=-")
+	case p.total > 0:
+		fields = append(fields, fmt.Sprintf("page=%d/%d", p.page, p.total))
+	default:
+		fields = append(fields, fmt.Sprintf("page=%d", p.page))
+	}
+	fields = append(fields,
+		fmt.Sprintf("in-flight=%d", p.inFlight),
+		fmt.Sprintf("kept=%d", p.kept),
+		fmt.Sprintf("retries=%d", p.retries),
+	)
+	if left := p.resumeAt.Sub(now); left > 0 {
+		// Rounded up, so the countdown never shows 0s while waiting.
+		left = (left + time.Second - 1).Truncate(time.Second)
+		fields = append(fields, fmt.Sprintf("rate-limited=%d resuming in %s", p.status, left))
+	}
+
+	return strings.Join(fields, " ")
+}
+
+// draw redraws the line; p.mu must be held.
+func (p *progress) draw() {
+	line := p.render(p.now())
+	if width := p.width(); width > 1 {
+		// A line that wraps can no longer be cleared with \r.
+		if runes := []rune(line); len(runes) >= width {
+			line = string(runes[:width-1])
+		}
+	}
+	_, _ = io.WriteString(p.out, clearLine+line)
+}
@@ -0,0 +1,80 @@
+package app
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"git.skobk.in/skobkin/jnovel-scrape/internal/event"
+	"git.skobk.in/skobkin/jnovel-scrape/internal/model"
+)
+
+func TestProgressRender(t *testing.T) {
+	now := time.Date(2025, time.October, 20, 12, 0, 0, 0, time.UTC)
+	cfg := Config{Command: CommandScrape, Mode: ModeAuto, Cutoff: time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC), TitleFilters: []string{"mage"}}
+	p := newProgress(&bytes.Buffer{}, cfg)
+	p.now = func() time.Time { return now }
+
+	if got := p.render(now); got != "mode=auto page=- in-flight=0 kept=0 retries=0" {
+		t.Fatalf("unexpected initial line: %s", got)
+	}
+
+	p.Observe(event.PageFetched{Source: event.SourceAPI, Page: 3, Total: 12})
+	for _, post := range []model.Post{
+		{Title: "Mage Academy", Link: "https://example.com/a", Date: cfg.Cutoff.AddDate(0, 0, 5)},
+		{Title: "Mage Academy", Link: "https://example.com/a", Date: cfg.Cutoff.AddDate(0, 0, 5)},
+		{Title: "Other", Link: "https://example.com/b", Date: cfg.Cutoff.AddDate(0, 0, 5)},
+		{Title: "Mage Academy", Link: "https://example.com/c", Date: cfg.Cutoff.AddDate(0, 0, -5)},
+	} {
+		p.Observe(event.PostParsed{Source: event.SourceAPI, Post: post})
+	}
+	p.Observe(event.RetryScheduled{URL: "https://example.com/d", Attempt: 1})
+	p.Observe(event.RateLimited{URL: "https://example.com/e", Status: 429, Wait: 60 * time.Second})
+	want := "mode=api page=3/12 in-flight=0 kept=1 retries=2 rate-limited=429 resuming in 1m0s"
+	if got := p.render(now); got != want {
+		t.Fatalf("unexpected line:\n%s\nwant:\n%s", got, want)
+	}
+	if got := p.render(now.Add(59500 * time.Millisecond)); !strings.HasSuffix(got, "resuming in 1s") {
+		t.Fatalf("the countdown must round up, got %s", got)
+	}
+	if got := p.render(now.Add(time.Minute)); strings.Contains(got, "rate-limited") {
+		t.Fatalf("the countdown must end with the wait, got %s", got)
+	}
+
+	p.Observe(event.FallbackTriggered{From: "api", To: "html"})
+	p.Observe(event.DetailStarted{URL: "https://example.com/f"})
+	p.Observe(event.DetailStarted{URL: "https://example.com/g"})
+	p.Observe(event.DetailFinished{URL: "https://example.com/f"})
+	if got := p.render(now.Add(time.Minute)); got != "mode=html page=- in-flight=1 kept=1 retries=2" {
+		t.Fatalf("unexpected line after fallback: %s", got)
+	}
+}
+
+func TestProgressPrintsLogAboveLine(t *testing.T) {
+	var buf bytes.Buffer
+	p := newProgress(&buf, Config{Mode: ModeHTML})
+	p.width = func() int { return 20 }
+	logger := NewLogger(&bytes.Buffer{})
+	logger.divert(p)
+
+	logger.Info("hidden while the line is shown")
+	logger.Warn("kept")
+
+	out := buf.String()
+	if strings.Contains(out, "hidden") {
+		t.Fatalf("INFO must be hidden, got %q", out)
+	}
+	if !strings.HasPrefix(out, clearLine) || !strings.Contains(out, "level=WARN msg=kept\n") {
+		t.Fatalf("expected the warning on a cleared line, got %q", out)
+	}
+	if !strings.HasSuffix(out, clearLine+"mode=html page=- in") {
+		t.Fatalf("expected the line redrawn and cut to the width, got %q", out)
+	}
+
+	logger.restore()
+	logger.Info("shown again")
+	if strings.Contains(buf.String(), "shown again") {
+		t.Fatalf("restore must write to the original output")
+	}
+}
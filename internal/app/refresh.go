@@ -0,0 +1,241 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"git.skobk.in/skobkin/jnovel-scrape/internal/collect"
+	"git.skobk.in/skobkin/jnovel-scrape/internal/model"
+	"git.skobk.in/skobkin/jnovel-scrape/internal/util"
+)
+
+// ChangeKind classifies one difference found by the refresh command.
+type ChangeKind string
+
+const (
+	// ChangeRetitled marks a post whose title changed.
+	ChangeRetitled ChangeKind = "retitled"
+	// ChangeVolume marks a post whose parsed volume changed.
+	ChangeVolume ChangeKind = "volume"
+	// ChangeType marks a post whose inferred type changed.
+	ChangeType ChangeKind = "type"
+	// ChangeRecategorized marks a post whose categories or tags changed.
+	ChangeRecategorized ChangeKind = "recategorized"
+	// ChangeModified marks a post that was edited without a visible
+	// change to the fields above, typically a re-upload.
+	ChangeModified ChangeKind = "modified"
+	// ChangeDeleted marks a post the site no longer returns.
+	ChangeDeleted ChangeKind = "deleted"
+)
+
+// Change describes one difference between a recorded post and its
+// current version. Old and New hold the compared values; New is blank for
+// deleted posts.
+type Change struct {
+	Kind  ChangeKind
+	Title string
+	Link  string
+	Old   string
+	New   string
+}
+
+// runRefresh re-reads the posts recorded in the state file, writes a
+// change report in place of the usual table and stores the fresh copies.
+// Deleted posts are dropped from the state.
+func runRefresh(ctx context.Context, cfg Config, options collect.Options, summary *runSummary, logger *Logger) error {
+	state, err := loadState(cfg.StatePath)
+	if err != nil {
+		return err
+	}
+	var known model.Posts
+	for _, post := range state.Posts {
+		if !post.Date.Before(cfg.Cutoff) {
+			known = append(known, post)
+		}
+	}
+	logger.Info("Refresh: checking known posts", "posts", len(known), "known", len(state.Posts), "by", cfg.RefreshBy)
+
+	var (
+		fresh    model.Posts
+		warnings []collect.Warning
+	)
+	switch cfg.RefreshBy {
+	case RefreshByModified:
+		fresh, warnings, err = collect.FetchAPIModifiedSince(ctx, latestModified(state), options)
+	default:
+		ids := make([]int64, 0, len(known))
+		for _, post := range known {
+			if post.SourceID == 0 {
+				logger.Warn("Post has no ID; cannot refresh by ID", "url", post.Link)
+
+				continue
+			}
+			ids = append(ids, post.SourceID)
+		}
+		fresh, warnings, err = collect.FetchAPIByID(ctx, ids, options)
+	}
+	if err != nil {
+		logger.Error("Refresh failed", "error", err)
+
+		return err
+	}
+	if warnings, err = reportWarnings(cfg, warnings, logger); err != nil {
+		return err
+	}
+	summary.recordWarnings(warnings)
+
+	changes, updated := diffRefresh(known, fresh, cfg.RefreshBy == RefreshByIDs)
+	if err := writeRefreshOutput(cfg, changes); err != nil {
+		return err
+	}
+	logger.Info("Refresh finished", "changes", len(changes))
+	summary.recordPosts(len(fresh), len(fresh), len(changes), FilterStats{})
+
+	deleted := make(map[string]struct{})
+	for _, c := range changes {
+		if c.Kind == ChangeDeleted {
+			deleted[c.Link] = struct{}{}
+		}
+	}
+	state.merge(updated)
+	state.Posts = slices.DeleteFunc(state.Posts, func(post model.Post) bool {
+		_, gone := deleted[post.Link]
+
+		return gone
+	})
+	state.UpdatedAt = time.Now().UTC()
+	if err := saveState(cfg.StatePath, state); err != nil {
+		return err
+	}
+
+	return checkWarnings(cfg, warnings)
+}
+
+// latestModified returns the newest edit time recorded in state. Only
+// the server's own timestamps count: UpdatedAt is the local clock and
+// moves on every scrape run, even one that did not look at older posts.
+// With no edit time recorded the zero time lists every post.
+func latestModified(state State) time.Time {
+	var latest time.Time
+	for _, post := range state.Posts {
+		if post.Modified.After(latest) {
+			latest = post.Modified
+		}
+	}
+
+	return latest
+}
+
+// diffRefresh matches fresh posts to known ones by SourceID, then
+// canonical link, and reports what changed. Fresh posts that match
+// nothing are ignored: they are new, and the next scrape run picks them
+// up. When complete is set, fresh is the full answer for every known ID
+// and known posts missing from it are reported as deleted. The second
+// return value lists the fresh copies of matched posts.
+func diffRefresh(known, fresh model.Posts, complete bool) ([]Change, model.Posts) {
+	byID := make(map[int64]int, len(fresh))
+	byLink := make(map[string]int, len(fresh))
+	for i, post := range fresh {
+		if post.SourceID != 0 {
+			byID[post.SourceID] = i
+		}
+		byLink[model.CanonicalLink(post.Link)] = i
+	}
+
+	var (
+		out     []Change
+		updated model.Posts
+	)
+	for _, old := range known {
+		idx, ok := byID[old.SourceID]
+		if !ok {
+			idx, ok = byLink[model.CanonicalLink(old.Link)]
+		}
+		if !ok {
+			if complete && old.SourceID != 0 {
+				out = append(out, Change{Kind: ChangeDeleted, Title: old.Title, Link: old.Link, Old: old.FormatDate()})
+			}
+
+			continue
+		}
+		current := fresh[idx]
+		updated = append(updated, current)
+
+		before := len(out)
+		if old.Title != current.Title {
+			out = append(out, Change{Kind: ChangeRetitled, Title: current.Title, Link: current.Link, Old: old.Title, New: current.Title})
+		}
+		o := util.FormatVolumeWithExtra(old.Volume, old.VolumeExtra)
+		n := util.FormatVolumeWithExtra(current.Volume, current.VolumeExtra)
+		if o != n {
+			out = append(out, Change{Kind: ChangeVolume, Title: current.Title, Link: current.Link, Old: o, New: n})
+		}
+		if old.Type != current.Type {
+			out = append(out, Change{Kind: ChangeType, Title: current.Title, Link: current.Link, Old: string(old.Type), New: string(current.Type)})
+		}
+		if o, n := formatTaxonomy(old), formatTaxonomy(current); o != n {
+			out = append(out, Change{Kind: ChangeRecategorized, Title: current.Title, Link: current.Link, Old: o, New: n})
+		}
+		if len(out) == before && current.Modified.After(old.Modified) && !old.Modified.IsZero() {
+			out = append(out, Change{Kind: ChangeModified, Title: current.Title, Link: current.Link,
+				Old: old.Modified.Format(time.DateTime), New: current.Modified.Format(time.DateTime)})
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Kind != out[j].Kind {
+			return out[i].Kind < out[j].Kind
+		}
+
+		return out[i].Link < out[j].Link
+	})
+
+	return out, updated
+}
+
+// formatTaxonomy renders categories and tags in a stable, comparable form.
+func formatTaxonomy(post model.Post) string {
+	categories := slices.Sorted(slices.Values(post.Categories))
+	tags := slices.Sorted(slices.Values(post.Tags))
+
+	return "categories: " + strings.Join(categories, ", ") + "; tags: " + strings.Join(tags, ", ")
+}
+
+func writeRefreshOutput(cfg Config, changes []Change) error {
+	if cfg.OutputPath == "" {
+		return writeRefreshReport(os.Stdout, changes)
+	}
+	file, err := os.Create(cfg.OutputPath)
+	if err != nil {
+		return fmt.Errorf("open output path: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	return writeRefreshReport(file, changes)
+}
+
+func writeRefreshReport(w io.Writer, changes []Change) error {
+	if _, err := fmt.Fprintf(w, "Refresh: %d changes\n\n", len(changes)); err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	if _, err := fmt.Fprint(w, "| Kind | Title | Old | New | Link |\n|---|---|---|---|---|\n"); err != nil {
+		return err
+	}
+	for _, c := range changes {
+		if _, err := fmt.Fprintf(w, "| %s | %s | %s | %s | [link](%s) |\n",
+			c.Kind, util.EscapePipes(c.Title), util.EscapePipes(c.Old), util.EscapePipes(c.New), c.Link); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
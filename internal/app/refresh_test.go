@@ -0,0 +1,79 @@
+package app
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"git.skobk.in/skobkin/jnovel-scrape/internal/model"
+)
+
+func TestDiffRefresh(t *testing.T) {
+	day := time.Date(2025, time.October, 10, 12, 0, 0, 0, time.UTC)
+	v1, v2 := 1.0, 2.0
+	known := model.Posts{
+		{Title: "Same", SourceID: 1, Link: "https://example.com/same/", Date: day, Type: model.TypeEPUB, Volume: &v1, Modified: day},
+		{Title: "Wrong", SourceID: 2, Link: "https://example.com/wrong/", Date: day, Type: model.TypeEPUB, Volume: &v1, Categories: []string{"Light Novel"}},
+		{Title: "Reuploaded", SourceID: 3, Link: "https://example.com/reuploaded/", Date: day, Type: model.TypePDF, Modified: day},
+		{Title: "Gone", SourceID: 4, Link: "https://example.com/gone/", Date: day, Type: model.TypePDF},
+	}
+	fresh := model.Posts{
+		{Title: "Same", SourceID: 1, Link: "https://example.com/same/", Date: day, Type: model.TypeEPUB, Volume: &v1, Modified: day},
+		{Title: "Right", SourceID: 2, Link: "https://example.com/wrong/", Date: day, Type: model.TypeEPUB, Volume: &v2, Categories: []string{"Manga"}},
+		{Title: "Reuploaded", SourceID: 3, Link: "https://example.com/reuploaded/", Date: day, Type: model.TypePDF, Modified: day.Add(time.Hour)},
+		{Title: "Brand new", SourceID: 5, Link: "https://example.com/brand-new/", Date: day},
+	}
+
+	changes, updated := diffRefresh(known, fresh, true)
+	kinds := make([]string, len(changes))
+	for i, c := range changes {
+		kinds[i] = string(c.Kind) + ":" + c.Title
+	}
+	want := []string{
+		"deleted:Gone",
+		"modified:Reuploaded",
+		"recategorized:Right",
+		"retitled:Right",
+		"volume:Right",
+	}
+	if strings.Join(kinds, ",") != strings.Join(want, ",") {
+		t.Fatalf("diffRefresh() = %v, want %v", kinds, want)
+	}
+	if len(updated) != 3 {
+		t.Fatalf("expected 3 updated posts, got %d", len(updated))
+	}
+
+	changes, _ = diffRefresh(known, fresh[:1], false)
+	if len(changes) != 0 {
+		t.Fatalf("incomplete refresh must not report deletions: %+v", changes)
+	}
+
+	var buf bytes.Buffer
+	if err := writeRefreshReport(&buf, changes); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "Refresh: 0 changes") {
+		t.Fatalf("unexpected report: %q", buf.String())
+	}
+}
+
+func TestLatestModified(t *testing.T) {
+	edited := time.Date(2025, time.March, 4, 10, 0, 0, 0, time.UTC)
+	state := State{
+		// A later scrape run moved UpdatedAt past edits it never saw.
+		UpdatedAt: time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC),
+		Posts: model.Posts{
+			{Link: "https://example.com/a/", Modified: edited.Add(-time.Hour)},
+			{Link: "https://example.com/b/", Modified: edited},
+			{Link: "https://example.com/c/"},
+		},
+	}
+	if got := latestModified(state); !got.Equal(edited) {
+		t.Fatalf("latestModified() = %v, want %v", got, edited)
+	}
+
+	if got := latestModified(State{UpdatedAt: state.UpdatedAt}); !got.IsZero() {
+		t.Fatalf("latestModified() without edit times = %v, want zero", got)
+	}
+}
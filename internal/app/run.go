@@ -2,143 +2,364 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"math"
 	"os"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
 
 	"git.skobk.in/skobkin/jnovel-scrape/internal/collect"
+	"git.skobk.in/skobkin/jnovel-scrape/internal/event"
+	"git.skobk.in/skobkin/jnovel-scrape/internal/export"
 	"git.skobk.in/skobkin/jnovel-scrape/internal/httpx"
 	"git.skobk.in/skobkin/jnovel-scrape/internal/markdown"
 	"git.skobk.in/skobkin/jnovel-scrape/internal/model"
 )
 
-// Run executes the scraper using the provided configuration.
-func Run(ctx context.Context, cfg Config, logger *Logger) error {
+// Run executes the scraper using the provided configuration. With
+// --summary-out the run summary is written however the run ends.
+func Run(ctx context.Context, cfg Config, logger *Logger) (err error) {
 	if logger == nil {
-		logger = NewLogger(os.Stderr)
+		logger = NewLoggerFor(os.Stderr, cfg)
 	}
 
-	client := httpx.NewClient(cfg.ReqInterval, cfg.LimitWait)
+	var observer event.Observer = logObserver{logger: logger}
+	summary := newRunSummary(cfg)
+	if summary != nil {
+		observer = event.Multi(observer, summary)
+		defer func() {
+			if writeErr := summary.write(cfg.SummaryOut, err); writeErr != nil {
+				if err != nil {
+					logger.Warn("Could not write the run summary", "error", writeErr)
+
+					return
+				}
+				err = writeErr
+			}
+		}()
+	}
+	if view := startProgress(cfg, logger); view != nil {
+		defer view.stop(logger)
+		observer = event.Multi(observer, view)
+	}
+	client := httpx.NewClient(cfg.ReqInterval, cfg.LimitWait, httpx.WithObserver(observer))
 
 	options := collect.Options{
-		BaseURL:     collect.DefaultBaseURL,
-		MaxPages:    cfg.MaxPages,
-		Concurrency: cfg.Concurrency,
-		UserAgent:   cfg.UserAgent,
-		Logger:      logger,
-		Client:      client,
-		ReqInterval: cfg.ReqInterval,
+		BaseURL:      collect.DefaultBaseURL,
+		MaxPages:     cfg.MaxPages,
+		Concurrency:  cfg.Concurrency,
+		StopAfter:    cfg.StopAfter,
+		UserAgent:    cfg.UserAgent,
+		Logger:       logger,
+		Client:       client,
+		ReqInterval:  cfg.ReqInterval,
+		APIStrategy:  cfg.APIStrategy,
+		FetchCovers:  slices.Contains(cfg.Columns, markdown.ColumnCover),
+		RetryBackoff: cfg.RetryBackoff,
+		ForceDetail:  cfg.ForceDetail,
+		Observer:     observer,
+	}
+	options.FetchMetadata = len(cfg.AuthorFilters) > 0 || slices.ContainsFunc(cfg.Columns, func(c markdown.Column) bool {
+		return slices.Contains(markdown.MetadataColumns, c)
+	})
+	if cfg.SelectorsPath != "" {
+		selectors, err := collect.LoadSelectors(cfg.SelectorsPath)
+		if err != nil {
+			return err
+		}
+		options.Selectors = selectors
+	}
+	if cfg.TitleSearch && len(cfg.TitleFilters) > 0 {
+		options.Search = cfg.TitleFilters
 	}
 
+	switch cfg.Command {
+	case CommandRefresh:
+		return runRefresh(ctx, cfg, options, summary, logger)
+	case CommandRetryFailed:
+		return runRetryFailed(ctx, cfg, options, summary, logger)
+	}
+
+	return runScrape(ctx, cfg, options, summary, logger)
+}
+
+// runScrape crawls back to the cutoff with the collectors cfg.Mode
+// selects and writes the output.
+func runScrape(ctx context.Context, cfg Config, options collect.Options, summary *runSummary, logger *Logger) error {
+	failures := &collect.Failures{}
+	options.Failures = failures
+
 	destination := "stdout"
 	if cfg.OutputPath != "" {
 		destination = cfg.OutputPath
 	}
-	logger.Infof("Starting crawl: cutoff=%s mode=%s out=%s", cfg.Cutoff.Format("2006-01-02"), cfg.Mode, destination)
+	logger.Info("Starting crawl", "cutoff", cfg.Cutoff.Format("2006-01-02"), "mode", cfg.Mode, "out", destination)
+	if len(options.Search) > 0 {
+		logger.Info("Pushing title needles to site search", "needles", len(options.Search))
+	}
+
+	if cfg.Mode == ModeVerify {
+		return runVerify(ctx, cfg, options, summary, logger)
+	}
+	if streamable(cfg) {
+		return runStream(ctx, cfg, options, summary, logger)
+	}
+
+	checkpoints, err := openCheckpoint(cfg, options, logger)
+	if err != nil {
+		return err
+	}
+	options.Checkpoints = checkpoints
+	// complete is set once the crawl covered the whole range; until
+	// then the checkpoint is kept for the next run.
+	complete := false
+	defer func() { closeCheckpoint(cfg, checkpoints, complete, logger) }()
 
 	var (
 		posts    model.Posts
-		warnings []string
-		err      error
+		warnings []collect.Warning
+		// crawlErr is set when the HTML crawl stopped on a failed page:
+		// what it collected is still written and the failures recorded
+		// before Run returns it.
+		crawlErr error
 	)
 
 	switch cfg.Mode {
 	case ModeAPI:
 		posts, warnings, err = collect.FetchAPI(ctx, cfg.Cutoff, options)
-		if err != nil {
-			logger.Errorf("API mode failed: %v", err)
+		var partial *collect.PartialError
+		switch {
+		case ctx.Err() != nil:
+		case errors.As(err, &partial):
+			// The failed pages are in the dead-letter file; keep the rest.
+			logger.Warn("API mode failed on some pages; keeping the posts collected", "error", partial.Err, "posts", len(posts))
+		case err != nil:
+			logger.Error("API mode failed", "error", err)
 
 			return err
+		default:
+			complete = true
 		}
-		logger.Infof("API mode retrieved %d posts before filtering", len(posts))
+		logger.Info("API mode finished", "posts", len(posts))
 	case ModeHTML:
 		posts, warnings, err = collect.FetchHTML(ctx, cfg.Cutoff, options)
-		if err != nil {
-			logger.Errorf("HTML mode failed: %v", err)
+		var partial *collect.PartialError
+		switch {
+		case ctx.Err() != nil:
+		case errors.As(err, &partial):
+			logger.Error("HTML mode failed part-way; keeping the posts collected", "error", partial.Err, "posts", len(posts), "reached", partial.Reached.Format("2006-01-02"))
+			crawlErr = err
+		case err != nil:
+			logger.Error("HTML mode failed", "error", err)
 
 			return err
+		default:
+			complete = true
 		}
-		logger.Infof("HTML mode retrieved %d posts before filtering", len(posts))
+		logger.Info("HTML mode finished", "posts", len(posts))
 	case ModeAuto:
 		posts, warnings, err = collect.FetchAPI(ctx, cfg.Cutoff, options)
-		if err != nil {
-			logger.Warnf("API mode failed (%v); switching to HTML fallback", err)
+		var partial *collect.PartialError
+		switch {
+		case ctx.Err() != nil:
+			// Interrupted: there is no time left for a fallback.
+		case errors.As(err, &partial):
+			logger.Warn("API mode failed part-way; resuming with HTML fallback", "error", partial.Err, "posts", len(posts), "from", partial.Reached.Format("2006-01-02"))
+			observeFallback(options, err)
+			resumed := options
+			resumed.Resume = &collect.Resume{Before: partial.Reached, Known: posts}
+			htmlPosts, htmlWarnings, htmlErr := collect.FetchHTML(ctx, cfg.Cutoff, resumed)
+			switch {
+			case htmlErr == nil:
+				// The HTML crawl covered the pages the API could not load.
+				failures.Discard(collect.FailureAPIPage)
+				complete = true
+			case ctx.Err() != nil:
+			default:
+				// The API posts and failed pages are kept either way; the
+				// range the fallback missed stays in the dead-letter file.
+				logger.Error("HTML fallback failed; keeping the posts collected", "error", htmlErr, "posts", len(posts)+len(htmlPosts))
+				crawlErr = htmlErr
+			}
+			posts = append(posts, htmlPosts...)
+			warnings = append(warnings, htmlWarnings...)
+			logger.Info("HTML fallback finished", "added", len(htmlPosts), "posts", len(posts))
+		case err != nil:
+			logger.Warn("API mode failed; switching to HTML fallback", "error", err)
+			observeFallback(options, err)
+			failures.Discard(collect.FailureAPIPage)
 			posts, warnings, err = collect.FetchHTML(ctx, cfg.Cutoff, options)
-			if err != nil {
-				logger.Errorf("HTML fallback failed: %v", err)
+			var htmlPartial *collect.PartialError
+			switch {
+			case ctx.Err() != nil:
+			case errors.As(err, &htmlPartial):
+				logger.Error("HTML fallback failed part-way; keeping the posts collected", "error", htmlPartial.Err, "posts", len(posts), "reached", htmlPartial.Reached.Format("2006-01-02"))
+				crawlErr = err
+			case err != nil:
+				logger.Error("HTML fallback failed", "error", err)
 
 				return err
+			default:
+				complete = true
 			}
-			logger.Infof("HTML fallback succeeded with %d posts before filtering", len(posts))
-		} else {
-			logger.Infof("API mode succeeded with %d posts before filtering", len(posts))
+			logger.Info("HTML fallback finished", "posts", len(posts))
+		default:
+			complete = true
+			logger.Info("API mode finished", "posts", len(posts))
 		}
 	default:
 		return fmt.Errorf("unsupported mode %q", cfg.Mode)
 	}
 
-	for _, warn := range warnings {
-		logger.Warnf("%s", warn)
+	// An interrupted crawl still writes what it collected, marked as
+	// incomplete, and Run then reports ErrInterrupted.
+	interrupted := ctx.Err() != nil
+	if interrupted {
+		complete = false
+		logger.Warn("Crawl interrupted; writing the posts collected so far", "cause", context.Cause(ctx), "posts", len(posts))
 	}
 
+	if warnings, err = reportWarnings(cfg, warnings, logger); err != nil {
+		return err
+	}
+	summary.recordWarnings(warnings)
+	if err := recordFailures(cfg, failures.Items(), logger); err != nil {
+		return err
+	}
+
+	collected := len(posts)
 	posts, removed := dedupePosts(posts)
 	if removed > 0 {
-		logger.Infof("Removed %d duplicate posts (by link)", removed)
+		logger.Info("Removed duplicate posts (by ID or link)", "removed", removed)
+	}
+	if cfg.StatePath != "" {
+		// The state records everything collected, not just what the
+		// filters keep, so a later refresh sees the whole range.
+		if err := updateState(cfg.StatePath, posts, logger); err != nil {
+			return err
+		}
 	}
 
 	filtered, stats := filterPosts(posts, cfg)
-	logger.Infof("Filter stats: type=%d title=%d volume=%d", stats.TypeDropped, stats.TitleDropped, stats.VolumeDropped)
+	filtered = limitPosts(filtered, cfg, &stats)
+	logFilterStats(logger, stats)
 	filtered = applyGrouping(filtered, cfg.GroupMode, cfg.GroupSort)
-	logger.Infof("Kept %d posts after filters", len(filtered))
+	logger.Info("Filters applied", "kept", len(filtered))
+	summary.recordPosts(collected, len(posts), len(filtered), stats)
+
+	if err := writeOutput(cfg, filtered, interrupted || crawlErr != nil, logger); err != nil {
+		return err
+	}
+	if interrupted {
+		return ErrInterrupted
+	}
+	if crawlErr != nil {
+		return crawlErr
+	}
 
-	return writeOutput(cfg, filtered, logger)
+	return checkWarnings(cfg, warnings)
 }
 
-func writeOutput(cfg Config, posts model.Posts, logger *Logger) error {
-	var (
-		writer io.Writer
-		file   *os.File
-		err    error
-	)
-	if cfg.OutputPath == "" {
-		writer = os.Stdout
-	} else {
-		file, err = os.Create(cfg.OutputPath)
+// observeFallback reports the auto-mode switch from the API to the HTML
+// collector.
+func observeFallback(options collect.Options, err error) {
+	if options.Observer != nil {
+		options.Observer.Observe(event.FallbackTriggered{From: string(ModeAPI), To: string(ModeHTML), Err: err})
+	}
+}
+
+// ErrInterrupted is returned by Run when the context was cancelled
+// during the crawl. The output has been written but is incomplete.
+var ErrInterrupted = errors.New("crawl interrupted; output is incomplete")
+
+// writeOutput writes the collected posts in cfg.Format. Only Markdown
+// has a header to mark an incomplete result in.
+func writeOutput(cfg Config, posts model.Posts, incomplete bool, logger *Logger) error {
+	writer, closeOutput, err := openOutput(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeOutput()
+
+	switch cfg.Format {
+	case FormatJSONL, FormatCSV:
+		rows, err := newRowWriter(cfg, writer)
 		if err != nil {
-			return fmt.Errorf("open output path: %w", err)
+			return err
+		}
+		for _, post := range posts {
+			if err := rows.Write(post); err != nil {
+				return fmt.Errorf("write %s: %w", cfg.Format, err)
+			}
+		}
+		if err := rows.Flush(); err != nil {
+			return fmt.Errorf("write %s: %w", cfg.Format, err)
+		}
+	default:
+		header := markdown.Header{Cutoff: cfg.Cutoff, Incomplete: incomplete}
+		if err := markdown.WriteDocument(writer, header, posts, cfg.Columns); err != nil {
+			return fmt.Errorf("write markdown: %w", err)
 		}
-		defer func() { _ = file.Close() }()
-		writer = file
 	}
+	logWritten(cfg, len(posts), logger)
 
-	if err := markdown.WriteTable(writer, cfg.Cutoff, posts); err != nil {
-		return fmt.Errorf("write markdown: %w", err)
-	}
+	return nil
+}
 
+// openOutput opens --out, or stdout when it is not set. The returned
+// function closes the file.
+func openOutput(cfg Config) (io.Writer, func(), error) {
 	if cfg.OutputPath == "" {
-		logger.Infof("Wrote Markdown to stdout (%d rows)", len(posts))
-	} else {
-		logger.Infof("Wrote Markdown to %s (%d rows)", cfg.OutputPath, len(posts))
+		return os.Stdout, func() {}, nil
+	}
+	file, err := os.Create(cfg.OutputPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open output path: %w", err)
 	}
 
-	return nil
+	return file, func() { _ = file.Close() }, nil
+}
+
+// newRowWriter returns the writer for the row-based formats.
+func newRowWriter(cfg Config, w io.Writer) (export.RowWriter, error) {
+	if cfg.Format == FormatJSONL {
+		return export.NewJSONLWriter(w), nil
+	}
+
+	return export.NewCSVWriter(w, cfg.Columns)
+}
+
+func logWritten(cfg Config, rows int, logger *Logger) {
+	name := "Markdown"
+	switch cfg.Format {
+	case FormatJSONL:
+		name = "JSON Lines"
+	case FormatCSV:
+		name = "CSV"
+	}
+	destination := "stdout"
+	if cfg.OutputPath != "" {
+		destination = cfg.OutputPath
+	}
+	logger.Info("Wrote "+name, "out", destination, "rows", rows)
 }
 
+// dedupePosts keeps the first occurrence of every post, matching by
+// SourceID when known and by canonical link otherwise.
 func dedupePosts(posts model.Posts) (model.Posts, int) {
-	seen := make(map[string]struct{}, len(posts))
+	seen := model.NewIdentitySet()
 	var result model.Posts
 	removed := 0
 	for _, post := range posts {
-		if _, ok := seen[post.Link]; ok {
+		if !seen.Add(post) {
 			removed++
 
 			continue
 		}
-		seen[post.Link] = struct{}{}
 		result = append(result, post)
 	}
 
@@ -6,13 +6,20 @@ package app
 
 import (
 	"bytes"
+	"context"
+	"fmt"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"git.skobk.in/skobkin/jnovel-scrape/internal/collect"
+	"git.skobk.in/skobkin/jnovel-scrape/internal/httpx"
+	"git.skobk.in/skobkin/jnovel-scrape/internal/markdown"
 	"git.skobk.in/skobkin/jnovel-scrape/internal/model"
 )
 
@@ -36,6 +43,24 @@ func TestDedupePosts(t *testing.T) {
 	}
 }
 
+func TestDedupePostsPrefersSourceID(t *testing.T) {
+	ts := time.Now()
+	posts := model.Posts{
+		{Title: "API", SourceID: 42, Link: "https://example.com/hero-volume-2/", Date: ts},
+		{Title: "HTML renamed", SourceID: 42, Link: "https://example.com/hero-vol-2/", Date: ts},
+		{Title: "HTML no id", Link: "https://EXAMPLE.com/hero-volume-2?utm_source=rss", Date: ts},
+		{Title: "Other", SourceID: 43, Link: "https://example.com/other/", Date: ts},
+	}
+
+	deduped, removed := dedupePosts(posts)
+	if removed != 2 || len(deduped) != 2 {
+		t.Fatalf("expected 2 removed and 2 kept, got removed=%d kept=%+v", removed, deduped)
+	}
+	if deduped[0].Title != "API" || deduped[1].Title != "Other" {
+		t.Fatalf("unexpected survivors: %+v", deduped)
+	}
+}
+
 func TestApplyGroupingTitleAsc(t *testing.T) {
 	ts := time.Now()
 	v1 := 1.0
@@ -134,7 +159,7 @@ func TestWriteOutputToFile(t *testing.T) {
 	logger := NewLogger(io.Discard)
 	cfg := Config{Cutoff: cutoff, OutputPath: outPath}
 
-	if err := writeOutput(cfg, posts, logger); err != nil {
+	if err := writeOutput(cfg, posts, false, logger); err != nil {
 		t.Fatalf("writeOutput error: %v", err)
 	}
 
@@ -150,3 +175,131 @@ func TestWriteOutputToFile(t *testing.T) {
 		t.Fatalf("unexpected table row: %q", content)
 	}
 }
+
+func TestWriteOutputCSVSortsPosts(t *testing.T) {
+	outPath := filepath.Join(t.TempDir(), "result.csv")
+	posts := model.Posts{
+		{Title: "Older", Type: model.TypePDF, Date: time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC), Link: "https://example.com/older"},
+		{Title: "Newer", Type: model.TypeEPUB, Date: time.Date(2025, time.January, 5, 0, 0, 0, 0, time.UTC), Link: "https://example.com/newer"},
+	}
+	filtered, _ := filterPosts(posts, Config{})
+	cfg := Config{OutputPath: outPath, Format: FormatCSV, Columns: []markdown.Column{markdown.ColumnTitle, markdown.ColumnDate}}
+
+	if err := writeOutput(cfg, filtered, false, NewLogger(io.Discard)); err != nil {
+		t.Fatalf("writeOutput error: %v", err)
+	}
+
+	data, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("read output: %v", err)
+	}
+	want := "title,date\nNewer,2025-01-05\nOlder,2025-01-01\n"
+	if string(data) != want {
+		t.Fatalf("unexpected CSV %q, want %q", data, want)
+	}
+}
+
+func TestStreamable(t *testing.T) {
+	cases := []struct {
+		name string
+		cfg  Config
+		want bool
+	}{
+		{"markdown", Config{Format: FormatMarkdown}, false},
+		{"jsonl", Config{Format: FormatJSONL, GroupMode: GroupNone}, true},
+		{"csv", Config{Format: FormatCSV}, true},
+		{"grouped", Config{Format: FormatJSONL, GroupMode: GroupTitle}, false},
+		{"state", Config{Format: FormatJSONL, StatePath: "state.json"}, false},
+		{"checkpoint", Config{Format: FormatCSV, CheckpointPath: "cp.json"}, false},
+		{"markdown limit", Config{Format: FormatMarkdown, Limit: 20}, true},
+		{"grouped limit", Config{Format: FormatJSONL, GroupMode: GroupTitle, Limit: 20}, true},
+		{"state limit", Config{Format: FormatMarkdown, StatePath: "state.json", Limit: 20}, false},
+		{"one needle limit", Config{Format: FormatJSONL, Limit: 1, TitleSearch: true, TitleFilters: []string{"alpha"}}, true},
+		{"needles limit", Config{Format: FormatJSONL, Limit: 1, TitleSearch: true, TitleFilters: []string{"alpha", "beta"}}, false},
+		{"needles per-title limit", Config{Format: FormatCSV, LimitPerTitle: 1, TitleSearch: true, TitleFilters: []string{"alpha", "beta"}}, false},
+		{"needles without search", Config{Format: FormatJSONL, Limit: 1, TitleFilters: []string{"alpha", "beta"}}, true},
+	}
+	for _, tc := range cases {
+		if got := streamable(tc.cfg); got != tc.want {
+			t.Fatalf("%s: streamable() = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestStreamOutputTracksKnownPosts(t *testing.T) {
+	stream := &collect.PostStream{Posts: func(yield func(model.Post, error) bool) {
+		for _, post := range []model.Post{
+			{SourceID: 1, Link: "https://example.com/a/", Title: "A", Type: model.TypeEPUB},
+			{SourceID: 1, Link: "https://example.com/a/?utm_source=feed", Title: "A", Type: model.TypeEPUB},
+			{SourceID: 2, Link: "https://example.com/b/", Title: "B", Type: model.TypeEPUB},
+		} {
+			if !yield(post, nil) {
+				return
+			}
+		}
+	}}
+
+	cfg := Config{Mode: ModeAuto}
+	out := &streamOutput{cfg: cfg, seen: model.NewIdentitySet(), limiter: newRowLimiter(cfg), trackKnown: true}
+	if err := out.consume(stream); err != nil {
+		t.Fatalf("consume() error: %v", err)
+	}
+	if len(out.known) != 2 || out.known[0].SourceID != 1 || out.known[1].SourceID != 2 {
+		t.Fatalf("expected both unique posts to be known, got %+v", out.known)
+	}
+	if out.duplicates != 1 || out.collected != 2 {
+		t.Fatalf("unexpected counts: collected=%d duplicates=%d", out.collected, out.duplicates)
+	}
+}
+
+func TestLimitAcrossSearchNeedles(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/wp-json/wp/v2/posts" {
+			fmt.Fprint(w, `[]`)
+
+			return
+		}
+		w.Header().Set("X-WP-TotalPages", "1")
+		switch r.URL.Query().Get("search") {
+		case "alpha":
+			fmt.Fprint(w, `[{"id":1,"date":"2025-10-05T00:00:00","date_gmt":"2025-10-05T00:00:00",
+				"link":"https://example.com/alpha-volume-1-epub/","title":{"rendered":"Alpha Volume 1 EPUB"}}]`)
+		case "beta":
+			// The second needle has the newest post.
+			fmt.Fprint(w, `[{"id":2,"date":"2025-10-20T00:00:00","date_gmt":"2025-10-20T00:00:00",
+				"link":"https://example.com/beta-volume-2-epub/","title":{"rendered":"Beta Volume 2 EPUB"}}]`)
+		default:
+			fmt.Fprint(w, `[]`)
+		}
+	}))
+	defer server.Close()
+
+	cfg := Config{
+		Mode:         ModeAPI,
+		Cutoff:       time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC),
+		Format:       FormatJSONL,
+		TitleFilters: []string{"alpha", "beta"},
+		TitleMode:    TitleModeSubstring,
+		TitleSearch:  true,
+		Limit:        1,
+	}
+	if streamable(cfg) {
+		t.Fatalf("--limit over several search needles must not end the crawl early")
+	}
+
+	client := httpx.NewClient(1*time.Millisecond, 5*time.Millisecond,
+		httpx.WithHTTPClient(server.Client()),
+		httpx.WithJitterFactor(0),
+	)
+	options := collect.Options{BaseURL: server.URL, Client: client, Search: cfg.TitleFilters}
+	posts, _, err := collect.FetchAPI(context.Background(), cfg.Cutoff, options)
+	if err != nil {
+		t.Fatalf("FetchAPI() error: %v", err)
+	}
+	posts, _ = dedupePosts(posts)
+	filtered, stats := filterPosts(posts, cfg)
+	filtered = limitPosts(filtered, cfg, &stats)
+	if len(filtered) != 1 || filtered[0].SourceID != 2 {
+		t.Fatalf("expected the newest post of the second needle, got %+v", filtered)
+	}
+}
@@ -0,0 +1,152 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"git.skobk.in/skobkin/jnovel-scrape/internal/model"
+)
+
+// stateVersion is bumped whenever the state file layout changes
+// incompatibly.
+const stateVersion = 1
+
+// State is the --state file: every post collected so far, as it looked
+// when it was last seen.
+type State struct {
+	Version   int         `json:"version"`
+	UpdatedAt time.Time   `json:"updated_at"`
+	Posts     model.Posts `json:"posts"`
+}
+
+// loadState reads the state file. A missing file is an empty state, so
+// the first scrape run creates it.
+func loadState(path string) (State, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return State{Version: stateVersion}, nil
+	}
+	if err != nil {
+		return State{}, fmt.Errorf("read state: %w", err)
+	}
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return State{}, fmt.Errorf("parse state %s: %w", path, err)
+	}
+	if state.Version != stateVersion {
+		return State{}, fmt.Errorf("state %s: unsupported version %d", path, state.Version)
+	}
+
+	return state, nil
+}
+
+// saveState writes the state through a temporary file in the same
+// directory, so an interrupted run never leaves a truncated state.
+func saveState(path string, state State) error {
+	state.Version = stateVersion
+	state.Posts.Sort()
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode state: %w", err)
+	}
+	if err := writeFileAtomic(path, append(data, '\n')); err != nil {
+		return fmt.Errorf("write state: %w", err)
+	}
+
+	return nil
+}
+
+// writeFileAtomic writes data through a temporary file in the same
+// directory and renames it over path.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// merge replaces known posts with their fresh copies (matched by
+// SourceID, then canonical link) and appends new ones. It returns how
+// many posts were added.
+//
+// Covers and book metadata are only fetched when a run asks for them,
+// so a fresh copy without them keeps the recorded values.
+func (s *State) merge(posts model.Posts) int {
+	byID := make(map[int64]int, len(s.Posts))
+	byLink := make(map[string]int, len(s.Posts))
+	for i, post := range s.Posts {
+		if post.SourceID != 0 {
+			byID[post.SourceID] = i
+		}
+		byLink[model.CanonicalLink(post.Link)] = i
+	}
+
+	added := 0
+	for _, post := range posts {
+		idx, ok := byID[post.SourceID]
+		if !ok {
+			idx, ok = byLink[model.CanonicalLink(post.Link)]
+		}
+		if ok {
+			s.Posts[idx] = keepOptionalFields(s.Posts[idx], post)
+
+			continue
+		}
+		s.Posts = append(s.Posts, post)
+		idx = len(s.Posts) - 1
+		if post.SourceID != 0 {
+			byID[post.SourceID] = idx
+		}
+		byLink[model.CanonicalLink(post.Link)] = idx
+		added++
+	}
+
+	return added
+}
+
+func keepOptionalFields(old, post model.Post) model.Post {
+	if post.CoverURL == "" {
+		post.CoverURL, post.CoverWidth, post.CoverHeight = old.CoverURL, old.CoverWidth, old.CoverHeight
+	}
+	if post.Author == "" && post.Illustrator == "" && post.Publisher == "" && len(post.AltTitles) == 0 && post.Summary == "" {
+		post.Author, post.Illustrator, post.Publisher = old.Author, old.Illustrator, old.Publisher
+		post.AltTitles, post.Summary = old.AltTitles, old.Summary
+	}
+	if post.Modified.IsZero() {
+		post.Modified = old.Modified
+	}
+
+	return post
+}
+
+// updateState merges the posts of a scrape run into the state file.
+func updateState(path string, posts model.Posts, logger *Logger) error {
+	state, err := loadState(path)
+	if err != nil {
+		return err
+	}
+	added := state.merge(posts)
+	state.UpdatedAt = time.Now().UTC()
+	if err := saveState(path, state); err != nil {
+		return err
+	}
+	logger.Info("State updated", "path", path, "posts", len(state.Posts), "new", added)
+
+	return nil
+}
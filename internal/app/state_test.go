@@ -0,0 +1,51 @@
+package app
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"git.skobk.in/skobkin/jnovel-scrape/internal/model"
+)
+
+func TestStateMergeAndRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	state, err := loadState(path)
+	if err != nil {
+		t.Fatalf("loadState() on missing file: %v", err)
+	}
+	if len(state.Posts) != 0 {
+		t.Fatalf("expected empty state, got %d posts", len(state.Posts))
+	}
+
+	day := time.Date(2025, time.October, 10, 0, 0, 0, 0, time.UTC)
+	state.Posts = model.Posts{
+		{Title: "Kept", SourceID: 1, Link: "https://example.com/kept/", Date: day, Author: "Jane Doe"},
+		{Title: "Old link", Link: "https://example.com/old-link/", Date: day},
+	}
+	added := state.merge(model.Posts{
+		{Title: "Kept (fixed)", SourceID: 1, Link: "https://example.com/kept/", Date: day},
+		{Title: "Old link (fixed)", Link: "http://EXAMPLE.com/old-link", Date: day},
+		{Title: "New", SourceID: 3, Link: "https://example.com/new/", Date: day.AddDate(0, 0, 1)},
+	})
+	if added != 1 || len(state.Posts) != 3 {
+		t.Fatalf("merge added %d, state has %d posts", added, len(state.Posts))
+	}
+	if state.Posts[0].Title != "Kept (fixed)" || state.Posts[0].Author != "Jane Doe" {
+		t.Fatalf("fresh copy should replace title and keep metadata: %+v", state.Posts[0])
+	}
+
+	if err := saveState(path, state); err != nil {
+		t.Fatalf("saveState() error: %v", err)
+	}
+	loaded, err := loadState(path)
+	if err != nil {
+		t.Fatalf("loadState() error: %v", err)
+	}
+	if len(loaded.Posts) != 3 || loaded.Posts[0].Title != "New" {
+		t.Fatalf("unexpected loaded state: %+v", loaded.Posts)
+	}
+	if matches, _ := filepath.Glob(path + ".*.tmp"); len(matches) != 0 {
+		t.Fatalf("temporary files left behind: %v", matches)
+	}
+}
@@ -0,0 +1,239 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"git.skobk.in/skobkin/jnovel-scrape/internal/collect"
+	"git.skobk.in/skobkin/jnovel-scrape/internal/export"
+	"git.skobk.in/skobkin/jnovel-scrape/internal/model"
+)
+
+// streamable reports whether a scrape run can take its posts from a
+// collect.PostStream: nothing may need the full result (--state and
+// --checkpoint record it), the limits must be able to trust the order
+// posts arrive in, and either the rows can be written as they arrive or
+// --limit can end the crawl early.
+func streamable(cfg Config) bool {
+	if cfg.StatePath != "" || cfg.CheckpointPath != "" {
+		return false
+	}
+	if (cfg.Limit > 0 || cfg.LimitPerTitle > 0) && !newestFirst(cfg) {
+		return false
+	}
+
+	return streamRows(cfg) || cfg.Limit > 0
+}
+
+// newestFirst reports whether the crawl yields posts newest first, which
+// the row limiter needs to keep the most recent ones. Several
+// --title-search needles are crawled one after another, each newest
+// first, so a newer post can arrive after older ones of an earlier
+// needle.
+func newestFirst(cfg Config) bool {
+	return !cfg.TitleSearch || len(cfg.TitleFilters) <= 1
+}
+
+// streamRows reports whether the output rows can be written while the
+// crawl is still going: the format must be row-based and the rows not
+// reordered by grouping.
+func streamRows(cfg Config) bool {
+	return (cfg.Format == FormatJSONL || cfg.Format == FormatCSV) && cfg.GroupMode != GroupTitle
+}
+
+// streamOutput filters streamed posts and writes the ones it keeps, or
+// collects them when rows is nil.
+type streamOutput struct {
+	cfg       Config
+	rows      export.RowWriter
+	posts     model.Posts
+	seen      *model.IdentitySet
+	limiter   *rowLimiter
+	stats     FilterStats
+	collected int
+	// duplicates counts posts dropped as already seen.
+	duplicates int
+	written    int
+	// known lists every post taken when trackKnown is set, so the
+	// auto-mode fallback can skip their detail pages.
+	known      model.Posts
+	trackKnown bool
+	// err is the first write error; it stops the stream.
+	err error
+}
+
+// consume reads stream until it ends or --limit is reached and returns
+// the crawl error it ended with, if any. Stopping early cancels the
+// crawl.
+func (o *streamOutput) consume(stream *collect.PostStream) error {
+	for post, err := range stream.Posts {
+		if err != nil {
+			return err
+		}
+		if !o.seen.Add(post) {
+			o.duplicates++
+
+			continue
+		}
+		if o.trackKnown {
+			o.known = append(o.known, post)
+		}
+		o.collected++
+		if !keepPost(post, o.cfg, &o.stats) || !o.limiter.take(post, &o.stats) {
+			continue
+		}
+		if o.rows == nil {
+			o.posts = append(o.posts, post)
+		} else if err := o.rows.Write(post); err != nil {
+			o.err = fmt.Errorf("write %s: %w", o.cfg.Format, err)
+
+			return nil
+		}
+		o.written++
+		if o.limiter.full() {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// runStream is the scrape run for streamable configurations. Posts are
+// taken in the order the site lists them, newest first, as soon as each
+// is final. Row formats write them straight away; otherwise they are
+// collected, then sorted and grouped as usual. With --limit the crawl
+// stops once enough posts passed the filters. The auto-mode fallback
+// follows the same rules as Run.
+func runStream(ctx context.Context, cfg Config, options collect.Options, summary *runSummary, logger *Logger) error {
+	out := &streamOutput{cfg: cfg, seen: model.NewIdentitySet(), limiter: newRowLimiter(cfg), trackKnown: cfg.Mode == ModeAuto}
+	if streamRows(cfg) {
+		writer, closeOutput, err := openOutput(cfg)
+		if err != nil {
+			return err
+		}
+		defer closeOutput()
+		rows, err := newRowWriter(cfg, writer)
+		if err != nil {
+			return err
+		}
+		out.rows = rows
+	}
+	failures := options.Failures
+	var err error
+	// crawlErr is set when the HTML crawl failed: the rows already
+	// taken are still written and the failures recorded before
+	// runStream returns it, as in Run.
+	var crawlErr error
+
+	var warnings []collect.Warning
+	stream := func(fetch func(context.Context, time.Time, collect.Options) *collect.PostStream, opt collect.Options) error {
+		s := fetch(ctx, cfg.Cutoff, opt)
+		err := out.consume(s)
+		warnings = append(warnings, s.Warnings...)
+
+		return err
+	}
+
+	switch cfg.Mode {
+	case ModeAPI:
+		err = stream(collect.StreamAPI, options)
+		var partial *collect.PartialError
+		switch {
+		case out.err != nil, ctx.Err() != nil:
+		case errors.As(err, &partial):
+			logger.Warn("API mode failed on some pages; keeping the posts collected", "error", partial.Err, "posts", out.collected)
+		case err != nil:
+			logger.Error("API mode failed", "error", err)
+
+			return err
+		}
+	case ModeHTML:
+		err = stream(collect.StreamHTML, options)
+		if err != nil && out.err == nil && ctx.Err() == nil {
+			logger.Error("HTML mode failed; keeping the posts collected", "error", err, "posts", out.collected)
+			crawlErr = err
+		}
+	case ModeAuto:
+		err = stream(collect.StreamAPI, options)
+		var partial *collect.PartialError
+		switch {
+		case out.err != nil, ctx.Err() != nil:
+		case errors.As(err, &partial):
+			// The rows written so far stay; the fallback covers the
+			// rest and skips what the API already returned, without
+			// fetching those detail pages again.
+			logger.Warn("API mode failed part-way; resuming with HTML fallback", "error", partial.Err, "posts", out.collected, "from", partial.Reached.Format("2006-01-02"))
+			observeFallback(options, err)
+			resumed := options
+			resumed.Resume = &collect.Resume{Before: partial.Reached, Known: out.known}
+			htmlErr := stream(collect.StreamHTML, resumed)
+			switch {
+			case htmlErr == nil:
+				failures.Discard(collect.FailureAPIPage)
+			case out.err == nil && ctx.Err() == nil:
+				logger.Error("HTML fallback failed; keeping the posts collected", "error", htmlErr, "posts", out.collected)
+				crawlErr = htmlErr
+			}
+		case err != nil:
+			// A failure that is not partial leaves nothing to resume
+			// from, so the fallback starts over; rows already written
+			// are not repeated.
+			logger.Warn("API mode failed; switching to HTML fallback", "error", err)
+			observeFallback(options, err)
+			failures.Discard(collect.FailureAPIPage)
+			err = stream(collect.StreamHTML, options)
+			if err != nil && out.err == nil && ctx.Err() == nil {
+				logger.Error("HTML fallback failed; keeping the posts collected", "error", err, "posts", out.collected)
+				crawlErr = err
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported mode %q", cfg.Mode)
+	}
+	if out.err != nil {
+		return out.err
+	}
+
+	if out.limiter.full() {
+		logger.Info("Stopped the crawl once --limit was reached", "kept", out.written)
+	}
+	interrupted := ctx.Err() != nil
+	if interrupted {
+		logger.Warn("Crawl interrupted; the output ends early", "cause", context.Cause(ctx), "rows", out.written)
+	}
+	if warnings, err = reportWarnings(cfg, warnings, logger); err != nil {
+		return err
+	}
+	summary.recordWarnings(warnings)
+	if err := recordFailures(cfg, failures.Items(), logger); err != nil {
+		return err
+	}
+
+	logger.Info("Crawl finished", "posts", out.collected)
+	logFilterStats(logger, out.stats)
+	summary.recordPosts(out.collected+out.duplicates, out.collected, out.written, out.stats)
+	if out.rows == nil {
+		posts := out.posts
+		posts.Sort()
+		posts = applyGrouping(posts, cfg.GroupMode, cfg.GroupSort)
+		logger.Info("Filters applied", "kept", len(posts))
+		if err := writeOutput(cfg, posts, interrupted || crawlErr != nil, logger); err != nil {
+			return err
+		}
+	} else {
+		if err := out.rows.Flush(); err != nil {
+			return fmt.Errorf("write %s: %w", cfg.Format, err)
+		}
+		logWritten(cfg, out.written, logger)
+	}
+	if interrupted {
+		return ErrInterrupted
+	}
+	if crawlErr != nil {
+		return crawlErr
+	}
+
+	return checkWarnings(cfg, warnings)
+}
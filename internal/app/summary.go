@@ -0,0 +1,156 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"git.skobk.in/skobkin/jnovel-scrape/internal/collect"
+	"git.skobk.in/skobkin/jnovel-scrape/internal/event"
+)
+
+// Summary is the --summary-out report of one run: what was crawled, how
+// much it cost and what came out of it.
+type Summary struct {
+	Command Command `json:"command"`
+	// Mode is the --mode requested; ModesUsed lists the collectors
+	// that actually fetched listing pages, in the order they started.
+	Mode      Mode     `json:"mode"`
+	ModesUsed []string `json:"modes_used"`
+	// Fallback is set when auto mode switched from the API to HTML.
+	Fallback bool `json:"fallback"`
+
+	StartedAt       time.Time `json:"started_at"`
+	FinishedAt      time.Time `json:"finished_at"`
+	DurationSeconds float64   `json:"duration_seconds"`
+
+	Pages           int   `json:"pages"`
+	Requests        int   `json:"requests"`
+	BytesDownloaded int64 `json:"bytes_downloaded"`
+	Retries         int   `json:"retries"`
+	RateLimited     int   `json:"rate_limited"`
+	// RateLimitSleepSeconds is the time spent waiting out 429 and 503
+	// answers.
+	RateLimitSleepSeconds float64 `json:"rate_limit_sleep_seconds"`
+
+	// PostsCollected counts posts before duplicates were removed,
+	// PostsUnique after, and PostsWritten the rows of the output.
+	PostsCollected int                         `json:"posts_collected"`
+	PostsUnique    int                         `json:"posts_unique"`
+	PostsWritten   int                         `json:"posts_written"`
+	Filters        FilterStats                 `json:"filters"`
+	Warnings       map[collect.WarningCode]int `json:"warnings"`
+
+	Output      string `json:"output"`
+	Interrupted bool   `json:"interrupted"`
+	Error       string `json:"error,omitempty"`
+}
+
+// runSummary fills a Summary as the run goes: the crawl through its
+// events, the rest from the run itself. A nil *runSummary, used when
+// --summary-out is not set, ignores everything.
+type runSummary struct {
+	mu      sync.Mutex
+	summary Summary
+	sleep   time.Duration
+}
+
+func newRunSummary(cfg Config) *runSummary {
+	if cfg.SummaryOut == "" {
+		return nil
+	}
+	output := "stdout"
+	if cfg.OutputPath != "" {
+		output = cfg.OutputPath
+	}
+
+	return &runSummary{summary: Summary{
+		Command:   cfg.Command,
+		Mode:      cfg.Mode,
+		ModesUsed: []string{},
+		StartedAt: time.Now().UTC(),
+		Warnings:  make(map[collect.WarningCode]int),
+		Output:    output,
+	}}
+}
+
+// Observe implements event.Observer.
+func (s *runSummary) Observe(e event.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch e := e.(type) {
+	case event.PageFetched:
+		s.summary.Pages++
+		if !slices.Contains(s.summary.ModesUsed, string(e.Source)) {
+			s.summary.ModesUsed = append(s.summary.ModesUsed, string(e.Source))
+		}
+	case event.RequestDone:
+		s.summary.Requests++
+		s.summary.BytesDownloaded += e.Bytes
+	case event.RetryScheduled:
+		s.summary.Retries++
+	case event.RateLimited:
+		s.summary.RateLimited++
+		s.sleep += e.Wait
+	case event.FallbackTriggered:
+		if e.To == string(ModeHTML) {
+			s.summary.Fallback = true
+		}
+	}
+}
+
+// recordPosts records the post counts of the run.
+func (s *runSummary) recordPosts(collected, unique, written int, stats FilterStats) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.summary.PostsCollected = collected
+	s.summary.PostsUnique = unique
+	s.summary.PostsWritten = written
+	s.summary.Filters = stats
+}
+
+// recordWarnings counts the warnings reported, by code.
+func (s *runSummary) recordWarnings(warnings []collect.Warning) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, warn := range warnings {
+		s.summary.Warnings[warn.Code]++
+	}
+}
+
+// write finishes the summary with the outcome of the run and writes it
+// to path.
+func (s *runSummary) write(path string, runErr error) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	summary := s.summary
+	summary.FinishedAt = time.Now().UTC()
+	summary.DurationSeconds = summary.FinishedAt.Sub(summary.StartedAt).Seconds()
+	summary.RateLimitSleepSeconds = s.sleep.Seconds()
+	s.mu.Unlock()
+	summary.Interrupted = errors.Is(runErr, ErrInterrupted)
+	if runErr != nil {
+		summary.Error = runErr.Error()
+	}
+
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode summary file: %w", err)
+	}
+	if err := writeFileAtomic(path, append(data, '\n')); err != nil {
+		return fmt.Errorf("write summary file: %w", err)
+	}
+
+	return nil
+}
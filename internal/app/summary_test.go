@@ -0,0 +1,115 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"git.skobk.in/skobkin/jnovel-scrape/internal/collect"
+	"git.skobk.in/skobkin/jnovel-scrape/internal/event"
+)
+
+func TestNewRunSummaryDisabled(t *testing.T) {
+	summary := newRunSummary(Config{})
+	if summary != nil {
+		t.Fatalf("expected no summary without --summary-out")
+	}
+	// A nil summary ignores the run.
+	summary.recordWarnings([]collect.Warning{{Code: collect.WarningMissingVolume}})
+	summary.recordPosts(1, 1, 1, FilterStats{})
+	if err := summary.write("", nil); err != nil {
+		t.Fatalf("write() error: %v", err)
+	}
+}
+
+func TestRunSummary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "summary.json")
+	cfg := Config{Command: CommandScrape, Mode: ModeAuto, OutputPath: "out.md", SummaryOut: path}
+	summary := newRunSummary(cfg)
+
+	observer := event.Multi(summary)
+	observer.Observe(event.RequestDone{URL: "https://example.com/api", Status: 500, Bytes: 20})
+	observer.Observe(event.RetryScheduled{URL: "https://example.com/api", Attempt: 1, Wait: time.Second})
+	observer.Observe(event.RequestDone{URL: "https://example.com/api", Err: errors.New("reset")})
+	observer.Observe(event.FallbackTriggered{From: string(ModeAPI), To: string(ModeHTML)})
+	observer.Observe(event.RequestDone{URL: "https://example.com/page/1", Status: 429})
+	observer.Observe(event.RateLimited{URL: "https://example.com/page/1", Status: 429, Wait: 90 * time.Second})
+	observer.Observe(event.RequestDone{URL: "https://example.com/page/1", Status: 200, Bytes: 1000})
+	observer.Observe(event.PageFetched{Source: event.SourceHTML, Page: 1, Posts: 5})
+	observer.Observe(event.PageFetched{Source: event.SourceHTML, Page: 2, Posts: 3})
+	summary.recordWarnings([]collect.Warning{
+		{Code: collect.WarningMissingVolume},
+		{Code: collect.WarningMissingVolume},
+		{Code: collect.WarningUnknownType},
+	})
+	summary.recordPosts(8, 7, 4, FilterStats{TypeDropped: 2, LimitDropped: 1})
+
+	if err := summary.write(path, fmt.Errorf("partial: %w", ErrInterrupted)); err != nil {
+		t.Fatalf("write() error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read summary file: %v", err)
+	}
+	var got Summary
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("decode summary file: %v", err)
+	}
+
+	if got.Command != CommandScrape || got.Mode != ModeAuto || got.Output != "out.md" {
+		t.Fatalf("unexpected run settings: %+v", got)
+	}
+	if len(got.ModesUsed) != 1 || got.ModesUsed[0] != "html" || !got.Fallback {
+		t.Fatalf("expected an HTML fallback, got modes %v fallback=%v", got.ModesUsed, got.Fallback)
+	}
+	if got.Pages != 2 || got.Requests != 4 || got.BytesDownloaded != 1020 || got.Retries != 1 {
+		t.Fatalf("unexpected crawl counts: %+v", got)
+	}
+	if got.RateLimited != 1 || got.RateLimitSleepSeconds != 90 {
+		t.Fatalf("unexpected rate-limit counts: %+v", got)
+	}
+	if got.PostsCollected != 8 || got.PostsUnique != 7 || got.PostsWritten != 4 {
+		t.Fatalf("unexpected post counts: %+v", got)
+	}
+	if got.Filters.TypeDropped != 2 || got.Filters.LimitDropped != 1 {
+		t.Fatalf("unexpected filter stats: %+v", got.Filters)
+	}
+	if got.Warnings[collect.WarningMissingVolume] != 2 || got.Warnings[collect.WarningUnknownType] != 1 || len(got.Warnings) != 2 {
+		t.Fatalf("unexpected warning counts: %v", got.Warnings)
+	}
+	if !got.Interrupted || got.Error == "" {
+		t.Fatalf("expected the interruption to be recorded: %+v", got)
+	}
+	if got.FinishedAt.Before(got.StartedAt) || got.DurationSeconds < 0 {
+		t.Fatalf("unexpected timing: %+v", got)
+	}
+}
+
+func TestRunSummaryStdout(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "summary.json")
+	summary := newRunSummary(Config{Command: CommandScrape, Mode: ModeAPI, SummaryOut: path})
+	if err := summary.write(path, nil); err != nil {
+		t.Fatalf("write() error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read summary file: %v", err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("decode summary file: %v", err)
+	}
+	if got["output"] != "stdout" || got["interrupted"] != false {
+		t.Fatalf("unexpected summary: %s", data)
+	}
+	if _, ok := got["error"]; ok {
+		t.Fatalf("a successful run must not record an error: %s", data)
+	}
+	if modes, ok := got["modes_used"].([]any); !ok || len(modes) != 0 {
+		t.Fatalf("expected an empty modes_used list: %s", data)
+	}
+}
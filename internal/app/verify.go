@@ -0,0 +1,239 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"git.skobk.in/skobkin/jnovel-scrape/internal/collect"
+	"git.skobk.in/skobkin/jnovel-scrape/internal/model"
+	"git.skobk.in/skobkin/jnovel-scrape/internal/util"
+)
+
+// ErrVerifyMismatch is returned by Run in verify mode when the API and
+// HTML collectors disagree.
+var ErrVerifyMismatch = errors.New("API and HTML results differ")
+
+// DiscrepancyKind classifies one difference found by verify mode.
+type DiscrepancyKind string
+
+const (
+	// DiscrepancyMissingHTML marks a post only the API returned.
+	DiscrepancyMissingHTML DiscrepancyKind = "missing_in_html"
+	// DiscrepancyMissingAPI marks a post only the HTML crawl returned.
+	DiscrepancyMissingAPI DiscrepancyKind = "missing_in_api"
+	// DiscrepancyDate marks differing publish dates.
+	DiscrepancyDate DiscrepancyKind = "date"
+	// DiscrepancyType marks differing inferred types.
+	DiscrepancyType DiscrepancyKind = "type"
+	// DiscrepancyVolume marks differing parsed volumes.
+	DiscrepancyVolume DiscrepancyKind = "volume"
+)
+
+// Discrepancy describes one difference between API and HTML results.
+// API and HTML hold the value each side reported; the side a post is
+// missing from is left blank.
+type Discrepancy struct {
+	Kind  DiscrepancyKind
+	Title string
+	Link  string
+	API   string
+	HTML  string
+}
+
+// runVerify collects the same range with both collectors, applies the
+// configured filters to each side, and writes a discrepancy report in
+// place of the usual table. A collector that fails part-way narrows the
+// comparison to the range both covered; warnings and failed fetches are
+// reported as in Run.
+func runVerify(ctx context.Context, cfg Config, options collect.Options, summary *runSummary, logger *Logger) error {
+	apiPosts, warnings, err := collect.FetchAPI(ctx, cfg.Cutoff, options)
+	apiReached, err := verifyReached(ctx, "API", err, logger)
+	if err != nil {
+		return err
+	}
+	htmlPosts, htmlWarnings, err := collect.FetchHTML(ctx, cfg.Cutoff, options)
+	warnings = append(warnings, htmlWarnings...)
+	htmlReached, err := verifyReached(ctx, "HTML", err, logger)
+	if err != nil {
+		return err
+	}
+
+	if warnings, err = reportWarnings(cfg, warnings, logger); err != nil {
+		return err
+	}
+	summary.recordWarnings(warnings)
+	if err := recordFailures(cfg, options.Failures.Items(), logger); err != nil {
+		return err
+	}
+
+	// Both sides are complete for posts after the later of the dates
+	// they reached; older posts would show up as missing on one side.
+	var covered time.Time
+	if apiReached.After(htmlReached) {
+		covered = apiReached
+	} else {
+		covered = htmlReached
+	}
+	if !covered.IsZero() {
+		logger.Warn("Verify: comparing only the range both collectors covered", "after", covered.Format("2006-01-02"))
+		apiPosts = postsAfter(apiPosts, covered)
+		htmlPosts = postsAfter(htmlPosts, covered)
+	}
+
+	collected := len(apiPosts) + len(htmlPosts)
+	apiPosts, _ = dedupePosts(apiPosts)
+	htmlPosts, _ = dedupePosts(htmlPosts)
+	unique := len(apiPosts) + len(htmlPosts)
+	apiPosts, stats := filterPosts(apiPosts, cfg)
+	htmlPosts, htmlStats := filterPosts(htmlPosts, cfg)
+	stats.add(htmlStats)
+	logger.Info("Verify: filters applied", "api", len(apiPosts), "html", len(htmlPosts))
+
+	discrepancies := comparePosts(apiPosts, htmlPosts)
+	summary.recordPosts(collected, unique, len(discrepancies), stats)
+	if err := writeVerifyOutput(cfg, covered, discrepancies); err != nil {
+		return err
+	}
+	if len(discrepancies) > 0 {
+		logger.Warn("Verify: API and HTML results differ", "discrepancies", len(discrepancies))
+
+		return fmt.Errorf("%w: %d discrepancies", ErrVerifyMismatch, len(discrepancies))
+	}
+	logger.Info("Verify: API and HTML results agree")
+
+	return checkWarnings(cfg, warnings)
+}
+
+// verifyReached checks the error one collector returned in verify mode.
+// A partial result is accepted and the date it reached returned; the
+// zero time means the collector covered the whole range.
+func verifyReached(ctx context.Context, side string, err error, logger *Logger) (time.Time, error) {
+	var partial *collect.PartialError
+	switch {
+	case err == nil:
+		return time.Time{}, nil
+	case ctx.Err() != nil:
+		return time.Time{}, err
+	case errors.As(err, &partial):
+		logger.Warn("Verify: "+side+" mode failed part-way; keeping the posts collected", "error", partial.Err, "reached", partial.Reached.Format("2006-01-02"))
+
+		return partial.Reached, nil
+	default:
+		logger.Error("Verify: "+side+" mode failed", "error", err)
+
+		return time.Time{}, err
+	}
+}
+
+// postsAfter returns the posts published after since.
+func postsAfter(posts model.Posts, since time.Time) model.Posts {
+	kept := make(model.Posts, 0, len(posts))
+	for _, post := range posts {
+		if post.Date.After(since) {
+			kept = append(kept, post)
+		}
+	}
+
+	return kept
+}
+
+// comparePosts matches posts by SourceID, then by canonical link, and
+// reports posts present on one side only plus differing dates, types and
+// volumes. The result is ordered by kind, then link.
+func comparePosts(apiPosts, htmlPosts model.Posts) []Discrepancy {
+	byID := make(map[int64]int, len(htmlPosts))
+	byLink := make(map[string]int, len(htmlPosts))
+	for i, post := range htmlPosts {
+		if post.SourceID != 0 {
+			byID[post.SourceID] = i
+		}
+		byLink[model.CanonicalLink(post.Link)] = i
+	}
+
+	var out []Discrepancy
+	matched := make(map[int]struct{}, len(htmlPosts))
+	for _, apiPost := range apiPosts {
+		idx, ok := byID[apiPost.SourceID]
+		if !ok {
+			idx, ok = byLink[model.CanonicalLink(apiPost.Link)]
+		}
+		if !ok {
+			out = append(out, Discrepancy{Kind: DiscrepancyMissingHTML, Title: apiPost.Title, Link: apiPost.Link, API: apiPost.FormatDate()})
+
+			continue
+		}
+		matched[idx] = struct{}{}
+		htmlPost := htmlPosts[idx]
+		if a, h := apiPost.FormatDate(), htmlPost.FormatDate(); a != h {
+			out = append(out, Discrepancy{Kind: DiscrepancyDate, Title: apiPost.Title, Link: apiPost.Link, API: a, HTML: h})
+		}
+		if apiPost.Type != htmlPost.Type {
+			out = append(out, Discrepancy{Kind: DiscrepancyType, Title: apiPost.Title, Link: apiPost.Link, API: string(apiPost.Type), HTML: string(htmlPost.Type)})
+		}
+		a := util.FormatVolumeWithExtra(apiPost.Volume, apiPost.VolumeExtra)
+		h := util.FormatVolumeWithExtra(htmlPost.Volume, htmlPost.VolumeExtra)
+		if a != h {
+			out = append(out, Discrepancy{Kind: DiscrepancyVolume, Title: apiPost.Title, Link: apiPost.Link, API: a, HTML: h})
+		}
+	}
+	for i, htmlPost := range htmlPosts {
+		if _, ok := matched[i]; !ok {
+			out = append(out, Discrepancy{Kind: DiscrepancyMissingAPI, Title: htmlPost.Title, Link: htmlPost.Link, HTML: htmlPost.FormatDate()})
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Kind != out[j].Kind {
+			return out[i].Kind < out[j].Kind
+		}
+
+		return out[i].Link < out[j].Link
+	})
+
+	return out
+}
+
+func writeVerifyOutput(cfg Config, covered time.Time, discrepancies []Discrepancy) error {
+	if cfg.OutputPath == "" {
+		return writeVerifyReport(os.Stdout, cfg, covered, discrepancies)
+	}
+	file, err := os.Create(cfg.OutputPath)
+	if err != nil {
+		return fmt.Errorf("open output path: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	return writeVerifyReport(file, cfg, covered, discrepancies)
+}
+
+// writeVerifyReport writes the discrepancy table. A non-zero covered is
+// the date the comparison was narrowed to because a collector stopped
+// early.
+func writeVerifyReport(w io.Writer, cfg Config, covered time.Time, discrepancies []Discrepancy) error {
+	scope := "cutoff: " + cfg.Cutoff.Format("2006-01-02")
+	if !covered.IsZero() {
+		scope += "; a collector stopped early, only posts after " + covered.Format("2006-01-02") + " compared"
+	}
+	if _, err := fmt.Fprintf(w, "API vs HTML verification (%s): %d discrepancies\n\n", scope, len(discrepancies)); err != nil {
+		return err
+	}
+	if len(discrepancies) == 0 {
+		return nil
+	}
+	if _, err := fmt.Fprint(w, "| Kind | Title | API | HTML | Link |\n|---|---|---|---|---|\n"); err != nil {
+		return err
+	}
+	for _, d := range discrepancies {
+		if _, err := fmt.Fprintf(w, "| %s | %s | %s | %s | [link](%s) |\n",
+			d.Kind, util.EscapePipes(d.Title), util.EscapePipes(d.API), util.EscapePipes(d.HTML), d.Link); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
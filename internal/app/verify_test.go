@@ -0,0 +1,145 @@
+// Test code reads a t.TempDir()-controlled path; gosec G304 is a
+// false positive here.
+//
+//nolint:gosec
+package app
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"git.skobk.in/skobkin/jnovel-scrape/internal/collect"
+	"git.skobk.in/skobkin/jnovel-scrape/internal/httpx"
+	"git.skobk.in/skobkin/jnovel-scrape/internal/model"
+)
+
+func TestComparePosts(t *testing.T) {
+	day := time.Date(2025, time.October, 10, 12, 0, 0, 0, time.UTC)
+	v1, v2 := 1.0, 2.0
+	apiPosts := model.Posts{
+		{Title: "Same", SourceID: 1, Link: "https://example.com/same/", Date: day, Type: model.TypeEPUB, Volume: &v1},
+		{Title: "Drifted", SourceID: 2, Link: "https://example.com/drifted/", Date: day, Type: model.TypeEPUB, Volume: &v2},
+		{Title: "API only", SourceID: 3, Link: "https://example.com/api-only/", Date: day, Type: model.TypePDF},
+		{Title: "By link", SourceID: 4, Link: "https://example.com/by-link/", Date: day, Type: model.TypePDF},
+	}
+	htmlPosts := model.Posts{
+		{Title: "Same", SourceID: 1, Link: "https://example.com/same/", Date: day, Type: model.TypeEPUB, Volume: &v1},
+		{Title: "Drifted", SourceID: 2, Link: "https://example.com/drifted-renamed/", Date: day.AddDate(0, 0, -1), Type: model.TypeUnknown},
+		{Title: "By link", Link: "https://EXAMPLE.com/by-link", Date: day, Type: model.TypePDF},
+		{Title: "HTML only", Link: "https://example.com/html-only/", Date: day, Type: model.TypeManga},
+	}
+
+	got := comparePosts(apiPosts, htmlPosts)
+	kinds := make([]string, len(got))
+	for i, d := range got {
+		kinds[i] = string(d.Kind) + ":" + d.Title
+	}
+	want := []string{
+		"date:Drifted",
+		"missing_in_api:HTML only",
+		"missing_in_html:API only",
+		"type:Drifted",
+		"volume:Drifted",
+	}
+	if strings.Join(kinds, ",") != strings.Join(want, ",") {
+		t.Fatalf("comparePosts() = %v, want %v", kinds, want)
+	}
+	if got[0].API != "2025-10-10" || got[0].HTML != "2025-10-09" {
+		t.Fatalf("unexpected date values: %+v", got[0])
+	}
+	if got[4].API != "2" || got[4].HTML != "" {
+		t.Fatalf("unexpected volume values: %+v", got[4])
+	}
+}
+
+func TestWriteVerifyReport(t *testing.T) {
+	cfg := Config{Cutoff: time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC)}
+	var buf bytes.Buffer
+	err := writeVerifyReport(&buf, cfg, time.Time{}, []Discrepancy{
+		{Kind: DiscrepancyType, Title: "A | B", Link: "https://example.com/a", API: "EPUB", HTML: "UNKNOWN"},
+	})
+	if err != nil {
+		t.Fatalf("writeVerifyReport() error: %v", err)
+	}
+	out := buf.String()
+	if !strings.HasPrefix(out, "API vs HTML verification (cutoff: 2025-10-01): 1 discrepancies") {
+		t.Fatalf("unexpected header: %q", out)
+	}
+	if !strings.Contains(out, "| type | A \\| B | EPUB | UNKNOWN | [link](https://example.com/a) |") {
+		t.Fatalf("unexpected row: %q", out)
+	}
+}
+
+func TestRunVerifyComparesCoveredRange(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/wp-json/wp/v2/posts":
+			w.Header().Set("X-WP-TotalPages", "1")
+			fmt.Fprint(w, `[
+				{"id":1,"date":"2025-10-20T00:00:00","date_gmt":"2025-10-20T00:00:00","link":"https://example.com/first-volume-1-epub/","title":{"rendered":"First Volume 1 EPUB"}},
+				{"id":2,"date":"2025-10-18T00:00:00","date_gmt":"2025-10-18T00:00:00","link":"https://example.com/second-volume-2-epub/","title":{"rendered":"Second Volume 2 EPUB"}},
+				{"id":3,"date":"2025-10-05T00:00:00","date_gmt":"2025-10-05T00:00:00","link":"https://example.com/third-volume-3-epub/","title":{"rendered":"Third Volume 3 EPUB"}}]`)
+		case "/wp-json/wp/v2/categories", "/wp-json/wp/v2/tags":
+			fmt.Fprint(w, `[]`)
+		case "/", "":
+			fmt.Fprint(w, `<html><body>
+				<article id="post-1"><h2 class="entry-title"><a href="https://example.com/first-volume-1-epub/">First Volume 1 EPUB</a></h2>
+					<time datetime="2025-10-20T00:00:00Z"></time></article>
+				<article id="post-2"><h2 class="entry-title"><a href="https://example.com/second-volume-2-epub/">Second Volume 2 EPUB</a></h2>
+					<time datetime="2025-10-18T00:00:00Z"></time></article>
+			</body></html>`)
+		default:
+			// The second archive page fails, so the HTML side never
+			// reaches the third post.
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	deadLetter := filepath.Join(dir, "failed.json")
+	warningsPath := filepath.Join(dir, "warnings.json")
+	outPath := filepath.Join(dir, "verify.md")
+	cfg := Config{
+		Command:        CommandScrape,
+		Mode:           ModeVerify,
+		Cutoff:         time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC),
+		DeadLetterPath: deadLetter,
+		WarningsOut:    warningsPath,
+		OutputPath:     outPath,
+		TypeFilters:    map[model.PostType]struct{}{},
+	}
+	client := httpx.NewClient(time.Millisecond, 5*time.Millisecond, httpx.WithHTTPClient(server.Client()), httpx.WithJitterFactor(0))
+	options := collect.Options{BaseURL: server.URL, MaxPages: 3, Concurrency: 1, Client: client, RetryBackoff: time.Millisecond}
+
+	if err := runScrape(context.Background(), cfg, options, nil, NewLogger(io.Discard)); err != nil {
+		t.Fatalf("the covered range agrees, got %v", err)
+	}
+
+	out, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "only posts after 2025-10-18 compared): 0 discrepancies") {
+		t.Fatalf("the report should name the covered range:\n%s", out)
+	}
+	failed, err := loadDeadLetter(deadLetter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failed) != 1 || failed[0].Kind != collect.FailureHTMLPage {
+		t.Fatalf("expected the archive page in the dead-letter file, got %+v", failed)
+	}
+	if _, err := os.Stat(warningsPath); err != nil {
+		t.Fatalf("the warnings file must be written: %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"git.skobk.in/skobkin/jnovel-scrape/internal/collect"
+)
+
+// ErrWarnings is returned, wrapped, by a run whose warnings matched
+// --strict or --fail-on. The output has been written.
+var ErrWarnings = errors.New("run produced warnings")
+
+// reportWarnings drops the warnings --suppress-warnings names, logs the
+// rest and writes them to --warnings-out. It returns the warnings kept.
+func reportWarnings(cfg Config, warnings []collect.Warning, logger *Logger) ([]collect.Warning, error) {
+	kept := make([]collect.Warning, 0, len(warnings))
+	for _, warn := range warnings {
+		if slices.Contains(cfg.SuppressWarnings, warn.Code) {
+			continue
+		}
+		kept = append(kept, warn)
+		args := []any{"code", warn.Code}
+		if warn.Link != "" {
+			args = append(args, "url", warn.Link)
+		}
+		logger.Warn(warn.Detail, args...)
+	}
+	if cfg.WarningsOut == "" {
+		return kept, nil
+	}
+
+	data, err := json.MarshalIndent(kept, "", "  ")
+	if err != nil {
+		return kept, fmt.Errorf("encode warnings file: %w", err)
+	}
+	if err := writeFileAtomic(cfg.WarningsOut, append(data, '\n')); err != nil {
+		return kept, fmt.Errorf("write warnings file: %w", err)
+	}
+
+	return kept, nil
+}
+
+// checkWarnings returns an ErrWarnings error naming the codes that fail
+// the run: any code with --strict, the --fail-on ones otherwise.
+func checkWarnings(cfg Config, warnings []collect.Warning) error {
+	counts := make(map[collect.WarningCode]int)
+	for _, warn := range warnings {
+		if cfg.Strict || slices.Contains(cfg.FailOn, warn.Code) {
+			counts[warn.Code]++
+		}
+	}
+	if len(counts) == 0 {
+		return nil
+	}
+
+	parts := make([]string, 0, len(counts))
+	for _, code := range collect.WarningCodes {
+		if n := counts[code]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%s=%d", code, n))
+		}
+	}
+
+	return fmt.Errorf("%w: %s", ErrWarnings, strings.Join(parts, " "))
+}
@@ -0,0 +1,79 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"git.skobk.in/skobkin/jnovel-scrape/internal/collect"
+)
+
+func TestReportWarnings(t *testing.T) {
+	var buf bytes.Buffer
+	path := filepath.Join(t.TempDir(), "warnings.json")
+	cfg := Config{WarningsOut: path, SuppressWarnings: []collect.WarningCode{collect.WarningBeforeCutoff}}
+	warnings := []collect.Warning{
+		{Code: collect.WarningMissingVolume, Link: "https://example.com/a", Detail: "missing volume (no regex match) → kept with blank volume"},
+		{Code: collect.WarningBeforeCutoff, Link: "https://example.com/b", Detail: "skipped (date 2025-01-01 before cutoff)"},
+		{Code: collect.WarningLookupFailed, Detail: "cover lookup failed: timeout → covers left blank"},
+	}
+
+	kept, err := reportWarnings(cfg, warnings, NewLogger(&buf))
+	if err != nil {
+		t.Fatalf("reportWarnings() error: %v", err)
+	}
+	if len(kept) != 2 || kept[0].Code != collect.WarningMissingVolume || kept[1].Code != collect.WarningLookupFailed {
+		t.Fatalf("expected the suppressed warning to be dropped, got %+v", kept)
+	}
+
+	logged := buf.String()
+	if strings.Contains(logged, "example.com/b") {
+		t.Fatalf("suppressed warnings must not be logged, got %q", logged)
+	}
+	if !strings.Contains(logged, `level=WARN msg="missing volume (no regex match) → kept with blank volume" code=missing_volume url=https://example.com/a`) {
+		t.Fatalf("unexpected log output: %q", logged)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read warnings file: %v", err)
+	}
+	var written []collect.Warning
+	if err := json.Unmarshal(data, &written); err != nil {
+		t.Fatalf("decode warnings file: %v", err)
+	}
+	if len(written) != 2 || written[0] != kept[0] || written[1] != kept[1] {
+		t.Fatalf("unexpected warnings file: %s", data)
+	}
+	if !strings.Contains(string(data), `"code": "lookup_failed"`) || strings.Contains(string(data), `"link": ""`) {
+		t.Fatalf("unexpected warnings file layout: %s", data)
+	}
+}
+
+func TestCheckWarnings(t *testing.T) {
+	warnings := []collect.Warning{
+		{Code: collect.WarningUnknownType},
+		{Code: collect.WarningMissingVolume},
+		{Code: collect.WarningMissingVolume},
+	}
+
+	if err := checkWarnings(Config{}, warnings); err != nil {
+		t.Fatalf("warnings must not fail a run by default, got %v", err)
+	}
+	if err := checkWarnings(Config{FailOn: []collect.WarningCode{collect.WarningFetchFailed}}, warnings); err != nil {
+		t.Fatalf("only --fail-on codes may fail the run, got %v", err)
+	}
+
+	err := checkWarnings(Config{FailOn: []collect.WarningCode{collect.WarningMissingVolume}}, warnings)
+	if !errors.Is(err, ErrWarnings) || !strings.HasSuffix(err.Error(), ": missing_volume=2") {
+		t.Fatalf("unexpected --fail-on error: %v", err)
+	}
+	err = checkWarnings(Config{Strict: true}, warnings)
+	if !errors.Is(err, ErrWarnings) || !strings.HasSuffix(err.Error(), ": missing_volume=2 unknown_type=1") {
+		t.Fatalf("unexpected --strict error: %v", err)
+	}
+}
@@ -3,24 +3,134 @@ package collect
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
 	"net/url"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"git.skobk.in/skobkin/jnovel-scrape/internal/event"
 	"git.skobk.in/skobkin/jnovel-scrape/internal/model"
 	"git.skobk.in/skobkin/jnovel-scrape/internal/util"
 )
 
 // FetchAPI crawls posts using the WordPress REST API.
-func FetchAPI(ctx context.Context, cutoff time.Time, opt Options) (model.Posts, []string, error) {
+func FetchAPI(ctx context.Context, cutoff time.Time, opt Options) (model.Posts, []Warning, error) {
+	opt, err := apiDefaults(opt)
+	if err != nil {
+		return nil, nil, err
+	}
+	logger := opt.logger()
+
+	logger.Info("API mode", "cutoff", cutoff.Format("2006-01-02"), "max_pages", opt.MaxPages)
+
+	postsEndpoint, err := url.JoinPath(opt.BaseURL, "/wp-json/wp/v2/posts")
+	if err != nil {
+		return nil, nil, fmt.Errorf("build posts endpoint: %w", err)
+	}
+
+	var (
+		rawPosts   []apiPost
+		stopPaging bool
+		crawlErr   error
+	)
+	seenIDs := make(map[int64]struct{})
+
+	for _, search := range searchQueries(opt.Search) {
+		if search != "" {
+			logger.Info("API search", "search", search)
+		}
+		pagePosts, stopped, err := fetchAPIPosts(ctx, opt, postsEndpoint, cutoff, search)
+		if err != nil && opt.APIStrategy != APIStrategyFull && isStatus(err, http.StatusBadRequest) {
+			logger.Info("API rejected trimmed request; retrying with full post objects", "error", err)
+			opt.observe(event.FallbackTriggered{From: string(opt.APIStrategy), To: string(APIStrategyFull), Err: err})
+			opt.APIStrategy = APIStrategyFull
+			pagePosts, stopped, err = fetchAPIPosts(ctx, opt, postsEndpoint, cutoff, search)
+		}
+		if err != nil {
+			// Only a plain archive listing is resumed: the date a search
+			// reached vouches for that needle's matches alone, not for
+			// the full listing the HTML fallback would pick up from.
+			// Cancellation keeps whatever was collected either way.
+			interrupted := ctx.Err() != nil
+			collected := len(pagePosts) > 0 || (opt.sink != nil && !opt.sink.oldest.IsZero())
+			if !interrupted && (search != "" || !collected) {
+				return nil, nil, err
+			}
+			crawlErr = err
+		}
+		stopPaging = stopPaging || stopped
+		for _, ap := range pagePosts {
+			if _, ok := seenIDs[ap.ID]; ok {
+				continue
+			}
+			seenIDs[ap.ID] = struct{}{}
+			rawPosts = append(rawPosts, ap)
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	if stopPaging {
+		logger.Info("API pagination stopped after encountering posts older than cutoff")
+	}
+	if opt.sink != nil {
+		// Every post has been streamed already.
+		return nil, nil, partialAPI(crawlErr, opt.sink.oldest)
+	}
+	if len(rawPosts) == 0 {
+		return nil, nil, crawlErr
+	}
+
+	allPosts, warnings, err := buildAPIPosts(ctx, opt, cutoff, rawPosts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if crawlErr != nil {
+		if len(allPosts) == 0 {
+			return nil, warnings, crawlErr
+		}
+
+		return allPosts, warnings, partialAPI(crawlErr, allPosts[len(allPosts)-1].Date)
+	}
+
+	return allPosts, warnings, nil
+}
+
+// partialAPI wraps a crawl error that came after posts down to oldest
+// were collected in a PartialError.
+func partialAPI(err error, oldest time.Time) error {
+	if err == nil {
+		return nil
+	}
+	var gap *pageGapError
+	if errors.As(err, &gap) {
+		// Pages past the gap are included but the range is only
+		// complete down to the last page before it.
+		return &PartialError{Err: gap.err, Reached: gap.reached}
+	}
+	if oldest.IsZero() {
+		return err
+	}
+
+	return &PartialError{Err: err, Reached: oldest}
+}
+
+// apiDefaults checks the options every API entry point needs and fills
+// in defaults for the optional ones.
+func apiDefaults(opt Options) (Options, error) {
 	if opt.Client == nil {
-		return nil, nil, fmt.Errorf("http client is required")
+		return opt, fmt.Errorf("http client is required")
 	}
 	if opt.BaseURL == "" {
 		opt.BaseURL = DefaultBaseURL
@@ -28,197 +138,750 @@ func FetchAPI(ctx context.Context, cutoff time.Time, opt Options) (model.Posts,
 	if opt.MaxPages <= 0 {
 		opt.MaxPages = 2000
 	}
-
-	logger := opt.Logger
-	if logger == nil {
-		logger = noopLogger{}
+	if opt.Concurrency <= 0 {
+		opt.Concurrency = 4
 	}
 
-	logger.Infof("API mode: cutoff=%s maxPages=%d", cutoff.Format("2006-01-02"), opt.MaxPages)
+	return opt, nil
+}
 
-	postsEndpoint, err := url.JoinPath(opt.BaseURL, "/wp-json/wp/v2/posts")
+// buildAPIPosts resolves taxonomy names (and covers when asked) for raw
+// API posts and transforms them into sorted model posts.
+func buildAPIPosts(ctx context.Context, opt Options, cutoff time.Time, rawPosts []apiPost) (model.Posts, []Warning, error) {
+	posts, warnings, err := newAPIBuilder(opt, cutoff).build(ctx, rawPosts)
 	if err != nil {
-		return nil, nil, fmt.Errorf("build posts endpoint: %w", err)
+		return nil, nil, err
 	}
+	posts.Sort()
+
+	return posts, warnings, nil
+}
+
+// apiBuilder turns raw API posts into model posts. Its taxonomy
+// resolvers persist across calls, so a streamed crawl that builds page
+// by page looks every term up once.
+type apiBuilder struct {
+	opt        Options
+	cutoff     time.Time
+	categories *taxonomyResolver
+	tags       *taxonomyResolver
+}
+
+func newAPIBuilder(opt Options, cutoff time.Time) *apiBuilder {
+	savedCategories, savedTags := opt.Checkpoints.taxonomy()
+	b := &apiBuilder{
+		opt:        opt,
+		cutoff:     cutoff,
+		categories: newTaxonomyResolver("categories", opt),
+		tags:       newTaxonomyResolver("tags", opt),
+	}
+	b.categories.Seed(savedCategories)
+	b.tags.Seed(savedTags)
+
+	return b
+}
+
+// build returns the posts of rawPosts in their original order.
+func (b *apiBuilder) build(ctx context.Context, rawPosts []apiPost) (model.Posts, []Warning, error) {
+	opt, cutoff := b.opt, b.cutoff
+	logger := opt.logger()
+	var warnings []Warning
 
-	var (
-		rawPosts   []apiPost
-		warnings   []string
-		totalPages int
-		stopPaging bool
-	)
 	categoryIDs := make(map[int]struct{})
 	tagIDs := make(map[int]struct{})
-
-	for page := 1; page <= opt.MaxPages; page++ {
-		reqURL, err := url.Parse(postsEndpoint)
-		if err != nil {
-			return nil, nil, fmt.Errorf("parse posts endpoint: %w", err)
+	embeddedCategories := make(map[int]string)
+	embeddedTags := make(map[int]string)
+	for _, ap := range rawPosts {
+		ap.collectEmbeddedTerms(embeddedCategories, embeddedTags)
+		for _, id := range ap.Categories {
+			categoryIDs[id] = struct{}{}
 		}
-		query := reqURL.Query()
-		query.Set("per_page", "100")
-		query.Set("page", strconv.Itoa(page))
-		query.Set("order", "desc")
-		query.Set("orderby", "date")
-		query.Set("after", cutoff.Format(time.RFC3339))
-		reqURL.RawQuery = query.Encode()
+		for _, id := range ap.Tags {
+			tagIDs[id] = struct{}{}
+		}
+	}
 
-		req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL.String(), nil)
-		if err != nil {
-			return nil, nil, err
+	// Names delivered inline via _embed need no lookup. Anything left over
+	// (all of it when the site strips embeds) goes to the taxonomy endpoint.
+	categories, tags := b.categories, b.tags
+	categories.Seed(embeddedCategories)
+	tags.Seed(embeddedTags)
+
+	categoryList := missingKeys(categoryIDs, categories.names())
+	tagList := missingKeys(tagIDs, tags.names())
+	if opt.APIStrategy == APIStrategyEmbed && len(embeddedCategories)+len(embeddedTags) == 0 && len(categoryList)+len(tagList) > 0 {
+		logger.Info("API response carried no embedded terms; falling back to taxonomy lookups")
+	}
+	if len(categoryList)+len(tagList) > 0 {
+		logger.Info("API taxonomy lookup", "categories", len(categoryList), "tags", len(tagList))
+	}
+
+	if _, err := categories.Resolve(ctx, categoryList); err != nil {
+		if ctx.Err() == nil {
+			return nil, nil, fmt.Errorf("fetch categories: %w", err)
 		}
-		setStandardHeaders(req, opt.UserAgent)
+		// Interrupted: build the posts anyway, typed from their titles.
+		warnings = append(warnings, warnf(WarningLookupFailed, "", "category lookup interrupted: %v → names left blank", err))
+	}
+	if _, err := tags.Resolve(ctx, tagList); err != nil {
+		if ctx.Err() == nil {
+			return nil, nil, fmt.Errorf("fetch tags: %w", err)
+		}
+		warnings = append(warnings, warnf(WarningLookupFailed, "", "tag lookup interrupted: %v → names left blank", err))
+	}
+	opt.Checkpoints.recordTaxonomy(categories, tags)
 
-		resp, err := opt.Client.Do(ctx, req)
+	var media map[int]apiMedia
+	if opt.FetchCovers {
+		var err error
+		media, err = resolveCovers(ctx, opt, rawPosts)
 		if err != nil {
-			return nil, nil, err
+			warnings = append(warnings, warnf(WarningLookupFailed, "", "cover lookup failed: %v → covers left blank", err))
 		}
-		if resp.StatusCode >= 400 {
-			payload, _ := io.ReadAll(resp.Body)
-			_ = resp.Body.Close()
+	}
 
-			return nil, nil, fmt.Errorf("posts request failed: %s (%s)", resp.Status, string(payload))
+	var allPosts model.Posts
+	for _, ap := range rawPosts {
+		post, warn, skip := transformAPIPost(ap, cutoff, categories.Lookup(ap.Categories), tags.Lookup(ap.Tags))
+		if warn != nil {
+			warnings = append(warnings, *warn)
 		}
-
-		if page == 1 {
-			if headerVal := resp.Header.Get("X-WP-TotalPages"); headerVal != "" {
-				if total, err := strconv.Atoi(headerVal); err == nil {
-					totalPages = total
-				}
+		if skip {
+			reason := "date before cutoff"
+			if warn != nil {
+				reason = warn.Detail
 			}
-			if totalPages == 0 {
-				totalPages = opt.MaxPages
+			opt.observe(event.PostSkipped{Source: event.SourceAPI, URL: ap.Link, Reason: reason})
+
+			continue
+		}
+		if post != nil {
+			if item, ok := media[ap.Media]; ok {
+				post.CoverURL = item.SourceURL
+				post.CoverWidth = item.Details.Width
+				post.CoverHeight = item.Details.Height
 			}
+			opt.observe(event.PostParsed{Source: event.SourceAPI, Post: *post})
+			allPosts = append(allPosts, *post)
 		}
+	}
 
-		var apiPosts []apiPost
-		if err := decodeJSON(resp.Body, &apiPosts); err != nil {
-			_ = resp.Body.Close()
+	return allPosts, warnings, nil
+}
 
-			return nil, nil, err
+// resolveCovers maps featured media ids to media items, taking embedded
+// items where the response carried them and batch-fetching the rest.
+func resolveCovers(ctx context.Context, opt Options, posts []apiPost) (map[int]apiMedia, error) {
+	media := make(map[int]apiMedia)
+	missing := make(map[int]struct{})
+	for _, ap := range posts {
+		if ap.Media == 0 {
+			continue
 		}
-		_ = resp.Body.Close()
+		if item, ok := ap.embeddedMedia(); ok {
+			media[ap.Media] = item
 
-		logger.Infof("API page=%d returned %d posts", page, len(apiPosts))
+			continue
+		}
+		missing[ap.Media] = struct{}{}
+	}
+	for id := range media {
+		delete(missing, id)
+	}
+	if len(missing) == 0 {
+		return media, nil
+	}
+	opt.logger().Info("API media lookup", "covers", len(missing))
 
-		if len(apiPosts) == 0 {
-			break
+	fetched, err := fetchMedia(ctx, opt, sortedKeys(missing))
+	if err != nil {
+		return media, err
+	}
+	for id, item := range fetched {
+		media[id] = item
+	}
+
+	return media, nil
+}
+
+// fetchAPIPosts pages through the posts endpoint for a single query. An
+// empty search lists every post; otherwise the needle is passed to the
+// WordPress full-text search.
+//
+// The first page is fetched on its own to learn X-WP-TotalPages; the
+// remaining pages are then fetched concurrently by fetchAPIPageRange.
+// Failed pages get a second attempt after opt.RetryBackoff. When a page
+// still fails, the posts of every other page are returned along with a
+// *pageGapError.
+func fetchAPIPosts(ctx context.Context, opt Options, endpoint string, cutoff time.Time, search string) ([]apiPost, bool, error) {
+	if search != "" {
+		// Only the full listing is checkpointed.
+		opt.Checkpoints = nil
+	} else if saved := opt.Checkpoints.apiProgress(); saved != nil && saved.Page > 0 {
+		return resumeAPIPosts(ctx, opt, endpoint, cutoff, saved)
+	}
+
+	first, totalPages, err := fetchAPIPage(ctx, opt, endpoint, cutoff, search, 1)
+	if err != nil && retryable(err) {
+		opt.logger().Info("API page failed; retrying", "page", 1, "error", err, "wait", opt.RetryBackoff)
+		observeAPIRetry(opt, endpoint, cutoff, search, 1, err)
+		if waitErr := waitRetryBackoff(ctx, opt); waitErr != nil {
+			return nil, false, err
 		}
+		first, totalPages, err = fetchAPIPage(ctx, opt, endpoint, cutoff, search, 1)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if totalPages == 0 {
+		totalPages = opt.MaxPages
+	}
+	lastPage := min(totalPages, opt.MaxPages)
+	opt.Checkpoints.recordAPIPage(1, totalPages, first)
+
+	rule := newStopRule(cutoff, opt.StopAfter)
+	rawPosts := takeAPIPage(ctx, opt, cutoff, nil, first)
+	stopped := observeAPIPage(rule, first)
+	if len(first) == 0 || stopped || lastPage <= 1 {
+		return rawPosts, stopped, nil
+	}
 
-		for _, ap := range apiPosts {
-			rawPosts = append(rawPosts, ap)
-			for _, id := range ap.Categories {
-				categoryIDs[id] = struct{}{}
+	rest, stopped, err := fetchAPIPageRange(ctx, opt, endpoint, cutoff, search, 2, lastPage, rule)
+	rawPosts = append(rawPosts, rest...)
+	var gap *pageGapError
+	if errors.As(err, &gap) && gap.reached.IsZero() {
+		// The gap starts right after the first page.
+		gap.reached = oldestAPIDate(first)
+	}
+	if err != nil {
+		return rawPosts, false, err
+	}
+
+	return rawPosts, stopped, nil
+}
+
+// resumeAPIPosts continues the full listing after the pages recorded in
+// a checkpoint. Posts published since then shift the listing towards
+// later pages, which only repeats a few posts; FetchAPI drops them by ID.
+func resumeAPIPosts(ctx context.Context, opt Options, endpoint string, cutoff time.Time, saved *APICheckpoint) ([]apiPost, bool, error) {
+	opt.logger().Info("API resuming from checkpoint", "page", saved.Page, "posts", len(saved.Posts))
+	rule := newStopRule(cutoff, opt.StopAfter)
+	rawPosts := takeAPIPage(ctx, opt, cutoff, nil, saved.Posts)
+	stopped := observeAPIPage(rule, saved.Posts)
+	lastPage := min(saved.TotalPages, opt.MaxPages)
+	if stopped || saved.Page >= lastPage {
+		return rawPosts, stopped, nil
+	}
+
+	rest, stopped, err := fetchAPIPageRange(ctx, opt, endpoint, cutoff, "", saved.Page+1, lastPage, rule)
+	rawPosts = append(rawPosts, rest...)
+	var gap *pageGapError
+	if errors.As(err, &gap) && gap.reached.IsZero() {
+		gap.reached = oldestAPIDate(saved.Posts)
+	}
+	if err != nil {
+		return rawPosts, false, err
+	}
+
+	return rawPosts, stopped, nil
+}
+
+// pageGapError reports listing pages that still failed after the second
+// pass. The posts returned with it include pages past the gap, but they
+// are only known to be complete down to reached.
+type pageGapError struct {
+	err     error
+	reached time.Time
+}
+
+func (e *pageGapError) Error() string {
+	return e.err.Error()
+}
+
+func (e *pageGapError) Unwrap() error {
+	return e.err
+}
+
+// retryable reports whether a failed request is worth a second pass.
+// Cancellation and requests the server rejected outright are not.
+func retryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	return !isStatus(err, http.StatusBadRequest) && !isStatus(err, http.StatusNotFound) && !isStatus(err, http.StatusGone)
+}
+
+func oldestAPIDate(posts []apiPost) time.Time {
+	var oldest time.Time
+	for _, ap := range posts {
+		if parsed, err := parseWPTime(ap.Date, ap.DateGMT); err == nil && (oldest.IsZero() || parsed.Before(oldest)) {
+			oldest = parsed
+		}
+	}
+
+	return oldest
+}
+
+type apiPageResult struct {
+	page  int
+	posts []apiPost
+	err   error
+}
+
+// fetchAPIPageRange fetches pages [from, to] with up to opt.Concurrency
+// requests in flight; the shared rate limiter in opt.Client still spaces
+// them out. Failed pages do not stop the others; they are retried once
+// more after opt.RetryBackoff, and the ones that still fail are reported
+// through opt.Failures. Pages are replayed in order through rule as they
+// arrive, which carries the run of older posts over from earlier pages;
+// the range ends after the page that trips it, so in-flight pages past
+// it are discarded, and no new pages are scheduled once it tripped or
+// any page came back empty.
+//
+// The requests already ask for posts after the cutoff, so the listing
+// normally ends on its own before rule trips; rule guards against
+// servers and caches that ignore the after parameter.
+func fetchAPIPageRange(ctx context.Context, opt Options, endpoint string, cutoff time.Time, search string, from, to int, rule *stopRule) ([]apiPost, bool, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var stop atomic.Bool
+	jobCh := make(chan int)
+	resultCh := make(chan apiPageResult)
+	var wg sync.WaitGroup
+
+	for i := 0; i < opt.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for page := range jobCh {
+				posts, _, err := fetchAPIPage(ctx, opt, endpoint, cutoff, search, page)
+				if err == nil && len(posts) == 0 || isStatus(err, http.StatusBadRequest) {
+					stop.Store(true)
+				}
+				resultCh <- apiPageResult{page: page, posts: posts, err: err}
 			}
-			for _, id := range ap.Tags {
-				tagIDs[id] = struct{}{}
+		}()
+	}
+
+	go func() {
+		defer close(jobCh)
+		for page := from; page <= to; page++ {
+			if stop.Load() {
+				return
 			}
-			if !stopPaging {
-				if parsed, err := parseWPTime(ap.Date, ap.DateGMT); err == nil && parsed.Before(cutoff) {
-					stopPaging = true
+			select {
+			case jobCh <- page:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(resultCh)
+	}()
+
+	pages := make(map[int][]apiPost)
+	failed := make(map[int]error)
+	var (
+		rawPosts   []apiPost
+		oldest     time.Time
+		stopPaging bool
+		gap        *pageGapError
+		next       = from
+		finished   bool
+	)
+	// assemble replays the pages from next on, in order, through rule as
+	// far as they have arrived, recording each in the checkpoint and
+	// streaming it when opt.sink is set. A failed page holds it up until
+	// the second pass is over (final); after that it is part of the gap.
+	assemble := func(final bool) {
+		for ; !finished && next <= to; next++ {
+			if err, ok := failed[next]; ok {
+				if isStatus(err, http.StatusBadRequest) {
+					// Without X-WP-TotalPages the range runs to MaxPages,
+					// and WordPress answers a page past the last one with
+					// 400 rest_post_invalid_page_number. Every page before
+					// this one was assembled, so the listing ends here.
+					finished = true
+
+					return
 				}
+				if !final {
+					return
+				}
+				if gap == nil {
+					gap = &pageGapError{err: fmt.Errorf("page %d: %w", next, err), reached: oldest}
+				}
+				if reqURL, urlErr := apiPageURL(opt, endpoint, cutoff, search, next); urlErr == nil {
+					opt.Failures.add(FailedFetch{Kind: FailureAPIPage, URL: reqURL.String(), Error: err.Error()})
+				}
+
+				continue
+			}
+			posts, ok := pages[next]
+			if !ok || len(posts) == 0 {
+				// An empty page is past the last post; a missing one is
+				// either still in flight or was never scheduled.
+				finished = ok || final
+
+				return
+			}
+			opt.Checkpoints.recordAPIPage(next, to, posts)
+			if pageOldest := oldestAPIDate(posts); !pageOldest.IsZero() && (oldest.IsZero() || pageOldest.Before(oldest)) {
+				oldest = pageOldest
+			}
+			rawPosts = takeAPIPage(ctx, opt, cutoff, rawPosts, posts)
+			if observeAPIPage(rule, posts) {
+				stopPaging, finished = true, true
+				stop.Store(true)
+
+				return
 			}
 		}
+	}
 
-		if page >= totalPages || stopPaging {
-			break
+	for result := range resultCh {
+		if result.err != nil {
+			failed[result.page] = result.err
+
+			continue
 		}
+		pages[result.page] = result.posts
+		assemble(false)
 	}
 
-	if len(rawPosts) == 0 {
-		return nil, warnings, nil
+	// Once assembly finished, every failed page lies past the end.
+	if len(failed) > 0 && !finished {
+		opt.logger().Info("API retrying failed pages", "pages", len(failed), "wait", opt.RetryBackoff)
+		retry := slices.DeleteFunc(slices.Sorted(maps.Keys(failed)), func(page int) bool {
+			return !retryable(failed[page])
+		})
+		for _, page := range retry {
+			observeAPIRetry(opt, endpoint, cutoff, search, page, failed[page])
+		}
+		if waitRetryBackoff(ctx, opt) != nil {
+			retry = nil
+		}
+		for _, page := range retry {
+			posts, _, err := fetchAPIPage(ctx, opt, endpoint, cutoff, search, page)
+			if err != nil {
+				failed[page] = err
+
+				continue
+			}
+			delete(failed, page)
+			pages[page] = posts
+		}
 	}
+	assemble(true)
 
-	if stopPaging {
-		logger.Infof("API pagination stopped after encountering posts older than cutoff")
+	if gap != nil {
+		return rawPosts, false, gap
 	}
 
-	categoryList := sortedKeys(categoryIDs)
-	if len(categoryList) > 0 {
-		logger.Infof("API taxonomy lookup: categories=%d", len(categoryList))
+	return rawPosts, stopPaging, nil
+}
+
+// takeAPIPage hands a finished listing page to the stream, or appends it
+// to rawPosts when the crawl is not streamed.
+func takeAPIPage(ctx context.Context, opt Options, cutoff time.Time, rawPosts, page []apiPost) []apiPost {
+	if opt.sink != nil {
+		opt.sink.emitAPI(ctx, opt, cutoff, page)
+
+		return rawPosts
 	}
 
-	categoryMap, err := fetchSelectedTaxonomy(ctx, opt, "categories", categoryList)
+	return append(rawPosts, page...)
+}
+
+// fetchAPIPage requests a single page of posts. The second return value
+// carries X-WP-TotalPages when the server sends it, and zero otherwise.
+func fetchAPIPage(ctx context.Context, opt Options, endpoint string, cutoff time.Time, search string, page int) ([]apiPost, int, error) {
+	reqURL, err := apiPageURL(opt, endpoint, cutoff, search, page)
 	if err != nil {
-		return nil, nil, fmt.Errorf("fetch categories: %w", err)
+		return nil, 0, err
 	}
 
-	var allPosts model.Posts
-	for _, ap := range rawPosts {
-		categoryNames := lookupNames(categoryMap, ap.Categories)
+	apiPosts, totalPages, err := getAPIPosts(ctx, opt, reqURL)
+	if err != nil {
+		return nil, 0, err
+	}
 
-		post, warn, skip := transformAPIPost(ap, cutoff, categoryNames, nil)
-		if warn != "" {
-			warnings = append(warnings, warn)
-		}
-		if skip {
-			continue
+	opt.logger().Info("API page fetched", "page", page, "total_pages", totalPages, "posts", len(apiPosts), "url", reqURL.String())
+	opt.observe(event.PageFetched{Source: event.SourceAPI, Page: page, URL: reqURL.String(), Posts: len(apiPosts), Total: totalPages})
+
+	return apiPosts, totalPages, nil
+}
+
+// observeAPIRetry reports a failed listing page queued for the second
+// pass.
+func observeAPIRetry(opt Options, endpoint string, cutoff time.Time, search string, page int, err error) {
+	if reqURL, urlErr := apiPageURL(opt, endpoint, cutoff, search, page); urlErr == nil {
+		opt.observeSecondPass(reqURL.String(), err)
+	}
+}
+
+// apiPageURL builds the request URL of one page of the posts listing.
+func apiPageURL(opt Options, endpoint string, cutoff time.Time, search string, page int) (*url.URL, error) {
+	reqURL, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("parse posts endpoint: %w", err)
+	}
+	query := reqURL.Query()
+	query.Set("per_page", "100")
+	query.Set("page", strconv.Itoa(page))
+	query.Set("order", "desc")
+	query.Set("orderby", "date")
+	query.Set("after", cutoff.Format(time.RFC3339))
+	if search != "" {
+		query.Set("search", search)
+	}
+	applyAPIStrategy(query, opt)
+	reqURL.RawQuery = query.Encode()
+
+	return reqURL, nil
+}
+
+// fetchAPIPageURL re-requests a posts page recorded as a FailedFetch.
+func fetchAPIPageURL(ctx context.Context, opt Options, rawURL string) ([]apiPost, error) {
+	reqURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse page URL: %w", err)
+	}
+	posts, _, err := getAPIPosts(ctx, opt, reqURL)
+
+	return posts, err
+}
+
+// getAPIPosts performs one posts request and decodes the response. The
+// second return value carries X-WP-TotalPages when the server sends it.
+func getAPIPosts(ctx context.Context, opt Options, reqURL *url.URL) ([]apiPost, int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL.String(), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	setStandardHeaders(req, opt.UserAgent)
+
+	resp, err := opt.Client.Do(ctx, req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode >= 400 {
+		payload, _ := io.ReadAll(resp.Body)
+
+		return nil, 0, &statusError{what: "posts", status: resp.Status, code: resp.StatusCode, body: string(payload)}
+	}
+
+	totalPages := 0
+	if headerVal := resp.Header.Get("X-WP-TotalPages"); headerVal != "" {
+		if total, err := strconv.Atoi(headerVal); err == nil {
+			totalPages = total
 		}
-		if post != nil {
-			allPosts = append(allPosts, *post)
+	}
+
+	var apiPosts []apiPost
+	if err := decodeJSON(resp.Body, &apiPosts); err != nil {
+		return nil, 0, err
+	}
+
+	return apiPosts, totalPages, nil
+}
+
+// apiPostFields lists the post fields transformAPIPost actually reads.
+const apiPostFields = "id,date,date_gmt,modified,modified_gmt,link,title,categories,tags,sticky"
+
+// applyAPIStrategy trims the posts request according to opt.APIStrategy.
+// WordPress drops _embedded unless _links is kept in _fields.
+func applyAPIStrategy(query url.Values, opt Options) {
+	fields := apiPostFields
+	embeds := "wp:term"
+	if opt.FetchMetadata {
+		fields += ",content"
+	}
+	if opt.FetchCovers {
+		fields += ",featured_media"
+		embeds += ",wp:featuredmedia"
+	}
+	switch opt.APIStrategy {
+	case APIStrategyFields:
+		query.Set("_fields", fields)
+	case APIStrategyEmbed:
+		query.Set("_fields", fields+",_links,_embedded")
+		query.Set("_embed", embeds)
+	}
+}
+
+// statusError reports a response with an HTTP error status.
+type statusError struct {
+	what   string
+	status string
+	code   int
+	body   string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s request failed: %s (%s)", e.what, e.status, e.body)
+}
+
+func isStatus(err error, code int) bool {
+	var se *statusError
+
+	return errors.As(err, &se) && se.code == code
+}
+
+// observeAPIPage feeds a page of posts to rule in order and reports
+// whether it tripped. Posts with unparseable dates are ignored here and
+// reported later by transformAPIPost.
+func observeAPIPage(rule *stopRule, posts []apiPost) bool {
+	stop := false
+	for _, ap := range posts {
+		if parsed, err := parseWPTime(ap.Date, ap.DateGMT); err == nil {
+			stop = rule.observe(parsed, ap.Sticky) || stop
 		}
 	}
 
-	allPosts.Sort()
+	return stop
+}
 
-	return allPosts, warnings, nil
+// searchQueries returns the list of queries to crawl. Without needles the
+// whole archive is crawled once under the empty query.
+func searchQueries(needles []string) []string {
+	if len(needles) == 0 {
+		return []string{""}
+	}
+
+	return needles
 }
 
+// fetchSelectedTaxonomy resolves taxonomy names in batches of 100 ids,
+// running up to opt.Concurrency batch requests at once.
 func fetchSelectedTaxonomy(ctx context.Context, opt Options, taxonomy string, ids []int) (map[int]string, error) {
-	if len(ids) == 0 {
-		return map[int]string{}, nil
-	}
-	endpoint, err := url.JoinPath(opt.BaseURL, "/wp-json/wp/v2/", taxonomy)
+	var mu sync.Mutex
+	result := make(map[int]string, len(ids))
+	err := forEachBatch(ctx, opt, ids, func(ctx context.Context, batch []int) error {
+		data, err := fetchTaxonomyChunk(ctx, opt, taxonomy, batch)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for id, name := range data {
+			result[id] = name
+		}
+
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	result := make(map[int]string, len(ids))
 
-	batches := chunkInts(ids, 100)
-	for _, batch := range batches {
+	return result, nil
+}
+
+// fetchMedia loads featured media items by id from /wp/v2/media, batched
+// with include= like taxonomy lookups.
+func fetchMedia(ctx context.Context, opt Options, ids []int) (map[int]apiMedia, error) {
+	endpoint, err := url.JoinPath(opt.BaseURL, "/wp-json/wp/v2/media")
+	if err != nil {
+		return nil, err
+	}
+	var mu sync.Mutex
+	result := make(map[int]apiMedia, len(ids))
+	err = forEachBatch(ctx, opt, ids, func(ctx context.Context, batch []int) error {
 		reqURL, err := url.Parse(endpoint)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		query := reqURL.Query()
 		query.Set("per_page", strconv.Itoa(len(batch)))
 		query.Set("include", joinInts(batch))
-		query.Set("_fields", "id,name")
+		query.Set("_fields", "id,source_url,media_details")
 		reqURL.RawQuery = query.Encode()
 
 		req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL.String(), nil)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		setStandardHeaders(req, opt.UserAgent)
 
 		resp, err := opt.Client.Do(ctx, req)
 		if err != nil {
-			return nil, err
+			return err
 		}
+		defer func() { _ = resp.Body.Close() }()
 		if resp.StatusCode >= 400 {
 			payload, _ := io.ReadAll(resp.Body)
-			_ = resp.Body.Close()
 
-			return nil, fmt.Errorf("%s request failed: %s (%s)", taxonomy, resp.Status, string(payload))
+			return &statusError{what: "media", status: resp.Status, code: resp.StatusCode, body: string(payload)}
 		}
 
-		var items []taxonomyItem
+		var items []apiMedia
 		if err := decodeJSON(resp.Body, &items); err != nil {
-			_ = resp.Body.Close()
-
-			return nil, err
+			return err
 		}
-		_ = resp.Body.Close()
-
+		mu.Lock()
+		defer mu.Unlock()
 		for _, item := range items {
-			result[item.ID] = item.Name
+			result[item.ID] = item
 		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return result, nil
 }
 
+// forEachBatch splits ids into batches of 100 and calls fn for each one
+// with up to opt.Concurrency calls in flight. The first error cancels the
+// remaining batches and is returned.
+func forEachBatch(ctx context.Context, opt Options, ids []int, fn func(ctx context.Context, batch []int) error) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+	sem := make(chan struct{}, max(opt.Concurrency, 1))
+
+	for _, batch := range chunkInts(ids, 100) {
+		wg.Add(1)
+		go func(batch []int) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+
+			if err := fn(ctx, batch); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				mu.Unlock()
+			}
+		}(batch)
+	}
+	wg.Wait()
+
+	return firstErr
+}
+
 func setStandardHeaders(req *http.Request, userAgent string) {
 	if userAgent == "" {
 		userAgent = "jnovels-scrape/1.0 (+https://example.com/contact)"
@@ -242,30 +905,98 @@ type taxonomyItem struct {
 }
 
 type apiPost struct {
-	ID         int64    `json:"id"`
-	Date       string   `json:"date"`
-	DateGMT    string   `json:"date_gmt"`
-	Link       string   `json:"link"`
-	Title      rendered `json:"title"`
-	Categories []int    `json:"categories"`
-	Tags       []int    `json:"tags"`
+	ID          int64        `json:"id"`
+	Date        string       `json:"date"`
+	DateGMT     string       `json:"date_gmt"`
+	Modified    string       `json:"modified"`
+	ModifiedGMT string       `json:"modified_gmt"`
+	Link        string       `json:"link"`
+	Title       rendered     `json:"title"`
+	Categories  []int        `json:"categories"`
+	Tags        []int        `json:"tags"`
+	Sticky      bool         `json:"sticky"`
+	Content     rendered     `json:"content"`
+	Media       int          `json:"featured_media"`
+	Embedded    *apiEmbedded `json:"_embedded,omitempty"`
+}
+
+// apiEmbedded holds the parts of _embedded we ask for. wp:term is a list
+// of term lists, one per taxonomy attached to the post.
+type apiEmbedded struct {
+	Terms [][]embeddedTerm `json:"wp:term"`
+	Media []apiMedia       `json:"wp:featuredmedia"`
+}
+
+// apiMedia is the subset of a /wp/v2/media item used for covers.
+type apiMedia struct {
+	ID        int    `json:"id"`
+	SourceURL string `json:"source_url"`
+	Details   struct {
+		Width  int `json:"width"`
+		Height int `json:"height"`
+	} `json:"media_details"`
+}
+
+// embeddedMedia returns the inline featured media item, if present.
+func (p apiPost) embeddedMedia() (apiMedia, bool) {
+	if p.Embedded == nil {
+		return apiMedia{}, false
+	}
+	for _, item := range p.Embedded.Media {
+		if item.SourceURL != "" {
+			return item, true
+		}
+	}
+
+	return apiMedia{}, false
+}
+
+type embeddedTerm struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Taxonomy string `json:"taxonomy"`
+}
+
+// collectEmbeddedTerms copies inline category and tag names into the
+// provided maps. Either map may be nil to skip that taxonomy.
+func (p apiPost) collectEmbeddedTerms(categories, tags map[int]string) {
+	if p.Embedded == nil {
+		return
+	}
+	for _, group := range p.Embedded.Terms {
+		for _, term := range group {
+			if term.Name == "" {
+				continue
+			}
+			switch {
+			case term.Taxonomy == "category" && categories != nil:
+				categories[term.ID] = term.Name
+			case term.Taxonomy == "post_tag" && tags != nil:
+				tags[term.ID] = term.Name
+			}
+		}
+	}
 }
 
 type rendered struct {
 	Text string `json:"rendered"`
 }
 
-func transformAPIPost(src apiPost, cutoff time.Time, categoryNames []string, tagNames []string) (*model.Post, string, bool) {
+func transformAPIPost(src apiPost, cutoff time.Time, categoryNames []string, tagNames []string) (*model.Post, *Warning, bool) {
 	postDate, err := parseWPTime(src.Date, src.DateGMT)
 	if err != nil {
-		return nil, fmt.Sprintf("%s failed to parse date: %v → skipped", src.Link, err), true
+		warn := warnf(WarningMissingDate, src.Link, "failed to parse date: %v → skipped", err)
+
+		return nil, &warn, true
 	}
 	if postDate.Before(cutoff) {
-		return &model.Post{Date: postDate}, "", true
+		return &model.Post{Date: postDate}, nil, true
 	}
 	rawTitle := util.CleanTitle(src.Title.Text)
 	if rawTitle == "" {
-		return nil, fmt.Sprintf("post id=%d missing title → skipped", src.ID), true
+		warn := warnf(WarningMissingTitle, src.Link, "post id=%d missing title → skipped", src.ID)
+
+		return nil, &warn, true
 	}
 	postType := util.InferType(rawTitle, categoryNames, tagNames)
 	title, volume, volumeExtra := util.ExtractTitleAndVolume(rawTitle)
@@ -274,7 +1005,9 @@ func transformAPIPost(src apiPost, cutoff time.Time, categoryNames []string, tag
 	}
 
 	if src.Link == "" {
-		return nil, fmt.Sprintf("post id=%d missing link → skipped", src.ID), true
+		warn := warnf(WarningMissingLink, "", "post id=%d missing link → skipped", src.ID)
+
+		return nil, &warn, true
 	}
 
 	post := model.Post{
@@ -283,11 +1016,16 @@ func transformAPIPost(src apiPost, cutoff time.Time, categoryNames []string, tag
 		VolumeExtra: volumeExtra,
 		Type:        postType,
 		Date:        postDate.UTC(),
+		DateSource:  model.DateSourceAPI,
 		Link:        src.Link,
 		SourceID:    src.ID,
 		Categories:  categoryNames,
 		Tags:        tagNames,
 	}
+	if modified, err := parseWPTime(src.Modified, src.ModifiedGMT); err == nil {
+		post.Modified = modified.UTC()
+	}
+	applyBookMeta(&post, src.Content.Text)
 
 	if post.Volume == nil {
 		if slugVol, slugExtra, ok := util.ExtractVolumeFromLink(post.Link); ok {
@@ -300,27 +1038,30 @@ func transformAPIPost(src apiPost, cutoff time.Time, categoryNames []string, tag
 	post.VolumeExtra = strings.TrimSpace(post.VolumeExtra)
 
 	if post.Volume == nil {
-		return &post, fmt.Sprintf("%s missing volume (no regex match) → kept with blank volume", post.Link), false
+		warn := warnf(WarningMissingVolume, post.Link, "missing volume (no regex match) → kept with blank volume")
+
+		return &post, &warn, false
 	}
 	if post.Type == model.TypeUnknown {
-		return &post, fmt.Sprintf("%s type unresolved → UNKNOWN", post.Link), false
+		warn := warnf(WarningUnknownType, post.Link, "type unresolved → UNKNOWN")
+
+		return &post, &warn, false
 	}
 
-	return &post, "", false
+	return &post, nil, false
 }
 
-func lookupNames(table map[int]string, ids []int) []string {
-	if len(ids) == 0 || len(table) == 0 {
-		return nil
-	}
-	out := make([]string, 0, len(ids))
-	for _, id := range ids {
-		if name, ok := table[id]; ok {
-			out = append(out, name)
-		}
+// applyBookMeta copies metadata parsed from a rendered post body.
+func applyBookMeta(post *model.Post, content string) {
+	if content == "" {
+		return
 	}
-
-	return out
+	meta := util.ExtractBookMeta(content)
+	post.Author = meta.Author
+	post.Illustrator = meta.Illustrator
+	post.Publisher = meta.Publisher
+	post.AltTitles = meta.AltTitles
+	post.Summary = meta.Summary
 }
 
 func parseWPTime(primary, fallback string) (time.Time, error) {
@@ -329,6 +1070,7 @@ func parseWPTime(primary, fallback string) (time.Time, error) {
 		time.RFC3339,
 		"2006-01-02T15:04:05",
 		"2006-01-02 15:04:05",
+		"2006-01-02",
 	}
 	for _, candidate := range []string{primary, fallback} {
 		if candidate == "" {
@@ -357,6 +1099,18 @@ func sortedKeys(set map[int]struct{}) []int {
 	return out
 }
 
+// missingKeys returns the sorted ids from set that have no entry in known.
+func missingKeys(set map[int]struct{}, known map[int]string) []int {
+	missing := make(map[int]struct{}, len(set))
+	for id := range set {
+		if _, ok := known[id]; !ok {
+			missing[id] = struct{}{}
+		}
+	}
+
+	return sortedKeys(missing)
+}
+
 func chunkInts(ids []int, size int) [][]int {
 	if size <= 0 {
 		size = 100
@@ -387,6 +1141,8 @@ func joinInts(ids []int) string {
 	return strings.Join(parts, ",")
 }
 
+// taxonomyResolver caches taxonomy names by id so that each term is
+// requested at most once per run.
 type taxonomyResolver struct {
 	taxonomy string
 	opt      Options
@@ -405,6 +1161,19 @@ func newTaxonomyResolver(taxonomy string, opt Options) *taxonomyResolver {
 	}
 }
 
+// Seed stores names obtained elsewhere (e.g. embedded in posts) so they
+// are never requested.
+func (r *taxonomyResolver) Seed(names map[int]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, name := range names {
+		r.cache[id] = name
+	}
+}
+
+// Resolve returns names for ids, fetching unknown ids in concurrent
+// batches first. Ids the server does not know are cached as empty and
+// omitted from the result.
 func (r *taxonomyResolver) Resolve(ctx context.Context, ids []int) ([]string, error) {
 	if len(ids) == 0 {
 		return nil, nil
@@ -421,26 +1190,26 @@ func (r *taxonomyResolver) Resolve(ctx context.Context, ids []int) ([]string, er
 	r.mu.Unlock()
 
 	if len(missing) > 0 {
-		for _, chunk := range chunkInts(missing, 100) {
-			data, err := fetchTaxonomyChunk(ctx, r.opt, r.taxonomy, chunk)
-			if err != nil {
-				return nil, err
-			}
-			r.mu.Lock()
-			for id, name := range data {
-				r.cache[id] = name
-				r.fetched[id] = struct{}{}
-			}
-			for _, id := range chunk {
-				if _, ok := data[id]; !ok {
-					r.cache[id] = ""
-					r.fetched[id] = struct{}{}
-				}
-			}
-			r.mu.Unlock()
+		data, err := fetchSelectedTaxonomy(ctx, r.opt, r.taxonomy, missing)
+		if err != nil {
+			return nil, err
+		}
+		r.mu.Lock()
+		for _, id := range missing {
+			r.cache[id] = data[id]
+			r.fetched[id] = struct{}{}
 		}
+		r.mu.Unlock()
 	}
 
+	return r.Lookup(ids), nil
+}
+
+// Lookup returns cached names for ids without issuing requests.
+func (r *taxonomyResolver) Lookup(ids []int) []string {
+	if len(ids) == 0 {
+		return nil
+	}
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	result := make([]string, 0, len(ids))
@@ -449,8 +1218,20 @@ func (r *taxonomyResolver) Resolve(ctx context.Context, ids []int) ([]string, er
 			result = append(result, name)
 		}
 	}
+	if len(result) == 0 {
+		return nil
+	}
 
-	return result, nil
+	return result
+}
+
+// names returns a copy of every cached name, including ids the server
+// did not know.
+func (r *taxonomyResolver) names() map[int]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return maps.Clone(r.cache)
 }
 
 func (r *taxonomyResolver) ResolvedCount() int {
@@ -496,7 +1277,7 @@ func fetchTaxonomyChunk(ctx context.Context, opt Options, taxonomy string, ids [
 		payload, _ := io.ReadAll(resp.Body)
 		_ = resp.Body.Close()
 
-		return nil, fmt.Errorf("%s request failed: %s (%s)", taxonomy, resp.Status, string(payload))
+		return nil, &statusError{what: taxonomy, status: resp.Status, code: resp.StatusCode, body: string(payload)}
 	}
 
 	var items []taxonomyItem
@@ -7,8 +7,14 @@ package collect
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -26,6 +32,7 @@ func TestParseWPTime(t *testing.T) {
 		{"RFC3339", "2024-05-01T10:11:12Z", ""},
 		{"RFC3339NoZone", "2024-05-01T10:11:12", ""},
 		{"Fallback", "", "2024-05-01T10:11:12Z"},
+		{"DateOnly", "2024-05-01", ""},
 	}
 
 	for _, tc := range cases {
@@ -48,6 +55,7 @@ func TestParseWPTime(t *testing.T) {
 func TestFetchAPISuccess(t *testing.T) {
 	var postRequests int32
 	var categoryRequests int32
+	var tagRequests int32
 
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
@@ -70,6 +78,7 @@ func TestFetchAPISuccess(t *testing.T) {
 					Link:       "https://example.com/hero-volume-2-epub/",
 					Title:      rendered{Text: "Hero Volume 2 EPUB"},
 					Categories: []int{11},
+					Tags:       []int{31, 32},
 				},
 				{
 					ID:         102,
@@ -88,6 +97,13 @@ func TestFetchAPISuccess(t *testing.T) {
 				{ID: 12, Name: "Downloads"},
 			}
 			json.NewEncoder(w).Encode(items)
+		case "/wp-json/wp/v2/tags":
+			atomic.AddInt32(&tagRequests, 1)
+			items := []taxonomyItem{
+				{ID: 31, Name: "Isekai"},
+				{ID: 32, Name: "Fantasy"},
+			}
+			json.NewEncoder(w).Encode(items)
 		default:
 			w.WriteHeader(http.StatusNotFound)
 		}
@@ -130,6 +146,12 @@ func TestFetchAPISuccess(t *testing.T) {
 	if atomic.LoadInt32(&categoryRequests) != 1 {
 		t.Fatalf("expected single taxonomy request, got %d", categoryRequests)
 	}
+	if len(posts[0].Tags) != 2 || posts[0].Tags[0] != "Isekai" || posts[0].Tags[1] != "Fantasy" {
+		t.Fatalf("unexpected tags: %+v", posts[0].Tags)
+	}
+	if atomic.LoadInt32(&tagRequests) != 1 {
+		t.Fatalf("expected single tag request, got %d", tagRequests)
+	}
 }
 
 func TestTaxonomyResolverCaching(t *testing.T) {
@@ -175,3 +197,586 @@ func TestTaxonomyResolverCaching(t *testing.T) {
 		t.Fatalf("expected cached responses, got %d requests", requests)
 	}
 }
+
+func TestTaxonomyResolverStatusError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"code":"rest_invalid_param"}`, http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	client := httpx.NewClient(1*time.Millisecond, 5*time.Millisecond,
+		httpx.WithHTTPClient(server.Client()),
+		httpx.WithJitterFactor(0),
+	)
+
+	resolver := newTaxonomyResolver("tags", Options{BaseURL: server.URL, Client: client})
+	_, err := resolver.Resolve(context.Background(), []int{1})
+	if !isStatus(err, http.StatusBadRequest) || retryable(err) {
+		t.Fatalf("expected a non-retryable 400 status error, got %v", err)
+	}
+	if !strings.HasPrefix(err.Error(), "tags request failed: 400") {
+		t.Fatalf("unexpected error message: %v", err)
+	}
+}
+
+func TestFetchAPISearchMergesNeedles(t *testing.T) {
+	var searches []string
+	var mu sync.Mutex
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/wp-json/wp/v2/posts" {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+		search := r.URL.Query().Get("search")
+		mu.Lock()
+		searches = append(searches, search)
+		mu.Unlock()
+		w.Header().Set("X-WP-TotalPages", "1")
+		shared := apiPost{
+			ID:      201,
+			Date:    "2025-10-15T00:00:00",
+			DateGMT: "2025-10-15T00:00:00",
+			Link:    "https://example.com/dragon-spice-volume-1-epub/",
+			Title:   rendered{Text: "Dragon and Spice Volume 1 EPUB"},
+		}
+		switch search {
+		case "dragon":
+			json.NewEncoder(w).Encode([]apiPost{shared})
+		case "spice":
+			json.NewEncoder(w).Encode([]apiPost{shared, {
+				ID:      202,
+				Date:    "2025-10-12T00:00:00",
+				DateGMT: "2025-10-12T00:00:00",
+				Link:    "https://example.com/spice-volume-4-pdf/",
+				Title:   rendered{Text: "Spice Volume 4 PDF"},
+			}})
+		default:
+			json.NewEncoder(w).Encode([]apiPost{})
+		}
+	}))
+	defer server.Close()
+
+	client := httpx.NewClient(1*time.Millisecond, 5*time.Millisecond,
+		httpx.WithHTTPClient(server.Client()),
+		httpx.WithJitterFactor(0),
+	)
+
+	cutoff := time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC)
+	opt := Options{BaseURL: server.URL, Client: client, Search: []string{"dragon", "spice"}}
+
+	posts, _, err := FetchAPI(context.Background(), cutoff, opt)
+	if err != nil {
+		t.Fatalf("FetchAPI() error: %v", err)
+	}
+	if len(posts) != 2 {
+		t.Fatalf("expected 2 merged posts, got %d: %+v", len(posts), posts)
+	}
+	if len(searches) != 2 || searches[0] != "dragon" || searches[1] != "spice" {
+		t.Fatalf("unexpected search queries: %v", searches)
+	}
+}
+
+func TestFetchAPIConcurrentPagesStopAtCutoff(t *testing.T) {
+	var inFlight, maxInFlight int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/wp-json/wp/v2/posts" {
+			json.NewEncoder(w).Encode([]taxonomyItem{})
+
+			return
+		}
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			seen := atomic.LoadInt32(&maxInFlight)
+			if current <= seen || atomic.CompareAndSwapInt32(&maxInFlight, seen, current) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		w.Header().Set("X-WP-TotalPages", "8")
+		// Pages 1-3 are newer than the cutoff, page 4 crosses it.
+		day := 20 - page*3
+		posts := []apiPost{{
+			ID:      int64(page),
+			Date:    fmt.Sprintf("2025-10-%02dT00:00:00", day),
+			DateGMT: fmt.Sprintf("2025-10-%02dT00:00:00", day),
+			Link:    fmt.Sprintf("https://example.com/series-volume-%d-epub/", page),
+			Title:   rendered{Text: fmt.Sprintf("Series Volume %d EPUB", page)},
+		}}
+		json.NewEncoder(w).Encode(posts)
+	}))
+	defer server.Close()
+
+	client := httpx.NewClient(1*time.Millisecond, 5*time.Millisecond,
+		httpx.WithHTTPClient(server.Client()),
+		httpx.WithJitterFactor(0),
+	)
+
+	cutoff := time.Date(2025, time.October, 10, 0, 0, 0, 0, time.UTC)
+	opt := Options{BaseURL: server.URL, Client: client, Concurrency: 3}
+
+	posts, _, err := FetchAPI(context.Background(), cutoff, opt)
+	if err != nil {
+		t.Fatalf("FetchAPI() error: %v", err)
+	}
+	if len(posts) != 3 {
+		t.Fatalf("expected posts from pages 1-3 only, got %d: %+v", len(posts), posts)
+	}
+	for i, post := range posts {
+		if post.SourceID != int64(i+1) {
+			t.Fatalf("post %d: expected id %d, got %d", i, i+1, post.SourceID)
+		}
+	}
+	if atomic.LoadInt32(&maxInFlight) < 2 {
+		t.Fatalf("expected concurrent page requests, max in flight = %d", maxInFlight)
+	}
+}
+
+func TestFetchAPIStopRuleWhenServerIgnoresAfter(t *testing.T) {
+	var mu sync.Mutex
+	var requested []int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/wp-json/wp/v2/posts" {
+			json.NewEncoder(w).Encode([]taxonomyItem{})
+
+			return
+		}
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		mu.Lock()
+		requested = append(requested, page)
+		mu.Unlock()
+		w.Header().Set("X-WP-TotalPages", "10")
+		// The after parameter is ignored: pages 1-3 are newer than the
+		// cutoff, every later page is older.
+		day := 20 - page*3
+		if page > 3 {
+			day = 1
+		}
+		posts := []apiPost{{
+			ID:      int64(page),
+			Date:    fmt.Sprintf("2025-10-%02dT00:00:00", day),
+			DateGMT: fmt.Sprintf("2025-10-%02dT00:00:00", day),
+			Link:    fmt.Sprintf("https://example.com/series-volume-%d-epub/", page),
+			Title:   rendered{Text: fmt.Sprintf("Series Volume %d EPUB", page)},
+		}}
+		if page == 1 {
+			// Old sticky posts pinned to the front must not stop the
+			// crawl.
+			sticky := []apiPost{
+				{ID: 91, Date: "2024-01-01T00:00:00", DateGMT: "2024-01-01T00:00:00", Link: "https://example.com/pinned-1/", Title: rendered{Text: "Pinned 1"}, Sticky: true},
+				{ID: 92, Date: "2024-01-01T00:00:00", DateGMT: "2024-01-01T00:00:00", Link: "https://example.com/pinned-2/", Title: rendered{Text: "Pinned 2"}, Sticky: true},
+			}
+			posts = append(sticky, posts...)
+		}
+		json.NewEncoder(w).Encode(posts)
+	}))
+	defer server.Close()
+
+	client := httpx.NewClient(1*time.Millisecond, 5*time.Millisecond,
+		httpx.WithHTTPClient(server.Client()),
+		httpx.WithJitterFactor(0),
+	)
+
+	cutoff := time.Date(2025, time.October, 10, 0, 0, 0, 0, time.UTC)
+	opt := Options{BaseURL: server.URL, Client: client, Concurrency: 1, StopAfter: 2}
+
+	posts, _, err := FetchAPI(context.Background(), cutoff, opt)
+	if err != nil {
+		t.Fatalf("FetchAPI() error: %v", err)
+	}
+	if len(posts) != 3 {
+		t.Fatalf("expected the posts of pages 1-3, got %d: %+v", len(posts), posts)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	// The one-post pages 4 and 5 trip the rule together; the worker
+	// and the scheduler may each hold one more page by then.
+	if !slices.Contains(requested, 5) || slices.Max(requested) > 7 {
+		t.Fatalf("expected paging to stop after page 5, requested %v", requested)
+	}
+}
+
+func TestFetchAPIEmbedStrategy(t *testing.T) {
+	var categoryRequests int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/wp-json/wp/v2/posts":
+			query := r.URL.Query()
+			if query.Get("_embed") != "wp:term" || !strings.Contains(query.Get("_fields"), "_embedded") {
+				t.Errorf("unexpected query: %s", r.URL.RawQuery)
+			}
+			w.Header().Set("X-WP-TotalPages", "1")
+			posts := []apiPost{
+				{
+					ID:         301,
+					Date:       "2025-10-15T00:00:00",
+					DateGMT:    "2025-10-15T00:00:00",
+					Link:       "https://example.com/embedded-volume-1/",
+					Title:      rendered{Text: "Embedded Volume 1"},
+					Categories: []int{21},
+					Embedded: &apiEmbedded{Terms: [][]embeddedTerm{
+						{{ID: 21, Name: "EPUB", Taxonomy: "category"}},
+					}},
+				},
+				{
+					// Stripped embed: falls back to a taxonomy lookup.
+					ID:         302,
+					Date:       "2025-10-14T00:00:00",
+					DateGMT:    "2025-10-14T00:00:00",
+					Link:       "https://example.com/stripped-volume-2/",
+					Title:      rendered{Text: "Stripped Volume 2"},
+					Categories: []int{22},
+				},
+			}
+			json.NewEncoder(w).Encode(posts)
+		case "/wp-json/wp/v2/categories":
+			atomic.AddInt32(&categoryRequests, 1)
+			if got := r.URL.Query().Get("include"); got != "22" {
+				t.Errorf("expected lookup of category 22 only, got %q", got)
+			}
+			json.NewEncoder(w).Encode([]taxonomyItem{{ID: 22, Name: "PDF"}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := httpx.NewClient(1*time.Millisecond, 5*time.Millisecond,
+		httpx.WithHTTPClient(server.Client()),
+		httpx.WithJitterFactor(0),
+	)
+
+	cutoff := time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC)
+	opt := Options{BaseURL: server.URL, Client: client, APIStrategy: APIStrategyEmbed}
+
+	posts, _, err := FetchAPI(context.Background(), cutoff, opt)
+	if err != nil {
+		t.Fatalf("FetchAPI() error: %v", err)
+	}
+	if len(posts) != 2 {
+		t.Fatalf("expected 2 posts, got %d", len(posts))
+	}
+	if posts[0].Type != model.TypeEPUB || posts[1].Type != model.TypePDF {
+		t.Fatalf("unexpected types: %s, %s", posts[0].Type, posts[1].Type)
+	}
+	if atomic.LoadInt32(&categoryRequests) != 1 {
+		t.Fatalf("expected one fallback taxonomy request, got %d", categoryRequests)
+	}
+}
+
+func TestFetchAPIFallsBackToFullOnBadRequest(t *testing.T) {
+	var trimmed, full int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("_fields") != "" {
+			atomic.AddInt32(&trimmed, 1)
+			http.Error(w, `{"code":"rest_invalid_param"}`, http.StatusBadRequest)
+
+			return
+		}
+		atomic.AddInt32(&full, 1)
+		w.Header().Set("X-WP-TotalPages", "1")
+		json.NewEncoder(w).Encode([]apiPost{{
+			ID:      401,
+			Date:    "2025-10-15T00:00:00",
+			DateGMT: "2025-10-15T00:00:00",
+			Link:    "https://example.com/plain-volume-1-epub/",
+			Title:   rendered{Text: "Plain Volume 1 EPUB"},
+		}})
+	}))
+	defer server.Close()
+
+	client := httpx.NewClient(1*time.Millisecond, 5*time.Millisecond,
+		httpx.WithHTTPClient(server.Client()),
+		httpx.WithJitterFactor(0),
+	)
+
+	cutoff := time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC)
+	opt := Options{BaseURL: server.URL, Client: client, APIStrategy: APIStrategyFields}
+
+	posts, _, err := FetchAPI(context.Background(), cutoff, opt)
+	if err != nil {
+		t.Fatalf("FetchAPI() error: %v", err)
+	}
+	if len(posts) != 1 {
+		t.Fatalf("expected 1 post, got %d", len(posts))
+	}
+	if atomic.LoadInt32(&trimmed) != 1 || atomic.LoadInt32(&full) != 1 {
+		t.Fatalf("expected one trimmed and one full request, got %d/%d", trimmed, full)
+	}
+}
+
+func TestFetchAPICovers(t *testing.T) {
+	var mediaRequests int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/wp-json/wp/v2/posts":
+			if !strings.Contains(r.URL.Query().Get("_fields"), "featured_media") {
+				t.Errorf("featured_media not requested: %s", r.URL.RawQuery)
+			}
+			w.Header().Set("X-WP-TotalPages", "1")
+			posts := []apiPost{
+				{
+					ID:      501,
+					Date:    "2025-10-15T00:00:00",
+					DateGMT: "2025-10-15T00:00:00",
+					Link:    "https://example.com/inline-volume-1-epub/",
+					Title:   rendered{Text: "Inline Volume 1 EPUB"},
+					Media:   71,
+					Embedded: &apiEmbedded{Media: []apiMedia{
+						{ID: 71, SourceURL: "https://example.com/inline.jpg"},
+					}},
+				},
+				{
+					ID:      502,
+					Date:    "2025-10-14T00:00:00",
+					DateGMT: "2025-10-14T00:00:00",
+					Link:    "https://example.com/lookup-volume-2-epub/",
+					Title:   rendered{Text: "Lookup Volume 2 EPUB"},
+					Media:   72,
+				},
+			}
+			json.NewEncoder(w).Encode(posts)
+		case "/wp-json/wp/v2/media":
+			atomic.AddInt32(&mediaRequests, 1)
+			if got := r.URL.Query().Get("include"); got != "72" {
+				t.Errorf("expected lookup of media 72 only, got %q", got)
+			}
+			item := apiMedia{ID: 72, SourceURL: "https://example.com/lookup.jpg"}
+			item.Details.Width = 600
+			item.Details.Height = 900
+			json.NewEncoder(w).Encode([]apiMedia{item})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := httpx.NewClient(1*time.Millisecond, 5*time.Millisecond,
+		httpx.WithHTTPClient(server.Client()),
+		httpx.WithJitterFactor(0),
+	)
+
+	cutoff := time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC)
+	opt := Options{BaseURL: server.URL, Client: client, APIStrategy: APIStrategyEmbed, FetchCovers: true}
+
+	posts, _, err := FetchAPI(context.Background(), cutoff, opt)
+	if err != nil {
+		t.Fatalf("FetchAPI() error: %v", err)
+	}
+	if len(posts) != 2 {
+		t.Fatalf("expected 2 posts, got %d", len(posts))
+	}
+	if posts[0].CoverURL != "https://example.com/inline.jpg" {
+		t.Fatalf("unexpected embedded cover: %q", posts[0].CoverURL)
+	}
+	if posts[1].CoverURL != "https://example.com/lookup.jpg" || posts[1].CoverWidth != 600 || posts[1].CoverHeight != 900 {
+		t.Fatalf("unexpected fetched cover: %+v", posts[1])
+	}
+	if atomic.LoadInt32(&mediaRequests) != 1 {
+		t.Fatalf("expected one media request, got %d", mediaRequests)
+	}
+}
+
+func TestFetchAPIMetadata(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/wp-json/wp/v2/posts" {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+		if !strings.Contains(r.URL.Query().Get("_fields"), "content") {
+			t.Errorf("content not requested: %s", r.URL.RawQuery)
+		}
+		w.Header().Set("X-WP-TotalPages", "1")
+		posts := []apiPost{{
+			ID:      601,
+			Date:    "2025-10-15T00:00:00",
+			DateGMT: "2025-10-15T00:00:00",
+			Link:    "https://example.com/meta-volume-1-epub/",
+			Title:   rendered{Text: "Meta Volume 1 EPUB"},
+			Content: rendered{Text: "<p>Author: Jane Doe</p><p>Illustrator: John Roe</p>"},
+		}}
+		json.NewEncoder(w).Encode(posts)
+	}))
+	defer server.Close()
+
+	client := httpx.NewClient(1*time.Millisecond, 5*time.Millisecond,
+		httpx.WithHTTPClient(server.Client()),
+		httpx.WithJitterFactor(0),
+	)
+
+	cutoff := time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC)
+	opt := Options{BaseURL: server.URL, Client: client, APIStrategy: APIStrategyFields, FetchMetadata: true}
+
+	posts, _, err := FetchAPI(context.Background(), cutoff, opt)
+	if err != nil {
+		t.Fatalf("FetchAPI() error: %v", err)
+	}
+	if len(posts) != 1 {
+		t.Fatalf("expected 1 post, got %d", len(posts))
+	}
+	if posts[0].Author != "Jane Doe" || posts[0].Illustrator != "John Roe" {
+		t.Fatalf("unexpected metadata: %+v", posts[0])
+	}
+}
+
+func TestFetchAPIReturnsPartialResultsOnLaterPageFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/wp-json/wp/v2/posts" {
+			json.NewEncoder(w).Encode([]taxonomyItem{})
+
+			return
+		}
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 3 {
+			w.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+		w.Header().Set("X-WP-TotalPages", "4")
+		day := 22 - page*2
+		json.NewEncoder(w).Encode([]apiPost{{
+			ID:      int64(page),
+			Date:    fmt.Sprintf("2025-10-%02dT00:00:00", day),
+			DateGMT: fmt.Sprintf("2025-10-%02dT00:00:00", day),
+			Link:    fmt.Sprintf("https://example.com/series-volume-%d-epub/", page),
+			Title:   rendered{Text: fmt.Sprintf("Series Volume %d EPUB", page)},
+		}})
+	}))
+	defer server.Close()
+
+	client := httpx.NewClient(1*time.Millisecond, 5*time.Millisecond,
+		httpx.WithHTTPClient(server.Client()),
+		httpx.WithJitterFactor(0),
+	)
+
+	cutoff := time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC)
+	failures := &Failures{}
+	opt := Options{BaseURL: server.URL, Client: client, Concurrency: 1, Failures: failures}
+
+	posts, _, err := FetchAPI(context.Background(), cutoff, opt)
+	var partial *PartialError
+	if !errors.As(err, &partial) {
+		t.Fatalf("expected PartialError, got %v", err)
+	}
+	if len(posts) != 3 {
+		t.Fatalf("expected posts from pages 1, 2 and 4, got %d", len(posts))
+	}
+	// Page 4 is past the gap, so the range is only complete down to page 2.
+	if got := partial.Reached.Format("2006-01-02"); got != "2025-10-18" {
+		t.Fatalf("unexpected resume point %s", got)
+	}
+	items := failures.Items()
+	if len(items) != 1 || items[0].Kind != FailureAPIPage || !strings.Contains(items[0].URL, "page=3") {
+		t.Fatalf("unexpected failures: %+v", items)
+	}
+}
+
+func TestFetchAPIEndsOnBadRequestWithoutTotalPages(t *testing.T) {
+	var requested atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/wp-json/wp/v2/posts" {
+			json.NewEncoder(w).Encode([]taxonomyItem{})
+
+			return
+		}
+		requested.Add(1)
+		// No X-WP-TotalPages: the listing ends with WordPress's answer
+		// to a page number past the last page.
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page > 2 {
+			http.Error(w, `{"code":"rest_post_invalid_page_number"}`, http.StatusBadRequest)
+
+			return
+		}
+		day := 22 - page*2
+		json.NewEncoder(w).Encode([]apiPost{{
+			ID:      int64(page),
+			Date:    fmt.Sprintf("2025-10-%02dT00:00:00", day),
+			DateGMT: fmt.Sprintf("2025-10-%02dT00:00:00", day),
+			Link:    fmt.Sprintf("https://example.com/series-volume-%d-epub/", page),
+			Title:   rendered{Text: fmt.Sprintf("Series Volume %d EPUB", page)},
+		}})
+	}))
+	defer server.Close()
+
+	client := httpx.NewClient(1*time.Millisecond, 5*time.Millisecond,
+		httpx.WithHTTPClient(server.Client()),
+		httpx.WithJitterFactor(0),
+	)
+
+	cutoff := time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC)
+	failures := &Failures{}
+	opt := Options{BaseURL: server.URL, Client: client, Concurrency: 2, MaxPages: 50, Failures: failures}
+
+	posts, _, err := FetchAPI(context.Background(), cutoff, opt)
+	if err != nil {
+		t.Fatalf("FetchAPI() error: %v", err)
+	}
+	if len(posts) != 2 {
+		t.Fatalf("expected the posts of pages 1 and 2, got %d", len(posts))
+	}
+	if items := failures.Items(); len(items) != 0 {
+		t.Fatalf("the end of the listing is not a failure: %+v", items)
+	}
+	// The workers may have a couple of pages in flight when the end is
+	// seen, but scheduling stops there.
+	if got := requested.Load(); got > 6 {
+		t.Fatalf("expected paging to stop after the end, got %d requests", got)
+	}
+}
+
+func TestFetchAPIRetriesFailedPageInSecondPass(t *testing.T) {
+	var page3Calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 3 && page3Calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+		w.Header().Set("X-WP-TotalPages", "3")
+		day := 22 - page*2
+		json.NewEncoder(w).Encode([]apiPost{{
+			ID:      int64(page),
+			Date:    fmt.Sprintf("2025-10-%02dT00:00:00", day),
+			DateGMT: fmt.Sprintf("2025-10-%02dT00:00:00", day),
+			Link:    fmt.Sprintf("https://example.com/series-volume-%d-epub/", page),
+			Title:   rendered{Text: fmt.Sprintf("Series Volume %d EPUB", page)},
+		}})
+	}))
+	defer server.Close()
+
+	client := httpx.NewClient(1*time.Millisecond, 5*time.Millisecond,
+		httpx.WithHTTPClient(server.Client()),
+		httpx.WithJitterFactor(0),
+	)
+
+	cutoff := time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC)
+	failures := &Failures{}
+	opt := Options{BaseURL: server.URL, Client: client, Concurrency: 2, Failures: failures, RetryBackoff: time.Millisecond}
+
+	posts, _, err := FetchAPI(context.Background(), cutoff, opt)
+	if err != nil {
+		t.Fatalf("FetchAPI() error: %v", err)
+	}
+	if len(posts) != 3 {
+		t.Fatalf("expected 3 posts, got %d", len(posts))
+	}
+	if page3Calls.Load() != 2 {
+		t.Fatalf("expected page 3 to be requested twice, got %d", page3Calls.Load())
+	}
+	if items := failures.Items(); len(items) != 0 {
+		t.Fatalf("unexpected failures: %+v", items)
+	}
+}
@@ -0,0 +1,181 @@
+package collect
+
+import (
+	"maps"
+	"sync"
+	"time"
+
+	"git.skobk.in/skobkin/jnovel-scrape/internal/model"
+)
+
+// Checkpoint is the saved progress of a crawl over the full archive
+// listing, so a run that was interrupted can skip the pages it already
+// finished. Search crawls and the HTML resume of a failed API crawl are
+// not checkpointed.
+type Checkpoint struct {
+	// Key identifies the run parameters the progress belongs to. It is
+	// set and compared by the caller; collectors ignore it.
+	Key       string          `json:"key"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	API       *APICheckpoint  `json:"api,omitempty"`
+	HTML      *HTMLCheckpoint `json:"html,omitempty"`
+}
+
+// APICheckpoint records that pages 1 through Page of the REST API posts
+// listing are done.
+type APICheckpoint struct {
+	Page       int `json:"page"`
+	TotalPages int `json:"total_pages"`
+	// Posts holds the raw posts of the finished pages in listing order.
+	Posts []apiPost `json:"posts"`
+	// Categories and Tags are the taxonomy names resolved so far.
+	Categories map[int]string `json:"categories,omitempty"`
+	Tags       map[int]string `json:"tags,omitempty"`
+}
+
+// HTMLCheckpoint records that archive pages 1 through Page are done.
+type HTMLCheckpoint struct {
+	Page    int    `json:"page"`
+	NextURL string `json:"next_url"`
+	// Streak is the stop rule's run of older posts at the end of Page.
+	Streak int         `json:"streak"`
+	Posts  model.Posts `json:"posts"`
+	// Pending lists detail pages queued for the second pass.
+	Pending []FailedFetch `json:"pending,omitempty"`
+}
+
+// Checkpointer keeps the progress of a crawl and hands it to a save
+// function, at most once per interval. A nil *Checkpointer records
+// nothing.
+type Checkpointer struct {
+	mu       sync.Mutex
+	current  Checkpoint
+	interval time.Duration
+	last     time.Time
+	dirty    bool
+	save     func(Checkpoint)
+}
+
+// NewCheckpointer starts from the progress in start, which may be empty,
+// and calls save with updated progress at most once per interval.
+func NewCheckpointer(start Checkpoint, interval time.Duration, save func(Checkpoint)) *Checkpointer {
+	return &Checkpointer{current: start, interval: interval, last: time.Now(), save: save}
+}
+
+// Flush saves the latest progress regardless of the interval, if there
+// is anything new since the last save.
+func (c *Checkpointer) Flush() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.dirty {
+		c.saveLocked()
+	}
+}
+
+// apiProgress returns a copy of the saved API progress, or nil.
+func (c *Checkpointer) apiProgress() *APICheckpoint {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.current.API == nil {
+		return nil
+	}
+	cp := *c.current.API
+	cp.Posts = append([]apiPost(nil), cp.Posts...)
+
+	return &cp
+}
+
+// htmlProgress returns a copy of the saved HTML progress, or nil.
+func (c *Checkpointer) htmlProgress() *HTMLCheckpoint {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.current.HTML == nil {
+		return nil
+	}
+	cp := *c.current.HTML
+	cp.Posts = append(model.Posts(nil), cp.Posts...)
+
+	return &cp
+}
+
+func (c *Checkpointer) updateAPI(fn func(*APICheckpoint)) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.current.API == nil {
+		c.current.API = &APICheckpoint{}
+	}
+	fn(c.current.API)
+	c.touchLocked()
+}
+
+func (c *Checkpointer) updateHTML(fn func(*HTMLCheckpoint)) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.current.HTML == nil {
+		c.current.HTML = &HTMLCheckpoint{}
+	}
+	fn(c.current.HTML)
+	c.touchLocked()
+}
+
+func (c *Checkpointer) touchLocked() {
+	c.dirty = true
+	if time.Since(c.last) >= c.interval {
+		c.saveLocked()
+	}
+}
+
+func (c *Checkpointer) saveLocked() {
+	c.current.UpdatedAt = time.Now().UTC()
+	c.last = time.Now()
+	c.dirty = false
+	if c.save != nil {
+		c.save(c.current)
+	}
+}
+
+// recordAPIPage appends a finished listing page to the API progress.
+// Pages must be recorded in order.
+func (c *Checkpointer) recordAPIPage(page, totalPages int, posts []apiPost) {
+	c.updateAPI(func(cp *APICheckpoint) {
+		if page != cp.Page+1 {
+			return
+		}
+		cp.Page = page
+		cp.TotalPages = totalPages
+		cp.Posts = append(cp.Posts, posts...)
+	})
+}
+
+// recordTaxonomy stores the names known to the resolvers.
+func (c *Checkpointer) recordTaxonomy(categories, tags *taxonomyResolver) {
+	c.updateAPI(func(cp *APICheckpoint) {
+		cp.Categories = categories.names()
+		cp.Tags = tags.names()
+	})
+}
+
+// taxonomy returns the saved names for seeding taxonomy resolvers.
+func (c *Checkpointer) taxonomy() (map[int]string, map[int]string) {
+	cp := c.apiProgress()
+	if cp == nil {
+		return nil, nil
+	}
+
+	return maps.Clone(cp.Categories), maps.Clone(cp.Tags)
+}
@@ -0,0 +1,145 @@
+//nolint:gosec
+package collect
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"git.skobk.in/skobkin/jnovel-scrape/internal/httpx"
+	"git.skobk.in/skobkin/jnovel-scrape/internal/model"
+)
+
+func TestFetchAPIResumesFromCheckpoint(t *testing.T) {
+	listed := func(page int) apiPost {
+		day := 22 - page*2
+
+		return apiPost{
+			ID:         int64(page),
+			Date:       fmt.Sprintf("2025-10-%02dT00:00:00", day),
+			DateGMT:    fmt.Sprintf("2025-10-%02dT00:00:00", day),
+			Link:       fmt.Sprintf("https://example.com/series-volume-%d/", page),
+			Title:      rendered{Text: fmt.Sprintf("Series Volume %d", page)},
+			Categories: []int{5},
+		}
+	}
+	var (
+		mu        sync.Mutex
+		requested []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested = append(requested, r.URL.Path+"?page="+r.URL.Query().Get("page"))
+		mu.Unlock()
+		if r.URL.Path != "/wp-json/wp/v2/posts" {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		w.Header().Set("X-WP-TotalPages", "3")
+		json.NewEncoder(w).Encode([]apiPost{listed(page)})
+	}))
+	defer server.Close()
+
+	client := httpx.NewClient(1*time.Millisecond, 5*time.Millisecond,
+		httpx.WithHTTPClient(server.Client()),
+		httpx.WithJitterFactor(0),
+	)
+
+	start := Checkpoint{API: &APICheckpoint{
+		Page:       2,
+		TotalPages: 3,
+		Posts:      []apiPost{listed(1), listed(2)},
+		Categories: map[int]string{5: "EPUB"},
+	}}
+	var last Checkpoint
+	checkpoints := NewCheckpointer(start, time.Hour, func(cp Checkpoint) { last = cp })
+
+	cutoff := time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC)
+	opt := Options{BaseURL: server.URL, Client: client, Concurrency: 2, Checkpoints: checkpoints}
+
+	posts, _, err := FetchAPI(context.Background(), cutoff, opt)
+	if err != nil {
+		t.Fatalf("FetchAPI() error: %v", err)
+	}
+	if len(posts) != 3 {
+		t.Fatalf("expected 3 posts, got %+v", posts)
+	}
+	for _, post := range posts {
+		if post.Type != model.TypeEPUB {
+			t.Fatalf("saved taxonomy not applied: %+v", post)
+		}
+	}
+	if len(requested) != 1 || requested[0] != "/wp-json/wp/v2/posts?page=3" {
+		t.Fatalf("expected only page 3 to be requested, got %v", requested)
+	}
+
+	checkpoints.Flush()
+	if last.API == nil || last.API.Page != 3 || len(last.API.Posts) != 3 {
+		t.Fatalf("progress not recorded: %+v", last.API)
+	}
+}
+
+func TestFetchHTMLResumesFromCheckpoint(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		requested []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested = append(requested, r.URL.Path)
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/page/2/":
+			fmt.Fprint(w, `<article><h2 class="entry-title"><a href="/second-volume-1-epub/">Second Volume 1 EPUB</a></h2></article>`)
+		case "/second-volume-1-epub/":
+			fmt.Fprint(w, `<time datetime="2025-10-10T00:00:00Z"></time>`)
+		case "/pending-volume-1-epub/":
+			fmt.Fprint(w, `<time datetime="2025-10-12T00:00:00Z"></time>`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := httpx.NewClient(1*time.Millisecond, 5*time.Millisecond,
+		httpx.WithHTTPClient(server.Client()),
+		httpx.WithJitterFactor(0),
+	)
+
+	start := Checkpoint{HTML: &HTMLCheckpoint{
+		Page:    1,
+		NextURL: server.URL + "/page/2/",
+		Posts: model.Posts{{
+			Title: "First",
+			Date:  time.Date(2025, time.October, 15, 0, 0, 0, 0, time.UTC),
+			Link:  server.URL + "/first-volume-1-epub/",
+			Type:  model.TypeEPUB,
+		}},
+		Pending: []FailedFetch{{Kind: FailureHTMLDetail, URL: server.URL + "/pending-volume-1-epub/", Title: "Pending Volume 1 EPUB"}},
+	}}
+	checkpoints := NewCheckpointer(start, time.Hour, nil)
+
+	cutoff := time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC)
+	opt := Options{BaseURL: server.URL, Client: client, Concurrency: 1, Checkpoints: checkpoints}
+
+	posts, _, err := FetchHTML(context.Background(), cutoff, opt)
+	if err != nil {
+		t.Fatalf("FetchHTML() error: %v", err)
+	}
+	if len(posts) != 3 || posts[0].Title != "First" || posts[1].Title != "Pending" || posts[2].Title != "Second" {
+		t.Fatalf("unexpected posts: %+v", posts)
+	}
+	for _, path := range requested {
+		if path == "/" {
+			t.Fatalf("page 1 fetched again: %v", requested)
+		}
+	}
+}
@@ -2,34 +2,31 @@ package collect
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
-	"git.skobk.in/skobkin/jnovel-scrape/internal/httpx"
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+
+	"git.skobk.in/skobkin/jnovel-scrape/internal/event"
 	"git.skobk.in/skobkin/jnovel-scrape/internal/model"
 	"git.skobk.in/skobkin/jnovel-scrape/internal/util"
 )
 
 const fallbackUserAgent = "jnovels-scrape/1.0 (+https://example.com/contact)"
 
-var (
-	articlePattern   = regexp.MustCompile(`(?is)<article\b.*?</article>`)
-	headingPattern   = regexp.MustCompile(`(?is)<h[12][^>]*class="[^">]*entry-title[^">]*"[^>]*>.*?<a[^>]*href="([^\"]+)"[^>]*>(.*?)</a>`)
-	timePattern      = regexp.MustCompile(`(?is)<time[^>]*datetime="([^\"]+)"`)
-	metaTimePattern  = regexp.MustCompile(`(?is)<meta[^>]*property="article:published_time"[^>]*content="([^\"]+)"`)
-	anchorRelPattern = regexp.MustCompile(`(?is)<a[^>]*rel="([^\"]+)"[^>]*>(.*?)</a>`)
-	dateTextPattern  = regexp.MustCompile(`(?i)(January|February|March|April|May|June|July|August|September|October|November|December)\s+\d{1,2},\s+\d{4}`)
-)
-
 // FetchHTML crawls the website using HTML as a fallback.
-func FetchHTML(ctx context.Context, cutoff time.Time, opt Options) (model.Posts, []string, error) {
+func FetchHTML(ctx context.Context, cutoff time.Time, opt Options) (model.Posts, []Warning, error) {
 	if opt.Client == nil {
 		return nil, nil, fmt.Errorf("http client is required")
 	}
@@ -43,83 +40,200 @@ func FetchHTML(ctx context.Context, cutoff time.Time, opt Options) (model.Posts,
 		opt.Concurrency = 4
 	}
 
-	logger := opt.Logger
-	if logger == nil {
-		logger = noopLogger{}
+	sel, err := opt.Selectors.compile()
+	if err != nil {
+		return nil, nil, err
 	}
+	opt.selectors = sel
+	opt.retries = &retryQueue{}
+
+	logger := opt.logger()
 
 	var (
 		allPosts model.Posts
-		warnings []string
+		warnings []Warning
 	)
+	seen := model.NewIdentitySet()
+	if opt.sink != nil {
+		seen = opt.sink.seen
+	}
+	add := func(posts model.Posts) {
+		if opt.sink != nil {
+			opt.sink.emit(posts...)
 
-	for page := 1; page <= opt.MaxPages; page++ {
-		pageURL := archiveURL(opt.BaseURL, page)
-		req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
-		if err != nil {
-			return nil, nil, err
+			return
 		}
-		setHTMLHeaders(req, opt.UserAgent)
+		for _, post := range posts {
+			if seen.Add(post) {
+				allPosts = append(allPosts, post)
+			}
+		}
+	}
+	// finish runs the second pass over failed detail pages, whether or
+	// not the crawl itself completed.
+	finish := func(err error) (model.Posts, []Warning, error) {
+		posts, retryWarnings := retryDetails(ctx, cutoff, opt)
+		warnings = append(warnings, retryWarnings...)
+		add(posts)
+		if opt.sink != nil {
+			// Every post has been streamed already.
+			if err != nil && !opt.sink.oldest.IsZero() {
+				err = &PartialError{Err: err, Reached: opt.sink.oldest}
+			}
 
-		resp, err := opt.Client.Do(ctx, req)
+			return nil, warnings, err
+		}
 		if err != nil {
-			return nil, nil, err
+			return partialHTML(allPosts, warnings, err)
 		}
-		if resp.StatusCode >= 400 {
-			payload, _ := io.ReadAll(resp.Body)
-			_ = resp.Body.Close()
+		allPosts.Sort()
+
+		return allPosts, warnings, nil
+	}
 
-			return nil, nil, fmt.Errorf("archive request failed: %s (%s)", resp.Status, string(payload))
+	if opt.Resume != nil {
+		known := newKnownPosts(opt.Resume.Known)
+		for _, post := range opt.Resume.Known {
+			seen.Add(post)
+		}
+		for _, monthBase := range monthArchives(opt.BaseURL, opt.Resume.Before, cutoff) {
+			logger.Info("HTML resume", "archive", monthBase)
+			posts, crawlWarnings, reachedCutoff, err := crawlArchive(ctx, cutoff, opt, monthBase, "", known)
+			warnings = append(warnings, crawlWarnings...)
+			add(posts)
+			if err != nil {
+				return finish(err)
+			}
+			if reachedCutoff {
+				break
+			}
 		}
 
-		body, err := io.ReadAll(resp.Body)
-		_ = resp.Body.Close()
-		if err != nil {
-			return nil, nil, fmt.Errorf("read archive: %w", err)
+		return finish(nil)
+	}
+
+	for _, search := range searchQueries(opt.Search) {
+		if search != "" {
+			logger.Info("HTML search", "search", search)
 		}
+		posts, crawlWarnings, _, err := crawlArchive(ctx, cutoff, opt, opt.BaseURL, search, nil)
+		if err != nil {
+			// Search results have no date order to resume from, but
+			// what an interrupted search found is still worth keeping.
+			if search != "" && ctx.Err() == nil {
+				return nil, nil, err
+			}
+			warnings = append(warnings, crawlWarnings...)
+			add(posts)
 
-		candidates := extractArchiveCandidates(string(body), opt.BaseURL)
-		logger.Infof("HTML page=%d candidates=%d", page, len(candidates))
-		if len(candidates) == 0 {
-			break
+			return finish(err)
 		}
+		warnings = append(warnings, crawlWarnings...)
+		add(posts)
+	}
 
-		pagePosts, pageWarnings := enrichCandidates(ctx, opt, candidates)
-		warnings = append(warnings, pageWarnings...)
+	return finish(nil)
+}
 
-		var kept model.Posts
-		for _, post := range pagePosts {
-			if post.Date.Before(cutoff) {
-				warnings = append(warnings, fmt.Sprintf("%s skipped (date %s before cutoff)", post.Link, post.FormatDate()))
+// partialHTML wraps a crawl error in a PartialError when posts were
+// collected before it.
+func partialHTML(posts model.Posts, warnings []Warning, err error) (model.Posts, []Warning, error) {
+	if len(posts) == 0 {
+		return nil, warnings, err
+	}
+	posts.Sort()
 
-				continue
-			}
-			allPosts = append(allPosts, post)
-			kept = append(kept, post)
-		}
+	return posts, warnings, &PartialError{Err: err, Reached: posts[len(posts)-1].Date}
+}
 
-		if len(kept) == 0 {
-			break
-		}
+// fetchArchivePage loads and parses one archive page. When notFoundEnds
+// is set a 404 returns a nil document: WordPress answers 404 past the
+// last archive page, and on the first page of an empty month archive.
+func fetchArchivePage(ctx context.Context, opt Options, pageURL string, notFoundEnds bool) (*html.Node, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, err
 	}
+	setHTMLHeaders(req, opt.UserAgent)
 
-	allPosts.Sort()
+	resp, err := opt.Client.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode == http.StatusNotFound && notFoundEnds {
+		return nil, nil
+	}
+	if resp.StatusCode >= 400 {
+		payload, _ := io.ReadAll(resp.Body)
 
-	return allPosts, warnings, nil
+		return nil, fmt.Errorf("archive request failed: %s (%s)", resp.Status, string(payload))
+	}
+
+	doc, err := html.Parse(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read archive: %w", err)
+	}
+
+	return doc, nil
 }
 
 type archiveCandidate struct {
 	Title string
 	Link  string
+	// ID is the WordPress post ID from the archive block's id or class
+	// ("post-123"); zero when the theme does not print it.
+	ID int64
+	// Sticky marks a post pinned to the front of the archive.
+	Sticky bool
+	// Date, Categories and Tags are read from the archive block when
+	// the theme prints them there; Date is zero otherwise.
+	Date       time.Time
+	Categories []string
+	Tags       []string
 }
 
-func archiveURL(base string, page int) string {
+// needsDetail reports whether the detail page must be fetched: the
+// archive block lacks a date or enough taxonomy to tell the type, or
+// the caller wants data only the detail page has.
+func (c archiveCandidate) needsDetail(opt Options) bool {
+	if opt.ForceDetail || opt.FetchMetadata || opt.FetchCovers {
+		return true
+	}
+
+	return c.Date.IsZero() || util.InferType(c.Title, c.Categories, c.Tags) == model.TypeUnknown
+}
+
+func archiveURL(base string, page int, search string) string {
 	trimmed := strings.TrimRight(base, "/")
+	pageURL := trimmed
+	if page > 1 {
+		pageURL = fmt.Sprintf("%s/page/%d/", trimmed, page)
+	}
+	if search == "" {
+		return pageURL
+	}
 	if page <= 1 {
-		return trimmed
+		pageURL += "/"
+	}
+
+	// WordPress orders search results by relevance; the date-based
+	// stop rule needs them newest first like the archive.
+	return pageURL + "?s=" + url.QueryEscape(search) + "&orderby=date&order=desc"
+}
+
+// nextArchiveURL follows the theme's next-page link when the archive has
+// one and otherwise counts up through /page/N/.
+func nextArchiveURL(doc *html.Node, opt Options, base, current string, next int, search string) string {
+	if link := cascadia.Query(doc, opt.selectors.nextPage); link != nil {
+		if href := strings.TrimSpace(attr(link, "href")); href != "" {
+			if resolved := resolveLink(current, href); resolved != current {
+				return resolved
+			}
+		}
 	}
 
-	return fmt.Sprintf("%s/page/%d/", trimmed, page)
+	return archiveURL(base, next, search)
 }
 
 func setHTMLHeaders(req *http.Request, userAgent string) {
@@ -130,35 +244,60 @@ func setHTMLHeaders(req *http.Request, userAgent string) {
 	req.Header.Set("Accept", "text/html,application/xhtml+xml")
 }
 
-func extractArchiveCandidates(pageHTML, base string) []archiveCandidate {
-	blocks := articlePattern.FindAllString(pageHTML, -1)
+func extractArchiveCandidates(doc *html.Node, sel *compiledSelectors, base string) []archiveCandidate {
+	blocks := cascadia.QueryAll(doc, sel.article)
 	candidates := make([]archiveCandidate, 0, len(blocks))
 	for _, block := range blocks {
-		match := headingPattern.FindStringSubmatch(block)
-		if len(match) < 3 {
+		link := cascadia.Query(block, sel.titleLink)
+		if link == nil {
 			continue
 		}
-		href := strings.TrimSpace(match[1])
-		title := util.CleanTitle(match[2])
+		href := strings.TrimSpace(attr(link, "href"))
+		title := util.CleanTitle(innerHTML(link))
 		if href == "" || title == "" {
 			continue
 		}
-		candidates = append(candidates, archiveCandidate{
-			Title: title,
-			Link:  resolveLink(base, href),
-		})
+		candidate := archiveCandidate{
+			Title:  title,
+			Link:   resolveLink(base, href),
+			ID:     articleID(block),
+			Sticky: sel.sticky.Match(block) || cascadia.Query(block, sel.sticky) != nil,
+		}
+		for _, node := range cascadia.QueryAll(block, sel.date) {
+			if parsed, ok := parseDateNode(node); ok {
+				candidate.Date = parsed.UTC()
+
+				break
+			}
+		}
+		candidate.Categories, candidate.Tags = extractTaxonomy(block, sel)
+		candidates = append(candidates, candidate)
 	}
 
 	return candidates
 }
 
 type detailResult struct {
+	index    int
 	post     *model.Post
-	warnings []string
+	warnings []Warning
+	// retry marks a failed fetch (network error, 5xx and the like) as
+	// opposed to a page that loaded but could not be used.
+	retry bool
 }
 
-func enrichCandidates(ctx context.Context, opt Options, candidates []archiveCandidate) ([]model.Post, []string) {
-	jobCh := make(chan archiveCandidate)
+type detailJob struct {
+	index     int
+	candidate archiveCandidate
+}
+
+// enrichCandidates fetches the detail page of every candidate and returns
+// the resulting posts in candidate order. Failed fetches are handled by
+// settleDetail; after cancellation the candidates not fetched yet fail
+// the same way, so none goes missing. The archive crawl itself uses the
+// pipeline in crawlArchive; this is for the retry pass and retry-failed.
+func enrichCandidates(ctx context.Context, opt Options, candidates []archiveCandidate) ([]model.Post, []Warning) {
+	jobCh := make(chan detailJob)
 	resultCh := make(chan detailResult)
 	var wg sync.WaitGroup
 
@@ -171,28 +310,21 @@ func enrichCandidates(ctx context.Context, opt Options, candidates []archiveCand
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			for candidate := range jobCh {
-				select {
-				case <-ctx.Done():
-					return
-				default:
-				}
-				resultCh <- fetchDetail(ctx, opt.Client, opt.UserAgent, candidate)
+			for job := range jobCh {
+				result := fetchDetail(ctx, opt, job.candidate)
+				result.index = job.index
+				resultCh <- result
 			}
 		}()
 	}
 
 	go func() {
-		for _, candidate := range candidates {
-			select {
-			case jobCh <- candidate:
-			case <-ctx.Done():
-				close(jobCh)
-
-				return
-			}
+		defer close(jobCh)
+		// Every candidate is handed out even after cancellation, so each
+		// one ends up collected or reported as failed.
+		for i, candidate := range candidates {
+			jobCh <- detailJob{index: i, candidate: candidate}
 		}
-		close(jobCh)
 	}()
 
 	go func() {
@@ -200,52 +332,122 @@ func enrichCandidates(ctx context.Context, opt Options, candidates []archiveCand
 		close(resultCh)
 	}()
 
+	results := make([]detailResult, 0, len(candidates))
+	for result := range resultCh {
+		results = append(results, result)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].index < results[j].index })
+
 	var (
 		collected []model.Post
-		warnings  []string
+		warnings  []Warning
 	)
-
-	for result := range resultCh {
-		warnings = append(warnings, result.warnings...)
-		if result.post != nil {
-			collected = append(collected, *result.post)
+	for _, result := range results {
+		post, resultWarnings := settleDetail(opt, candidates[result.index], result)
+		warnings = append(warnings, resultWarnings...)
+		if post != nil {
+			collected = append(collected, *post)
 		}
 	}
 
 	return collected, warnings
 }
 
-func fetchDetail(ctx context.Context, client *httpx.Client, userAgent string, candidate archiveCandidate) detailResult {
+// settleDetail applies the retry policy to one detail result: a failed
+// fetch is queued on opt.retries for the second pass, or, without a
+// queue, recorded in opt.Failures and reported.
+func settleDetail(opt Options, candidate archiveCandidate, result detailResult) (*model.Post, []Warning) {
+	if result.post != nil {
+		opt.observe(event.PostParsed{Source: event.SourceHTML, Post: *result.post})
+
+		return result.post, result.warnings
+	}
+	reason := warningDetails(result.warnings)
+	if result.retry {
+		if opt.retries.push(candidate) {
+			opt.observeSecondPass(candidate.Link, errors.New(reason))
+
+			return nil, nil
+		}
+		opt.Failures.add(FailedFetch{
+			Kind:  FailureHTMLDetail,
+			URL:   candidate.Link,
+			Title: candidate.Title,
+			ID:    candidate.ID,
+			Error: reason,
+		})
+	}
+	opt.observe(event.PostSkipped{Source: event.SourceHTML, URL: candidate.Link, Reason: reason})
+
+	return nil, result.warnings
+}
+
+func fetchDetail(ctx context.Context, opt Options, candidate archiveCandidate) detailResult {
+	if err := ctx.Err(); err != nil {
+		// Cancelled before the request was made: cheap to report, and
+		// retryable like any interrupted request.
+		return detailResult{warnings: []Warning{warnf(WarningFetchFailed, candidate.Link, "not fetched: %v → skipped", err)}, retry: true}
+	}
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, candidate.Link, nil)
 	if err != nil {
-		return detailResult{warnings: []string{fmt.Sprintf("%s build request: %v → skipped", candidate.Link, err)}}
+		return detailResult{warnings: []Warning{warnf(WarningFetchFailed, candidate.Link, "build request: %v → skipped", err)}}
 	}
-	setHTMLHeaders(req, userAgent)
+	setHTMLHeaders(req, opt.UserAgent)
+	opt.observe(event.DetailStarted{URL: candidate.Link})
+	defer opt.observe(event.DetailFinished{URL: candidate.Link})
 
-	resp, err := client.Do(ctx, req)
+	resp, err := opt.Client.Do(ctx, req)
 	if err != nil {
-		return detailResult{warnings: []string{fmt.Sprintf("%s request failed: %v → skipped", candidate.Link, err)}}
+		return detailResult{warnings: []Warning{warnf(WarningFetchFailed, candidate.Link, "request failed: %v → skipped", err)}, retry: true}
 	}
 	if resp.StatusCode >= 400 {
 		payload, _ := io.ReadAll(resp.Body)
 		_ = resp.Body.Close()
+		// A removed post stays removed; retrying it is pointless.
+		gone := resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone
 
-		return detailResult{warnings: []string{fmt.Sprintf("%s unexpected status %s (%s) → skipped", candidate.Link, resp.Status, string(payload))}}
+		return detailResult{warnings: []Warning{warnf(WarningFetchFailed, candidate.Link, "unexpected status %s (%s) → skipped", resp.Status, string(payload))}, retry: !gone}
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	doc, err := html.Parse(resp.Body)
 	_ = resp.Body.Close()
 	if err != nil {
-		return detailResult{warnings: []string{fmt.Sprintf("%s read error: %v → skipped", candidate.Link, err)}}
+		return detailResult{warnings: []Warning{warnf(WarningFetchFailed, candidate.Link, "read error: %v → skipped", err)}, retry: true}
 	}
 
-	html := string(body)
-	published, err := extractPublishedDate(html)
+	sel := opt.selectors
+	published, dateSource, err := extractPublishedDate(doc, sel)
 	if err != nil {
-		return detailResult{warnings: []string{fmt.Sprintf("%s missing date (%v) → skipped", candidate.Link, err)}}
+		return detailResult{warnings: []Warning{warnf(WarningMissingDate, candidate.Link, "missing date (%v) → skipped", err)}}
+	}
+
+	categories, tags := extractTaxonomy(doc, sel)
+	post, postWarnings := buildHTMLPost(candidate, published, dateSource, categories, tags)
+	post.SourceID = detailPostID(doc, sel, candidate.ID)
+	if modified, ok := extractModifiedDate(doc); ok {
+		post.Modified = modified.UTC()
+	}
+	applyBookMeta(&post, extractEntryContent(doc, sel))
+	if cover := metaContent(doc, "og:image"); cover != "" {
+		post.CoverURL = resolveLink(candidate.Link, cover)
+		post.CoverWidth, _ = strconv.Atoi(metaContent(doc, "og:image:width"))
+		post.CoverHeight, _ = strconv.Atoi(metaContent(doc, "og:image:height"))
 	}
 
-	categories, tags := extractTaxonomy(html)
+	warnings := make([]Warning, 0, 3)
+	if dateSource == model.DateSourceModified {
+		warnings = append(warnings, warnf(WarningMissingDate, candidate.Link, "publish date missing → using last-modified time"))
+	}
+	warnings = append(warnings, postWarnings...)
+
+	return detailResult{post: &post, warnings: warnings}
+}
+
+// buildHTMLPost turns a candidate and its date and taxonomy, read from
+// either the detail page or the archive block, into a post: it infers
+// the type and parses the volume from the raw title, falling back to the
+// link slug.
+func buildHTMLPost(candidate archiveCandidate, published time.Time, dateSource model.DateSource, categories, tags []string) (model.Post, []Warning) {
 	rawTitle := candidate.Title
 	postType := util.InferType(rawTitle, categories, tags)
 	title, volume, volumeExtra := util.ExtractTitleAndVolume(rawTitle)
@@ -259,11 +461,12 @@ func fetchDetail(ctx context.Context, client *httpx.Client, userAgent string, ca
 		VolumeExtra: volumeExtra,
 		Type:        postType,
 		Date:        published.UTC(),
+		DateSource:  dateSource,
 		Link:        candidate.Link,
+		SourceID:    candidate.ID,
 		Categories:  categories,
 		Tags:        tags,
 	}
-
 	if post.Volume == nil {
 		if slugVol, slugExtra, ok := util.ExtractVolumeFromLink(post.Link); ok {
 			post.Volume = slugVol
@@ -274,63 +477,280 @@ func fetchDetail(ctx context.Context, client *httpx.Client, userAgent string, ca
 	}
 	post.VolumeExtra = strings.TrimSpace(post.VolumeExtra)
 
-	warnings := make([]string, 0, 2)
+	var warnings []Warning
 	if volume == nil {
-		warnings = append(warnings, fmt.Sprintf("%s missing volume (no regex match) → kept with blank volume", candidate.Link))
+		warnings = append(warnings, warnf(WarningMissingVolume, candidate.Link, "missing volume (no regex match) → kept with blank volume"))
 	}
 	if postType == model.TypeUnknown {
-		warnings = append(warnings, fmt.Sprintf("%s type unresolved → UNKNOWN", candidate.Link))
+		warnings = append(warnings, warnf(WarningUnknownType, candidate.Link, "type unresolved → UNKNOWN"))
 	}
 
-	return detailResult{post: &post, warnings: warnings}
+	return post, warnings
+}
+
+// extractPublishedDate looks for the publish date in order of
+// reliability: the date selector (datetime or content attribute first,
+// then text), schema.org JSON-LD and microdata datePublished, and a date
+// written out in any supported locale in the text of the post itself;
+// sidebars, comments and related posts are not searched unless the page
+// has no post markup to tell them apart. Only when all of
+// those fail does it settle for a last-modified timestamp, which is
+// usually close to the publish date on this site and beats dropping the
+// post.
+func extractPublishedDate(doc *html.Node, sel *compiledSelectors) (time.Time, model.DateSource, error) {
+	for _, node := range cascadia.QueryAll(doc, sel.date) {
+		if parsed, ok := parseDateNode(node); ok {
+			return parsed, model.DateSourceSelector, nil
+		}
+	}
+	if parsed, ok := jsonLDDate(doc, "datePublished"); ok {
+		return parsed, model.DateSourceJSONLD, nil
+	}
+	for _, node := range cascadia.QueryAll(doc, datePublishedItemprop) {
+		if parsed, ok := parseDateNode(node); ok {
+			return parsed, model.DateSourceMicrodata, nil
+		}
+	}
+	scope := mainArticle(doc, sel)
+	if scope == nil {
+		scope = doc
+	}
+	if parsed, ok := util.FindLocalizedDate(textContent(scope)); ok {
+		return parsed, model.DateSourceText, nil
+	}
+
+	if parsed, ok := extractModifiedDate(doc); ok {
+		return parsed, model.DateSourceModified, nil
+	}
+
+	return time.Time{}, "", fmt.Errorf("no publish date found")
 }
 
-func extractPublishedDate(content string) (time.Time, error) {
-	if match := timePattern.FindStringSubmatch(content); len(match) > 1 {
-		if parsed, err := parseWPTime(match[1], ""); err == nil {
-			return parsed, nil
+// extractModifiedDate reads the last-modified timestamp from JSON-LD
+// dateModified, the article:modified_time / og:updated_time meta tags or
+// microdata dateModified.
+func extractModifiedDate(doc *html.Node) (time.Time, bool) {
+	if parsed, ok := jsonLDDate(doc, "dateModified"); ok {
+		return parsed, true
+	}
+	for _, key := range []string{"article:modified_time", "og:updated_time"} {
+		if parsed, err := parseWPTime(metaContent(doc, key), ""); err == nil {
+			return parsed, true
 		}
 	}
-	if match := metaTimePattern.FindStringSubmatch(content); len(match) > 1 {
-		if parsed, err := parseWPTime(match[1], ""); err == nil {
-			return parsed, nil
+	for _, node := range cascadia.QueryAll(doc, dateModifiedItemprop) {
+		if parsed, ok := parseDateNode(node); ok {
+			return parsed, true
 		}
 	}
 
-	stripped := util.StripTags(content)
-	if match := dateTextPattern.FindString(stripped); match != "" {
-		if parsed, err := time.Parse("January 2, 2006", strings.TrimSpace(match)); err == nil {
-			return parsed, nil
+	return time.Time{}, false
+}
+
+var (
+	datePublishedItemprop = cascadia.MustCompile(`[itemprop="datePublished"]`)
+	dateModifiedItemprop  = cascadia.MustCompile(`[itemprop="dateModified"]`)
+	jsonLDSelector        = cascadia.MustCompile(`script[type="application/ld+json"]`)
+)
+
+// parseDateNode reads a timestamp from an element's datetime or content
+// attribute, falling back to its text.
+func parseDateNode(node *html.Node) (time.Time, bool) {
+	for _, raw := range []string{attr(node, "datetime"), attr(node, "content"), textContent(node)} {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		if parsed, err := parseWPTime(raw, ""); err == nil {
+			return parsed, true
+		}
+		if parsed, ok := util.FindLocalizedDate(raw); ok {
+			return parsed, true
 		}
 	}
 
-	return time.Time{}, fmt.Errorf("no publish date found")
+	return time.Time{}, false
 }
 
-func extractTaxonomy(content string) (categories, tags []string) {
-	catSet := map[string]struct{}{}
-	tagSet := map[string]struct{}{}
-	matches := anchorRelPattern.FindAllStringSubmatch(content, -1)
-	for _, match := range matches {
-		if len(match) < 3 {
+// jsonLDDate returns key from the page's schema.org JSON-LD. Article-like
+// objects (Article, BlogPosting, NewsArticle) win over others such as
+// WebPage, which some SEO plugins emit with their own dates.
+func jsonLDDate(doc *html.Node, key string) (time.Time, bool) {
+	var objects []map[string]any
+	for _, script := range cascadia.QueryAll(doc, jsonLDSelector) {
+		if script.FirstChild == nil {
 			continue
 		}
-		rel := strings.ToLower(match[1])
-		label := util.CleanTitle(match[2])
-		if label == "" {
+		var payload any
+		if err := json.Unmarshal([]byte(script.FirstChild.Data), &payload); err != nil {
 			continue
 		}
-		switch {
-		case strings.Contains(rel, "category"):
-			catSet[label] = struct{}{}
-		case strings.Contains(rel, "tag"):
-			tagSet[label] = struct{}{}
+		objects = appendJSONLDObjects(objects, payload)
+	}
+
+	for _, articleOnly := range []bool{true, false} {
+		for _, obj := range objects {
+			if articleOnly && !isJSONLDArticle(obj["@type"]) {
+				continue
+			}
+			raw, _ := obj[key].(string)
+			if parsed, err := parseWPTime(strings.TrimSpace(raw), ""); err == nil {
+				return parsed, true
+			}
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// appendJSONLDObjects flattens top-level arrays and @graph containers.
+func appendJSONLDObjects(out []map[string]any, v any) []map[string]any {
+	switch value := v.(type) {
+	case []any:
+		for _, item := range value {
+			out = appendJSONLDObjects(out, item)
+		}
+	case map[string]any:
+		out = append(out, value)
+		if graph, ok := value["@graph"]; ok {
+			out = appendJSONLDObjects(out, graph)
+		}
+	}
+
+	return out
+}
+
+func isJSONLDArticle(t any) bool {
+	switch value := t.(type) {
+	case string:
+		return strings.HasSuffix(value, "Article") || value == "BlogPosting"
+	case []any:
+		for _, item := range value {
+			if isJSONLDArticle(item) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// mainArticle returns the node holding the post on a detail page: the
+// article block around the content selector match, the match itself when
+// no article encloses it, or the first article block when the theme has
+// no content wrapper. It returns nil when the page has neither.
+func mainArticle(doc *html.Node, sel *compiledSelectors) *html.Node {
+	content := cascadia.Query(doc, sel.content)
+	if content == nil {
+		return cascadia.Query(doc, sel.article)
+	}
+	for node := content.Parent; node != nil; node = node.Parent {
+		if sel.article.Match(node) {
+			return node
+		}
+	}
+
+	return content
+}
+
+// extractEntryContent returns the post body of a detail page: the
+// content selector match, or the first article block when the theme
+// has no content wrapper.
+func extractEntryContent(doc *html.Node, sel *compiledSelectors) string {
+	if node := cascadia.Query(doc, sel.content); node != nil {
+		return innerHTML(node)
+	}
+	if node := cascadia.Query(doc, sel.article); node != nil {
+		return innerHTML(node)
+	}
+
+	return ""
+}
+
+// metaContent returns the trimmed content of the first <meta> tag whose
+// property or name attribute equals key.
+func metaContent(doc *html.Node, key string) string {
+	for _, node := range cascadia.QueryAll(doc, metaSelector) {
+		if attr(node, "property") == key || attr(node, "name") == key {
+			return strings.TrimSpace(attr(node, "content"))
+		}
+	}
+
+	return ""
+}
+
+var metaSelector = cascadia.MustCompile("meta[content]")
+
+func extractTaxonomy(doc *html.Node, sel *compiledSelectors) (categories, tags []string) {
+	labels := func(matcher cascadia.Matcher) []string {
+		set := map[string]struct{}{}
+		for _, node := range cascadia.QueryAll(doc, matcher) {
+			if label := util.CleanTitle(innerHTML(node)); label != "" {
+				set[label] = struct{}{}
+			}
+		}
+
+		return setToSortedSlice(set)
+	}
+
+	return labels(sel.category), labels(sel.tag)
+}
+
+var (
+	shortlinkSelector = cascadia.MustCompile(`link[rel~="shortlink"][href]`)
+	bodySelector      = cascadia.MustCompile("body")
+	postIDPattern     = regexp.MustCompile(`^post-(\d+)$`)
+	postIDClass       = regexp.MustCompile(`^postid-(\d+)$`)
+)
+
+// detailPostID reads the WordPress post ID from a detail page: the
+// rel="shortlink" URL (?p=123) or a postid-123 body class. Failing
+// those, fallback (usually the archive block's ID) wins over the id of
+// the post's article, since a related-posts widget can carry article
+// ids of its own; the article is only read when fallback is zero.
+func detailPostID(doc *html.Node, sel *compiledSelectors, fallback int64) int64 {
+	if link := cascadia.Query(doc, shortlinkSelector); link != nil {
+		if parsed, err := url.Parse(attr(link, "href")); err == nil {
+			if id, err := strconv.ParseInt(parsed.Query().Get("p"), 10, 64); err == nil && id > 0 {
+				return id
+			}
+		}
+	}
+	if body := cascadia.Query(doc, bodySelector); body != nil {
+		if id := matchID(strings.Fields(attr(body, "class")), postIDClass); id != 0 {
+			return id
+		}
+	}
+	if fallback != 0 {
+		return fallback
+	}
+	if article := mainArticle(doc, sel); article != nil {
+		return articleID(article)
+	}
+
+	return 0
+}
+
+// articleID reads "post-123" from an article's id attribute or, failing
+// that, from its classes.
+func articleID(node *html.Node) int64 {
+	if id := matchID([]string{strings.TrimSpace(attr(node, "id"))}, postIDPattern); id != 0 {
+		return id
+	}
+
+	return matchID(strings.Fields(attr(node, "class")), postIDPattern)
+}
+
+func matchID(tokens []string, pattern *regexp.Regexp) int64 {
+	for _, token := range tokens {
+		if match := pattern.FindStringSubmatch(token); match != nil {
+			if id, err := strconv.ParseInt(match[1], 10, 64); err == nil && id > 0 {
+				return id
+			}
 		}
 	}
-	categories = setToSortedSlice(catSet)
-	tags = setToSortedSlice(tagSet)
 
-	return
+	return 0
 }
 
 func resolveLink(baseURL, href string) string {
@@ -2,14 +2,20 @@ package collect
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"golang.org/x/net/html"
+
+	"git.skobk.in/skobkin/jnovel-scrape/internal/event"
 	"git.skobk.in/skobkin/jnovel-scrape/internal/httpx"
 	"git.skobk.in/skobkin/jnovel-scrape/internal/model"
 )
@@ -24,10 +30,10 @@ func TestFetchHTMLSuccess(t *testing.T) {
 			atomic.AddInt32(&archiveRequests, 1)
 			fmt.Fprint(w, `
 				<html><body>
-					<article>
+					<article id="post-101" class="post-101 post">
 						<h2 class="entry-title"><a href="/hero-volume-2-epub/">Hero Volume 2 EPUB</a></h2>
 					</article>
-					<article>
+					<article class="post-102 post">
 						<h2 class="entry-title"><a href="/mystery-epub-volume-3/">Mystery EPUB</a></h2>
 					</article>
 				</body></html>
@@ -39,6 +45,12 @@ func TestFetchHTMLSuccess(t *testing.T) {
 			atomic.AddInt32(&detailRequests, 1)
 			fmt.Fprintf(w, `
 				<html>
+					<head>
+						<meta content="/covers/hero-2.jpg" property="og:image">
+						<meta property="og:image:width" content="640">
+						<meta property="og:image:height" content="960">
+						<link rel='shortlink' href='https://example.com/?p=201'>
+					</head>
 					<body>
 						<time datetime="2025-10-15T00:00:00Z"></time>
 						<a rel="category">Light Novels</a>
@@ -92,8 +104,17 @@ func TestFetchHTMLSuccess(t *testing.T) {
 	if posts[1].Volume == nil || *posts[1].Volume != 3 {
 		t.Fatalf("expected slug-derived volume 3, got %v", posts[1].Volume)
 	}
-	if !strings.Contains(warnings[0], "missing volume") {
-		t.Fatalf("unexpected warning text: %v", warnings)
+	if posts[0].CoverURL != server.URL+"/covers/hero-2.jpg" || posts[0].CoverWidth != 640 || posts[0].CoverHeight != 960 {
+		t.Fatalf("unexpected cover: %q %dx%d", posts[0].CoverURL, posts[0].CoverWidth, posts[0].CoverHeight)
+	}
+	if posts[0].SourceID != 201 || posts[1].SourceID != 102 {
+		t.Fatalf("expected shortlink and archive IDs, got %d and %d", posts[0].SourceID, posts[1].SourceID)
+	}
+	if posts[1].CoverURL != "" {
+		t.Fatalf("expected no cover for second post, got %q", posts[1].CoverURL)
+	}
+	if warnings[0].Code != WarningMissingVolume || !strings.Contains(warnings[0].Detail, "missing volume") {
+		t.Fatalf("unexpected warning: %v", warnings)
 	}
 	if atomic.LoadInt32(&archiveRequests) != 2 {
 		t.Fatalf("expected two archive requests, got %d", archiveRequests)
@@ -102,3 +123,501 @@ func TestFetchHTMLSuccess(t *testing.T) {
 		t.Fatalf("expected two detail requests, got %d", detailRequests)
 	}
 }
+
+func TestDetailPostID(t *testing.T) {
+	related := `<div class="entry-content"><p>Body</p></div></article>
+		<section class="related"><article id="post-12" class="post"></article></section>`
+	cases := []struct {
+		name     string
+		page     string
+		fallback int64
+		want     int64
+	}{
+		{"shortlink", `<head><link rel="shortlink" href="https://jnovels.com/?p=77"></head><body class="single postid-5"></body>`, 9, 77},
+		{"body class", `<body class="single single-post postid-55"></body>`, 9, 55},
+		{"archive id over article", `<body><article id="post-66" class="post"></article></body>`, 9, 9},
+		{"article id", `<body><article id="post-66" class="post"></article></body>`, 0, 66},
+		{"main article", `<body><aside><article id="post-12"></article></aside><article id="post-66">` + related + `</body>`, 0, 66},
+		{"related posts only", `<body><article class="post">` + related + `</body>`, 0, 0},
+		{"fallback", `<body><p>nothing</p></body>`, 9, 9},
+	}
+	sel, err := Selectors{}.compile()
+	if err != nil {
+		t.Fatalf("compile default selectors: %v", err)
+	}
+	for _, tc := range cases {
+		doc, err := html.Parse(strings.NewReader("<html>" + tc.page + "</html>"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := detailPostID(doc, sel, tc.fallback); got != tc.want {
+			t.Fatalf("%s: detailPostID() = %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestArchiveURL(t *testing.T) {
+	cases := []struct {
+		page   int
+		search string
+		want   string
+	}{
+		{1, "", "https://example.com"},
+		{3, "", "https://example.com/page/3/"},
+		{1, "spice and wolf", "https://example.com/?s=spice+and+wolf&orderby=date&order=desc"},
+		{2, "spice", "https://example.com/page/2/?s=spice&orderby=date&order=desc"},
+	}
+	for _, tc := range cases {
+		if got := archiveURL("https://example.com/", tc.page, tc.search); got != tc.want {
+			t.Fatalf("archiveURL(%d, %q) = %q, want %q", tc.page, tc.search, got, tc.want)
+		}
+	}
+}
+
+func TestFetchHTMLCustomSelectorsAndNextLink(t *testing.T) {
+	var requested []string
+	var mu sync.Mutex
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested = append(requested, r.URL.Path)
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/", "":
+			fmt.Fprint(w, `
+				<div class='post'><h3><a href='/alpha-volume-1-epub/'>Alpha Volume 1 EPUB</a></h3></div>
+				<a class='older' href='/archive/older/'>Older</a>
+			`)
+		case "/archive/older/":
+			fmt.Fprint(w, `<div class='post'></div>`)
+		case "/alpha-volume-1-epub/":
+			fmt.Fprint(w, `
+				<span class='posted' data-x='1'>2025-10-12T08:00:00Z</span>
+				<span class='cats'><a href='/c/ln/'>Light Novels</a></span>
+				<span class='tags'><a href='/t/epub/'>EPUB</a></span>
+			`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := httpx.NewClient(1*time.Millisecond, 5*time.Millisecond,
+		httpx.WithHTTPClient(server.Client()),
+		httpx.WithJitterFactor(0),
+	)
+
+	cutoff := time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC)
+	opt := Options{
+		BaseURL:     server.URL,
+		MaxPages:    5,
+		Concurrency: 1,
+		Client:      client,
+		Selectors: Selectors{
+			Article:   "div.post",
+			TitleLink: "h3 > a",
+			Date:      "span.posted",
+			Category:  ".cats a",
+			Tag:       ".tags a",
+			NextPage:  "a.older",
+		},
+	}
+
+	posts, _, err := FetchHTML(context.Background(), cutoff, opt)
+	if err != nil {
+		t.Fatalf("FetchHTML() error: %v", err)
+	}
+	if len(posts) != 1 {
+		t.Fatalf("expected 1 post, got %d", len(posts))
+	}
+	post := posts[0]
+	if post.Title != "Alpha" || post.Date.Day() != 12 || post.Type != model.TypeEPUB {
+		t.Fatalf("unexpected post: %+v", post)
+	}
+	if len(post.Categories) != 1 || post.Categories[0] != "Light Novels" {
+		t.Fatalf("unexpected categories: %v", post.Categories)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !slices.Contains(requested, "/archive/older/") || slices.Contains(requested, "/page/2/") {
+		t.Fatalf("expected next link to be followed, got %v", requested)
+	}
+}
+
+func TestExtractPublishedDateSources(t *testing.T) {
+	cases := []struct {
+		name   string
+		page   string
+		want   string
+		source model.DateSource
+	}{
+		{"time element", `<time datetime="2025-10-15T00:00:00Z">15 Oct</time>`, "2025-10-15", model.DateSourceSelector},
+		{"published meta", `<meta property="article:published_time" content="2025-10-14T08:00:00+00:00">`, "2025-10-14", model.DateSourceSelector},
+		{"json-ld graph", `<script type="application/ld+json">{"@graph":[
+			{"@type":"WebPage","datePublished":"2020-01-01T00:00:00Z"},
+			{"@type":"BlogPosting","datePublished":"2025-10-13T00:00:00Z","dateModified":"2025-10-20T00:00:00Z"}]}</script>`,
+			"2025-10-13", model.DateSourceJSONLD},
+		{"microdata", `<span itemprop="datePublished" content="2025-10-12">Oct 12</span>`, "2025-10-12", model.DateSourceMicrodata},
+		{"localized text", `<article><div class="entry-meta">Publié le 11 octobre 2025</div><div class="entry-content"><p>Texte</p></div></article>`, "2025-10-11", model.DateSourceText},
+		{"json-ld modified", `<script type="application/ld+json">{"@type":"Article","dateModified":"2025-10-10T00:00:00Z"}</script>`, "2025-10-10", model.DateSourceModified},
+		{"og updated", `<meta property="og:updated_time" content="2025-10-09T00:00:00+00:00">`, "2025-10-09", model.DateSourceModified},
+	}
+
+	sel, err := Selectors{}.compile()
+	if err != nil {
+		t.Fatalf("compile default selectors: %v", err)
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := html.Parse(strings.NewReader("<html><head></head><body>" + tc.page + "</body></html>"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, source, err := extractPublishedDate(doc, sel)
+			if err != nil {
+				t.Fatalf("extractPublishedDate() error: %v", err)
+			}
+			if got.UTC().Format("2006-01-02") != tc.want || source != tc.source {
+				t.Fatalf("extractPublishedDate() = %s (%s), want %s (%s)", got.UTC().Format("2006-01-02"), source, tc.want, tc.source)
+			}
+		})
+	}
+}
+
+func TestExtractPublishedDateIgnoresTextOutsidePost(t *testing.T) {
+	sel, err := Selectors{}.compile()
+	if err != nil {
+		t.Fatalf("compile default selectors: %v", err)
+	}
+	for name, page := range map[string]string{
+		"sidebar": `<article><div class="entry-content"><p>You may 2 volumes now.</p></div></article>
+			<aside><h3>Recent</h3><p>October 3, 2024</p></aside>`,
+		"related posts": `<article><div class="entry-content"><p>No date here.</p></div></article>
+			<section class="related"><article><p>Posted May 5, 2023</p></article></section>`,
+	} {
+		doc, err := html.Parse(strings.NewReader("<html><head></head><body>" + page + "</body></html>"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, source, err := extractPublishedDate(doc, sel); err == nil {
+			t.Fatalf("%s: extractPublishedDate() = %s (%s), want no date", name, got, source)
+		}
+	}
+}
+
+func TestExtractModifiedDate(t *testing.T) {
+	cases := []struct {
+		name string
+		page string
+		want string
+	}{
+		{"article meta", `<meta property="article:modified_time" content="2025-10-21T06:00:00+00:00">`, "2025-10-21"},
+		{"json-ld", `<script type="application/ld+json">{"@type":"BlogPosting","datePublished":"2025-10-01T00:00:00Z","dateModified":"2025-10-22T00:00:00Z"}</script>`, "2025-10-22"},
+		{"microdata", `<meta itemprop="dateModified" content="2025-10-23">`, "2025-10-23"},
+		{"none", `<time datetime="2025-10-01T00:00:00Z">1 Oct</time>`, ""},
+	}
+	for _, tc := range cases {
+		doc, err := html.Parse(strings.NewReader("<html><head></head><body>" + tc.page + "</body></html>"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok := extractModifiedDate(doc)
+		if tc.want == "" {
+			if ok {
+				t.Fatalf("%s: unexpected modified date %v", tc.name, got)
+			}
+
+			continue
+		}
+		if !ok || got.UTC().Format("2006-01-02") != tc.want {
+			t.Fatalf("%s: extractModifiedDate() = %v, %v; want %s", tc.name, got, ok, tc.want)
+		}
+	}
+}
+
+func TestFetchHTMLIgnoresStickyPostsForStopping(t *testing.T) {
+	var detailRequests int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		block := func(slug, class string) string {
+			return fmt.Sprintf(`<article class="post %s"><h2 class="entry-title"><a href="/%s/">%s Volume 1 EPUB</a></h2></article>`, class, slug, slug)
+		}
+		switch r.URL.Path {
+		case "/", "":
+			// An old sticky post and one out-of-order post sit on top.
+			fmt.Fprint(w, block("pinned", "sticky")+block("late", "")+block("fresh", ""))
+		case "/page/2/":
+			fmt.Fprint(w, block("second", "")+block("old-a", "")+block("old-b", ""))
+		case "/page/3/":
+			fmt.Fprint(w, block("old-c", ""))
+		case "/pinned/", "/late/", "/old-a/", "/old-b/":
+			atomic.AddInt32(&detailRequests, 1)
+			fmt.Fprint(w, `<time datetime="2025-09-01T00:00:00Z"></time>`)
+		case "/old-c/":
+			// Page 3 may be fetched ahead, but the crawl ends before it.
+			fmt.Fprint(w, `<time datetime="2025-09-01T00:00:00Z"></time>`)
+		case "/fresh/", "/second/":
+			atomic.AddInt32(&detailRequests, 1)
+			fmt.Fprint(w, `<time datetime="2025-10-15T00:00:00Z"></time>`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := httpx.NewClient(1*time.Millisecond, 5*time.Millisecond,
+		httpx.WithHTTPClient(server.Client()),
+		httpx.WithJitterFactor(0),
+	)
+
+	cutoff := time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC)
+	opt := Options{BaseURL: server.URL, Concurrency: 2, Client: client, StopAfter: 2}
+
+	posts, _, err := FetchHTML(context.Background(), cutoff, opt)
+	if err != nil {
+		t.Fatalf("FetchHTML() error: %v", err)
+	}
+	if len(posts) != 2 {
+		t.Fatalf("expected fresh and second posts, got %+v", posts)
+	}
+	// Every post of pages 1 and 2 is needed: page 2 ends with two older
+	// posts.
+	if got := atomic.LoadInt32(&detailRequests); got != 6 {
+		t.Fatalf("expected 6 detail requests, got %d", got)
+	}
+}
+
+func TestFetchHTMLReadsArchiveBlocksWithoutDetail(t *testing.T) {
+	var detailRequests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/", "":
+			fmt.Fprint(w, `<html><body>
+				<article id="post-11">
+					<h2 class="entry-title"><a href="/complete-volume-1/">Complete Volume 1</a></h2>
+					<time class="entry-date published" datetime="2025-10-15T00:00:00+00:00">October 15, 2025</time>
+					<a href="/category/epub/" rel="category tag">EPUB</a>
+				</article>
+				<article id="post-12">
+					<h2 class="entry-title"><a href="/untyped-volume-2/">Untyped Volume 2</a></h2>
+					<time datetime="2025-10-14T00:00:00+00:00">October 14, 2025</time>
+				</article>
+			</body></html>`)
+		case "/untyped-volume-2/":
+			detailRequests.Add(1)
+			fmt.Fprint(w, `<html><body><time datetime="2025-10-14T00:00:00Z"></time><a rel="tag">PDF</a></body></html>`)
+		case "/complete-volume-1/":
+			detailRequests.Add(1)
+			fmt.Fprint(w, `<html><body><time datetime="2025-10-15T00:00:00Z"></time><a rel="category">EPUB</a></body></html>`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := httpx.NewClient(1*time.Millisecond, 5*time.Millisecond,
+		httpx.WithHTTPClient(server.Client()),
+		httpx.WithJitterFactor(0),
+	)
+	cutoff := time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC)
+	opt := Options{BaseURL: server.URL, MaxPages: 1, Concurrency: 1, Client: client}
+
+	posts, _, err := FetchHTML(context.Background(), cutoff, opt)
+	if err != nil {
+		t.Fatalf("FetchHTML() error: %v", err)
+	}
+	if len(posts) != 2 {
+		t.Fatalf("expected 2 posts, got %+v", posts)
+	}
+	if detailRequests.Load() != 1 {
+		t.Fatalf("expected only the untyped post to load its detail page, got %d requests", detailRequests.Load())
+	}
+	complete := posts[0]
+	if complete.Type != model.TypeEPUB || complete.DateSource != model.DateSourceArchive || complete.SourceID != 11 {
+		t.Fatalf("unexpected archive-only post: %+v", complete)
+	}
+	if posts[1].Type != model.TypePDF || posts[1].DateSource != model.DateSourceSelector {
+		t.Fatalf("unexpected detail post: %+v", posts[1])
+	}
+
+	detailRequests.Store(0)
+	opt.ForceDetail = true
+	if _, _, err := FetchHTML(context.Background(), cutoff, opt); err != nil {
+		t.Fatalf("FetchHTML() error: %v", err)
+	}
+	if detailRequests.Load() != 2 {
+		t.Fatalf("ForceDetail should load every detail page, got %d requests", detailRequests.Load())
+	}
+}
+
+func TestFetchHTMLFetchesNextArchivePageWhileDetailsLoad(t *testing.T) {
+	page2Requested := make(chan struct{})
+	var once sync.Once
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		block := func(slug string) string {
+			return fmt.Sprintf(`<article><h2 class="entry-title"><a href="/%s/">%s Volume 1 EPUB</a></h2></article>`, slug, slug)
+		}
+		switch r.URL.Path {
+		case "/", "":
+			fmt.Fprint(w, block("slow")+block("fast"))
+		case "/page/2/":
+			once.Do(func() { close(page2Requested) })
+			fmt.Fprint(w, block("older"))
+		case "/slow/":
+			// Held until the producer has moved on to page 2.
+			select {
+			case <-page2Requested:
+			case <-time.After(2 * time.Second):
+				t.Errorf("page 2 was not requested while page 1 details were loading")
+			}
+			fmt.Fprint(w, `<time datetime="2025-10-15T00:00:00Z"></time>`)
+		case "/fast/":
+			fmt.Fprint(w, `<time datetime="2025-10-14T00:00:00Z"></time>`)
+		case "/older/":
+			fmt.Fprint(w, `<time datetime="2025-09-01T00:00:00Z"></time>`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := httpx.NewClient(1*time.Millisecond, 5*time.Millisecond,
+		httpx.WithHTTPClient(server.Client()),
+		httpx.WithJitterFactor(0),
+	)
+	cutoff := time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC)
+	opt := Options{BaseURL: server.URL, Concurrency: 2, Client: client, StopAfter: 1}
+
+	posts, warnings, err := FetchHTML(context.Background(), cutoff, opt)
+	if err != nil {
+		t.Fatalf("FetchHTML() error: %v", err)
+	}
+	if len(posts) != 2 || posts[0].Title != "slow" || posts[1].Title != "fast" {
+		t.Fatalf("unexpected posts: %+v", posts)
+	}
+	if len(warnings) != 1 || warnings[0].Code != WarningBeforeCutoff || !strings.HasSuffix(warnings[0].Link, "/older/") {
+		t.Fatalf("expected the older post to be skipped, got %v", warnings)
+	}
+}
+
+func TestFetchHTMLKeepsPostsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/", "":
+			fmt.Fprint(w, `<html><body>
+				<article><h2 class="entry-title"><a href="/kept-volume-1-epub/">Kept Volume 1 EPUB</a></h2></article>
+				<article><h2 class="entry-title"><a href="/cut-volume-1-epub/">Cut Volume 1 EPUB</a></h2></article>
+			</body></html>`)
+		case "/kept-volume-1-epub/":
+			fmt.Fprint(w, `<time datetime="2025-10-15T00:00:00Z"></time>`)
+		case "/cut-volume-1-epub/":
+			cancel()
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := httpx.NewClient(1*time.Millisecond, 5*time.Millisecond,
+		httpx.WithHTTPClient(server.Client()),
+		httpx.WithJitterFactor(0),
+	)
+	cutoff := time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC)
+	failures := &Failures{}
+	opt := Options{BaseURL: server.URL, Concurrency: 1, Client: client, Failures: failures}
+
+	posts, _, err := FetchHTML(ctx, cutoff, opt)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected a cancellation error, got %v", err)
+	}
+	if len(posts) != 1 || posts[0].Title != "Kept" {
+		t.Fatalf("expected the post fetched before cancellation, got %+v", posts)
+	}
+	items := failures.Items()
+	if len(items) != 1 || !strings.HasSuffix(items[0].URL, "/cut-volume-1-epub/") {
+		t.Fatalf("expected the interrupted detail page in failures, got %+v", items)
+	}
+}
+
+func TestFetchHTMLReportsEvents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/", "":
+			fmt.Fprint(w, `<html><body>
+				<article><h2 class="entry-title"><a href="/archive-volume-1-epub/">Archive Volume 1 EPUB</a></h2><time datetime="2025-10-15T00:00:00Z"></time></article>
+				<article><h2 class="entry-title"><a href="/detail-volume-2-epub/">Detail Volume 2 EPUB</a></h2></article>
+				<article><h2 class="entry-title"><a href="/old-volume-3-epub/">Old Volume 3 EPUB</a></h2><time datetime="2025-09-15T00:00:00Z"></time></article>
+				<article><h2 class="entry-title"><a href="/broken-volume-4-epub/">Broken Volume 4 EPUB</a></h2></article>
+			</body></html>`)
+		case "/detail-volume-2-epub/":
+			fmt.Fprint(w, `<html><body><time datetime="2025-10-12T00:00:00Z"></time></body></html>`)
+		case "/broken-volume-4-epub/":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := httpx.NewClient(1*time.Millisecond, 5*time.Millisecond,
+		httpx.WithHTTPClient(server.Client()),
+		httpx.WithJitterFactor(0),
+	)
+
+	var (
+		mu     sync.Mutex
+		events []event.Event
+	)
+	observer := event.Func(func(e event.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e)
+	})
+	cutoff := time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC)
+	opt := Options{BaseURL: server.URL, MaxPages: 1, Concurrency: 2, Client: client, Observer: observer, Failures: &Failures{}}
+
+	if _, _, err := FetchHTML(context.Background(), cutoff, opt); err != nil {
+		t.Fatalf("FetchHTML() error: %v", err)
+	}
+
+	var pages, parsed, retries, started, finished int
+	skipped := make(map[string]string)
+	for _, e := range events {
+		switch e := e.(type) {
+		case event.PageFetched:
+			pages++
+			if e.Source != event.SourceHTML || e.Posts != 4 {
+				t.Fatalf("unexpected page event: %+v", e)
+			}
+		case event.PostParsed:
+			parsed++
+		case event.PostSkipped:
+			skipped[e.URL] = e.Reason
+		case event.DetailStarted:
+			started++
+		case event.DetailFinished:
+			finished++
+		case event.RetryScheduled:
+			retries++
+			if !e.SecondPass || !strings.HasSuffix(e.URL, "/broken-volume-4-epub/") {
+				t.Fatalf("unexpected retry event: %+v", e)
+			}
+		}
+	}
+	if pages != 1 || parsed != 3 || retries != 1 {
+		t.Fatalf("expected 1 page, 3 parsed posts and 1 retry, got %d, %d and %d", pages, parsed, retries)
+	}
+	// Two detail pages, the broken one loaded again by the second pass.
+	if started != 3 || finished != 3 {
+		t.Fatalf("expected 3 detail fetches started and finished, got %d and %d", started, finished)
+	}
+	if skipped[server.URL+"/old-volume-3-epub/"] != "date before cutoff" {
+		t.Fatalf("expected the old post to be skipped for its date, got %v", skipped)
+	}
+	if _, ok := skipped[server.URL+"/broken-volume-4-epub/"]; !ok || len(skipped) != 2 {
+		t.Fatalf("expected the broken post to be skipped after the second pass, got %v", skipped)
+	}
+}
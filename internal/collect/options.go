@@ -3,17 +3,35 @@ package collect
 import (
 	"time"
 
+	"git.skobk.in/skobkin/jnovel-scrape/internal/event"
 	"git.skobk.in/skobkin/jnovel-scrape/internal/httpx"
 )
 
-// Logger is the minimal logging interface expected by collectors.
+// Logger is the minimal logging interface expected by collectors. Args
+// are key-value attribute pairs as in log/slog; *slog.Logger satisfies
+// it.
 type Logger interface {
-	Infof(format string, args ...any)
+	Info(msg string, args ...any)
 }
 
 type noopLogger struct{}
 
-func (noopLogger) Infof(string, ...any) {}
+func (noopLogger) Info(string, ...any) {}
+
+// APIStrategy selects how much data each posts request asks for.
+type APIStrategy string
+
+const (
+	// APIStrategyFull requests complete post objects.
+	APIStrategyFull APIStrategy = "full"
+	// APIStrategyFields trims posts with _fields to what the collector
+	// decodes; taxonomy names are still looked up separately.
+	APIStrategyFields APIStrategy = "fields"
+	// APIStrategyEmbed additionally asks for _embed=wp:term so category
+	// and tag names arrive inline. Posts whose embeds were stripped by
+	// the site fall back to taxonomy lookups automatically.
+	APIStrategyEmbed APIStrategy = "embed"
+)
 
 // Options controls collector behavior shared by API and HTML modes.
 type Options struct {
@@ -24,6 +42,70 @@ type Options struct {
 	Logger      Logger
 	Client      *httpx.Client
 	ReqInterval time.Duration
+	// Search lists needles pushed to the site search instead of
+	// crawling the whole archive. Result sets of every needle are
+	// merged; callers must still apply their exact title match.
+	Search []string
+	// APIStrategy trims posts requests; the zero value behaves like
+	// APIStrategyFull.
+	APIStrategy APIStrategy
+	// FetchCovers asks API mode to resolve featured media into cover
+	// URLs, which costs extra payload or requests. HTML mode reads
+	// og:image from the detail page it already loads.
+	FetchCovers bool
+	// FetchMetadata asks API mode for post bodies so book metadata
+	// (author, illustrator, publisher, synopsis) can be extracted.
+	// HTML mode always parses the detail page it already loads.
+	FetchMetadata bool
+	// Selectors drive HTML-mode extraction; empty fields use
+	// DefaultSelectors.
+	Selectors Selectors
+	// ForceDetail makes HTML mode load every detail page, even when the
+	// archive block already carries the date and enough taxonomy.
+	ForceDetail bool
+	// StopAfter is how many consecutive non-sticky posts older than
+	// the cutoff end the crawl; zero means DefaultStopAfter.
+	StopAfter int
+	// Resume, when set, makes FetchHTML continue a failed crawl
+	// instead of starting from the front archive.
+	Resume *Resume
+	// RetryBackoff is how long to wait before the second pass over
+	// failed API pages and detail pages; zero retries immediately.
+	RetryBackoff time.Duration
+	// Failures, when set, receives the fetches that still failed after
+	// the second pass so they can be re-attempted later.
+	Failures *Failures
+	// Checkpoints, when set, resumes a crawl of the full listing from
+	// the progress it holds and records new progress as pages finish.
+	Checkpoints *Checkpointer
+	// Observer, when set, receives events about pages, posts, retries
+	// and fallbacks as the crawl goes. Pass the same observer to the
+	// client with httpx.WithObserver to see its retries and rate-limit
+	// waits too.
+	Observer event.Observer
+
+	selectors *compiledSelectors
+	retries   *retryQueue
+	sink      *postSink
+}
+
+func (o Options) logger() Logger {
+	if o.Logger == nil {
+		return noopLogger{}
+	}
+
+	return o.Logger
+}
+
+func (o Options) observe(e event.Event) {
+	if o.Observer != nil {
+		o.Observer.Observe(e)
+	}
+}
+
+// observeSecondPass reports a failed fetch queued for the second pass.
+func (o Options) observeSecondPass(rawURL string, err error) {
+	o.observe(event.RetryScheduled{URL: rawURL, Attempt: 1, Wait: o.RetryBackoff, Err: err, SecondPass: true})
 }
 
 // DefaultBaseURL for jnovels.
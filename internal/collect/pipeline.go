@@ -0,0 +1,266 @@
+package collect
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"git.skobk.in/skobkin/jnovel-scrape/internal/event"
+	"git.skobk.in/skobkin/jnovel-scrape/internal/model"
+)
+
+// archiveLookahead is how many archive pages the producer may have
+// fetched ahead of the page the stop rule is looking at.
+const archiveLookahead = 2
+
+// archivePage is one archive page travelling through the crawl pipeline.
+// results is filled by the detail workers; pending counts the detail
+// fetches still running for it.
+type archivePage struct {
+	number     int
+	next       string
+	candidates []archiveCandidate
+	direct     []archiveCandidate
+	detail     []archiveCandidate
+	results    []detailResult
+	pending    sync.WaitGroup
+	err        error
+}
+
+type pageJob struct {
+	page  *archivePage
+	index int
+}
+
+// crawlArchive walks the paged archive rooted at base (or search results
+// when search is non-empty) until the stop rule sees enough consecutive
+// posts older than the cutoff, which it reports as reachedCutoff, or the
+// archive runs out. Candidates found in known are not fetched again but
+// their dates still feed the stop rule. On error the posts collected so
+// far are returned with it.
+//
+// The crawl is a pipeline: a producer fetches archive pages up to
+// archiveLookahead pages ahead and queues their detail pages on a queue
+// shared by opt.Concurrency workers, so the next archive page loads while
+// the details of the current one are still in flight. Pages are still
+// judged by the stop rule one at a time and in archive order; once it
+// stops, the producer and the fetches it ran ahead with are cancelled.
+//
+// A crawl of the full archive (no search, no known posts) records its
+// progress in opt.Checkpoints after every page and starts after the
+// last recorded page. With opt.sink set, each page's posts are streamed
+// as soon as the page is judged instead of being returned.
+func crawlArchive(ctx context.Context, cutoff time.Time, opt Options, base, search string, known *knownPosts) (model.Posts, []Warning, bool, error) {
+	var (
+		allPosts model.Posts
+		warnings []Warning
+	)
+	rule := newStopRule(cutoff, opt.StopAfter)
+	checkpoints := opt.Checkpoints
+	if search != "" || known != nil {
+		checkpoints = nil
+	}
+	startURL, startPage := archiveURL(base, 1, search), 1
+	if saved := checkpoints.htmlProgress(); saved != nil && saved.Page > 0 {
+		opt.logger().Info("HTML resuming from checkpoint", "page", saved.Page, "posts", len(saved.Posts))
+		startURL, startPage = saved.NextURL, saved.Page+1
+		allPosts = saved.Posts
+		rule.streak = saved.Streak
+		for _, item := range saved.Pending {
+			opt.retries.push(archiveCandidate{Title: item.Title, Link: item.URL, ID: item.ID})
+		}
+	}
+
+	pipeCtx, cancel := context.WithCancel(ctx)
+	pages := make(chan *archivePage, archiveLookahead)
+	jobs := make(chan pageJob, 2*opt.Concurrency)
+
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		produceArchivePages(pipeCtx, opt, base, search, known, startURL, startPage, pages, jobs)
+	}()
+	for i := 0; i < opt.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				result := fetchDetail(pipeCtx, opt, job.page.detail[job.index])
+				result.index = job.index
+				job.page.results[job.index] = result
+				job.page.pending.Done()
+			}
+		}()
+	}
+
+	for page := range pages {
+		if page.err != nil {
+			return allPosts, warnings, false, page.err
+		}
+		page.pending.Wait()
+
+		var pagePosts model.Posts
+		for i, candidate := range page.detail {
+			post, postWarnings := settleDetail(opt, candidate, page.results[i])
+			warnings = append(warnings, postWarnings...)
+			if post != nil {
+				pagePosts = append(pagePosts, *post)
+			}
+		}
+		for _, candidate := range page.direct {
+			post, postWarnings := buildHTMLPost(candidate, candidate.Date, model.DateSourceArchive, candidate.Categories, candidate.Tags)
+			opt.observe(event.PostParsed{Source: event.SourceHTML, Post: post})
+			pagePosts = append(pagePosts, post)
+			warnings = append(warnings, postWarnings...)
+		}
+		byLink := make(map[string]model.Post, len(pagePosts))
+		for _, post := range pagePosts {
+			byLink[post.Link] = post
+		}
+
+		// Replay the page in archive order so the stop rule sees dates
+		// the way the site lists them.
+		dated := 0
+		stop := false
+		for _, candidate := range page.candidates {
+			if date, ok := known.lookup(candidate); ok {
+				dated++
+				stop = rule.observe(date, candidate.Sticky) || stop
+
+				continue
+			}
+			post, ok := byLink[candidate.Link]
+			if !ok {
+				continue
+			}
+			dated++
+			stop = rule.observe(post.Date, candidate.Sticky) || stop
+			if post.Date.Before(cutoff) {
+				warnings = append(warnings, warnf(WarningBeforeCutoff, post.Link, "skipped (date %s before cutoff)", post.FormatDate()))
+				opt.observe(event.PostSkipped{Source: event.SourceHTML, URL: post.Link, Reason: "date before cutoff"})
+
+				continue
+			}
+			if opt.sink != nil {
+				if candidate.Sticky {
+					opt.sink.pin(post)
+				} else {
+					opt.sink.emit(post)
+				}
+
+				continue
+			}
+			allPosts = append(allPosts, post)
+		}
+
+		if stop {
+			return allPosts, warnings, true, nil
+		}
+		if err := ctx.Err(); err != nil {
+			// Cancelled: keep what the page yielded, but it is not
+			// finished and must not be checkpointed.
+			return allPosts, warnings, false, err
+		}
+		if dated == 0 {
+			// Every detail fetch failed; paging on would only repeat
+			// it. The second pass retries them after the crawl.
+			break
+		}
+		checkpoints.updateHTML(func(cp *HTMLCheckpoint) {
+			cp.Page = page.number
+			cp.NextURL = page.next
+			cp.Streak = rule.streak
+			// allPosts only grows, so the clipped slice stays valid.
+			cp.Posts = slices.Clip(allPosts)
+			cp.Pending = opt.retries.snapshot()
+		})
+	}
+
+	return allPosts, warnings, false, nil
+}
+
+// produceArchivePages fetches archive pages in order, starting with page
+// number startPage at startURL, queues their detail fetches on jobs and
+// hands each page to the consumer on pages. A page that cannot be
+// fetched after a second try is reported through opt.Failures and sent
+// with its error, which ends the crawl. Both channels are closed on
+// return.
+func produceArchivePages(ctx context.Context, opt Options, base, search string, known *knownPosts, startURL string, startPage int, pages chan<- *archivePage, jobs chan<- pageJob) {
+	defer close(pages)
+	defer close(jobs)
+
+	pageURL := startURL
+	for number := startPage; number <= opt.MaxPages; number++ {
+		doc, err := fetchArchivePage(ctx, opt, pageURL, number > 1 || known != nil)
+		if err != nil && ctx.Err() == nil {
+			opt.logger().Info("HTML archive page failed; retrying", "page", number, "url", pageURL, "error", err, "wait", opt.RetryBackoff)
+			opt.observeSecondPass(pageURL, err)
+			if waitErr := waitRetryBackoff(ctx, opt); waitErr == nil {
+				doc, err = fetchArchivePage(ctx, opt, pageURL, number > 1 || known != nil)
+			}
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				opt.Failures.add(FailedFetch{Kind: FailureHTMLPage, URL: pageURL, Error: err.Error()})
+			}
+			select {
+			case pages <- &archivePage{number: number, err: err}:
+			case <-ctx.Done():
+			}
+
+			return
+		}
+		if doc == nil {
+			return
+		}
+
+		candidates := extractArchiveCandidates(doc, opt.selectors, pageURL)
+		opt.logger().Info("HTML page fetched", "page", number, "candidates", len(candidates), "url", pageURL)
+		opt.observe(event.PageFetched{Source: event.SourceHTML, Page: number, URL: pageURL, Posts: len(candidates)})
+		if len(candidates) == 0 {
+			return
+		}
+
+		page := &archivePage{number: number, candidates: candidates}
+		page.next = nextArchiveURL(doc, opt, base, pageURL, number+1, search)
+		for _, candidate := range candidates {
+			if _, ok := known.lookup(candidate); ok {
+				continue
+			}
+			if candidate.needsDetail(opt) {
+				page.detail = append(page.detail, candidate)
+			} else {
+				page.direct = append(page.direct, candidate)
+			}
+		}
+		if len(page.direct) > 0 {
+			opt.logger().Info("HTML page read", "page", number, "from_archive", len(page.direct), "need_detail", len(page.detail))
+		}
+
+		page.results = make([]detailResult, len(page.detail))
+		page.pending.Add(len(page.detail))
+		for i := range page.detail {
+			select {
+			case jobs <- pageJob{page: page, index: i}:
+			case <-ctx.Done():
+				page.pending.Add(i - len(page.detail))
+
+				return
+			}
+		}
+		select {
+		case pages <- page:
+		case <-ctx.Done():
+			return
+		}
+
+		pageURL = page.next
+	}
+}
@@ -0,0 +1,148 @@
+package collect
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"git.skobk.in/skobkin/jnovel-scrape/internal/model"
+)
+
+// FetchAPIByID re-reads known posts by ID with include= batches of 100.
+// IDs absent from the result were deleted, unpublished or made private
+// since they were collected; callers detect them by comparing ID sets.
+func FetchAPIByID(ctx context.Context, ids []int64, opt Options) (model.Posts, []Warning, error) {
+	opt, err := apiDefaults(opt)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil, nil
+	}
+	logger := opt.logger()
+	logger.Info("API refresh: re-reading posts by ID", "posts", len(ids))
+
+	endpoint, err := url.JoinPath(opt.BaseURL, "/wp-json/wp/v2/posts")
+	if err != nil {
+		return nil, nil, fmt.Errorf("build posts endpoint: %w", err)
+	}
+	batchIDs := make([]int, len(ids))
+	for i, id := range ids {
+		batchIDs[i] = int(id)
+	}
+
+	fetch := func() ([]apiPost, error) {
+		var (
+			mu       sync.Mutex
+			rawPosts []apiPost
+		)
+		err := forEachBatch(ctx, opt, batchIDs, func(ctx context.Context, batch []int) error {
+			reqURL, err := url.Parse(endpoint)
+			if err != nil {
+				return err
+			}
+			query := reqURL.Query()
+			query.Set("per_page", strconv.Itoa(len(batch)))
+			query.Set("include", joinInts(batch))
+			applyAPIStrategy(query, opt)
+			reqURL.RawQuery = query.Encode()
+
+			posts, _, err := getAPIPosts(ctx, opt, reqURL)
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			rawPosts = append(rawPosts, posts...)
+
+			return nil
+		})
+
+		return rawPosts, err
+	}
+
+	rawPosts, err := fetch()
+	if err != nil && opt.APIStrategy != APIStrategyFull && isStatus(err, http.StatusBadRequest) {
+		logger.Info("API rejected trimmed request; retrying with full post objects", "error", err)
+		opt.APIStrategy = APIStrategyFull
+		rawPosts, err = fetch()
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	logger.Info("API refresh: posts still published", "published", len(rawPosts), "posts", len(ids))
+	if len(rawPosts) == 0 {
+		return nil, nil, nil
+	}
+
+	return buildAPIPosts(ctx, opt, time.Time{}, rawPosts)
+}
+
+// FetchAPIModifiedSince lists posts edited after since, newest edit
+// first, using the modified_after filter (WordPress 5.7+); a zero since
+// lists every post. Deleted posts
+// do not show up here; only FetchAPIByID can tell they are gone.
+func FetchAPIModifiedSince(ctx context.Context, since time.Time, opt Options) (model.Posts, []Warning, error) {
+	opt, err := apiDefaults(opt)
+	if err != nil {
+		return nil, nil, err
+	}
+	logger := opt.logger()
+	logger.Info("API refresh: listing modified posts", "since", since.Format(time.RFC3339))
+
+	endpoint, err := url.JoinPath(opt.BaseURL, "/wp-json/wp/v2/posts")
+	if err != nil {
+		return nil, nil, fmt.Errorf("build posts endpoint: %w", err)
+	}
+
+	fetch := func() ([]apiPost, error) {
+		var rawPosts []apiPost
+		for page := 1; page <= opt.MaxPages; page++ {
+			reqURL, err := url.Parse(endpoint)
+			if err != nil {
+				return nil, err
+			}
+			query := reqURL.Query()
+			query.Set("per_page", "100")
+			query.Set("page", strconv.Itoa(page))
+			query.Set("order", "desc")
+			query.Set("orderby", "modified")
+			if !since.IsZero() {
+				query.Set("modified_after", since.UTC().Format(time.RFC3339))
+			}
+			applyAPIStrategy(query, opt)
+			reqURL.RawQuery = query.Encode()
+
+			posts, totalPages, err := getAPIPosts(ctx, opt, reqURL)
+			if err != nil {
+				return nil, err
+			}
+			logger.Info("API page of modified posts fetched", "page", page, "posts", len(posts))
+			rawPosts = append(rawPosts, posts...)
+			if len(posts) == 0 || (totalPages > 0 && page >= totalPages) {
+				break
+			}
+		}
+
+		return rawPosts, nil
+	}
+
+	rawPosts, err := fetch()
+	if err != nil && opt.APIStrategy != APIStrategyFull && isStatus(err, http.StatusBadRequest) {
+		logger.Info("API rejected trimmed request; retrying with full post objects", "error", err)
+		opt.APIStrategy = APIStrategyFull
+		rawPosts, err = fetch()
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(rawPosts) == 0 {
+		return nil, nil, nil
+	}
+
+	return buildAPIPosts(ctx, opt, time.Time{}, rawPosts)
+}
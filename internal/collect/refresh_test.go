@@ -0,0 +1,102 @@
+//nolint:gosec
+package collect
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"git.skobk.in/skobkin/jnovel-scrape/internal/httpx"
+)
+
+func TestFetchAPIByIDReturnsSurvivingPosts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/wp-json/wp/v2/posts" {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+		if got := r.URL.Query().Get("include"); got != "11,12" {
+			t.Errorf("include = %q, want 11,12", got)
+		}
+		if !strings.Contains(r.URL.Query().Get("_fields"), "modified_gmt") {
+			t.Errorf("modified_gmt not requested: %s", r.URL.RawQuery)
+		}
+		// Post 12 was deleted and is simply missing from the response.
+		posts := []apiPost{{
+			ID:          11,
+			Date:        "2024-03-01T00:00:00",
+			DateGMT:     "2024-03-01T00:00:00",
+			Modified:    "2025-10-20T08:30:00",
+			ModifiedGMT: "2025-10-20T08:30:00",
+			Link:        "https://example.com/fixed-volume-3-epub/",
+			Title:       rendered{Text: "Fixed Volume 3 EPUB"},
+		}}
+		json.NewEncoder(w).Encode(posts)
+	}))
+	defer server.Close()
+
+	client := httpx.NewClient(1*time.Millisecond, 5*time.Millisecond,
+		httpx.WithHTTPClient(server.Client()),
+		httpx.WithJitterFactor(0),
+	)
+	opt := Options{BaseURL: server.URL, Client: client, APIStrategy: APIStrategyFields}
+
+	posts, _, err := FetchAPIByID(context.Background(), []int64{11, 12}, opt)
+	if err != nil {
+		t.Fatalf("FetchAPIByID() error: %v", err)
+	}
+	if len(posts) != 1 {
+		t.Fatalf("expected 1 post, got %d", len(posts))
+	}
+	post := posts[0]
+	if post.SourceID != 11 || post.Volume == nil || *post.Volume != 3 {
+		t.Fatalf("unexpected post: %+v", post)
+	}
+	if want := time.Date(2025, time.October, 20, 8, 30, 0, 0, time.UTC); !post.Modified.Equal(want) {
+		t.Fatalf("Modified = %v, want %v", post.Modified, want)
+	}
+}
+
+func TestFetchAPIModifiedSince(t *testing.T) {
+	since := time.Date(2025, time.October, 1, 12, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if got := query.Get("modified_after"); got != "2025-10-01T12:00:00Z" {
+			t.Errorf("modified_after = %q", got)
+		}
+		if query.Get("orderby") != "modified" {
+			t.Errorf("orderby = %q, want modified", query.Get("orderby"))
+		}
+		w.Header().Set("X-WP-TotalPages", "1")
+		posts := []apiPost{{
+			ID:          21,
+			Date:        "2023-01-01T00:00:00",
+			DateGMT:     "2023-01-01T00:00:00",
+			ModifiedGMT: "2025-10-02T00:00:00",
+			Link:        "https://example.com/old-post-volume-1-pdf/",
+			Title:       rendered{Text: "Old Post Volume 1 PDF"},
+		}}
+		json.NewEncoder(w).Encode(posts)
+	}))
+	defer server.Close()
+
+	client := httpx.NewClient(1*time.Millisecond, 5*time.Millisecond,
+		httpx.WithHTTPClient(server.Client()),
+		httpx.WithJitterFactor(0),
+	)
+	opt := Options{BaseURL: server.URL, Client: client}
+
+	posts, _, err := FetchAPIModifiedSince(context.Background(), since, opt)
+	if err != nil {
+		t.Fatalf("FetchAPIModifiedSince() error: %v", err)
+	}
+	// Publish dates far before since are kept: only the edit time matters.
+	if len(posts) != 1 || posts[0].SourceID != 21 {
+		t.Fatalf("unexpected posts: %+v", posts)
+	}
+}
@@ -0,0 +1,87 @@
+package collect
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"git.skobk.in/skobkin/jnovel-scrape/internal/model"
+)
+
+// PartialError reports a crawl that failed after collecting some posts.
+// The collector returns those posts alongside it; they are complete for
+// publish dates after Reached, so another collector only needs to cover
+// [cutoff, Reached].
+type PartialError struct {
+	Err     error
+	Reached time.Time
+}
+
+func (e *PartialError) Error() string {
+	return fmt.Sprintf("%v (results complete back to %s)", e.Err, e.Reached.Format("2006-01-02"))
+}
+
+func (e *PartialError) Unwrap() error {
+	return e.Err
+}
+
+// Resume narrows an HTML crawl to the range a failed run did not reach.
+type Resume struct {
+	// Before is PartialError.Reached of the failed run. The crawl walks
+	// month archives from this month back to the cutoff instead of the
+	// front archive.
+	Before time.Time
+	// Known lists the posts already collected. Their detail pages are
+	// not fetched again and they are not returned a second time.
+	Known model.Posts
+}
+
+// knownPosts indexes Resume.Known by post ID and canonical link.
+type knownPosts struct {
+	byID   map[int64]time.Time
+	byLink map[string]time.Time
+}
+
+func newKnownPosts(posts model.Posts) *knownPosts {
+	k := &knownPosts{
+		byID:   make(map[int64]time.Time, len(posts)),
+		byLink: make(map[string]time.Time, len(posts)),
+	}
+	for _, post := range posts {
+		if post.SourceID != 0 {
+			k.byID[post.SourceID] = post.Date
+		}
+		k.byLink[model.CanonicalLink(post.Link)] = post.Date
+	}
+
+	return k
+}
+
+// lookup returns the publish date of an already collected candidate.
+func (k *knownPosts) lookup(c archiveCandidate) (time.Time, bool) {
+	if k == nil {
+		return time.Time{}, false
+	}
+	if c.ID != 0 {
+		if date, ok := k.byID[c.ID]; ok {
+			return date, true
+		}
+	}
+	date, ok := k.byLink[model.CanonicalLink(c.Link)]
+
+	return date, ok
+}
+
+// monthArchives lists the WordPress month archive roots (/YYYY/MM/) from
+// the month of newest back to the month of oldest.
+func monthArchives(base string, newest, oldest time.Time) []string {
+	var out []string
+	month := time.Date(newest.Year(), newest.Month(), 1, 0, 0, 0, 0, time.UTC)
+	last := time.Date(oldest.Year(), oldest.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for !month.Before(last) {
+		out = append(out, fmt.Sprintf("%s/%04d/%02d/", strings.TrimRight(base, "/"), month.Year(), int(month.Month())))
+		month = month.AddDate(0, -1, 0)
+	}
+
+	return out
+}
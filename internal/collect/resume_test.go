@@ -0,0 +1,105 @@
+package collect
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"git.skobk.in/skobkin/jnovel-scrape/internal/httpx"
+	"git.skobk.in/skobkin/jnovel-scrape/internal/model"
+)
+
+func TestMonthArchives(t *testing.T) {
+	got := monthArchives("https://example.com/",
+		time.Date(2025, time.January, 15, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, time.November, 30, 0, 0, 0, 0, time.UTC))
+	want := []string{
+		"https://example.com/2025/01/",
+		"https://example.com/2024/12/",
+		"https://example.com/2024/11/",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("monthArchives() = %v, want %v", got, want)
+	}
+}
+
+func TestFetchHTMLResumesFromMonthArchives(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		requested []string
+	)
+	article := func(id int, slug, title string) string {
+		return fmt.Sprintf(`<article id="post-%d"><h2 class="entry-title"><a href="/%s/">%s</a></h2></article>`, id, slug, title)
+	}
+	detail := func(date string) string {
+		return fmt.Sprintf(`<html><body><time datetime="%s"></time><a rel="tag">EPUB</a></body></html>`, date)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested = append(requested, r.URL.Path)
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/2025/10":
+			fmt.Fprint(w, article(1, "known-volume-1", "Known Volume 1 EPUB")+article(2, "fresh-volume-2", "Fresh Volume 2 EPUB"))
+		case "/2025/09":
+			fmt.Fprint(w, article(3, "older-volume-3", "Older Volume 3 EPUB")+article(4, "ancient-volume-4", "Ancient Volume 4 EPUB"))
+		case "/fresh-volume-2/":
+			fmt.Fprint(w, detail("2025-10-05T00:00:00Z"))
+		case "/older-volume-3/":
+			fmt.Fprint(w, detail("2025-09-25T00:00:00Z"))
+		case "/ancient-volume-4/":
+			fmt.Fprint(w, detail("2025-09-10T00:00:00Z"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := httpx.NewClient(1*time.Millisecond, 5*time.Millisecond,
+		httpx.WithHTTPClient(server.Client()),
+		httpx.WithJitterFactor(0),
+	)
+
+	cutoff := time.Date(2025, time.September, 20, 0, 0, 0, 0, time.UTC)
+	opt := Options{
+		BaseURL:     server.URL,
+		Concurrency: 1,
+		Client:      client,
+		Resume: &Resume{
+			Before: time.Date(2025, time.October, 8, 0, 0, 0, 0, time.UTC),
+			Known: model.Posts{{
+				Title:    "Known",
+				SourceID: 1,
+				Link:     server.URL + "/known-volume-1/",
+				Date:     time.Date(2025, time.October, 8, 0, 0, 0, 0, time.UTC),
+			}},
+		},
+	}
+
+	posts, _, err := FetchHTML(context.Background(), cutoff, opt)
+	if err != nil {
+		t.Fatalf("FetchHTML() error: %v", err)
+	}
+	var titles []string
+	for _, post := range posts {
+		titles = append(titles, post.Title)
+	}
+	if !slices.Equal(titles, []string{"Fresh", "Older"}) {
+		t.Fatalf("unexpected resumed posts: %v", titles)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if slices.Contains(requested, "/known-volume-1/") {
+		t.Fatalf("known post detail should not be fetched: %v", requested)
+	}
+	if slices.Contains(requested, "/") {
+		t.Fatalf("front archive should not be crawled on resume: %v", requested)
+	}
+}
@@ -0,0 +1,272 @@
+package collect
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"git.skobk.in/skobkin/jnovel-scrape/internal/event"
+	"git.skobk.in/skobkin/jnovel-scrape/internal/model"
+)
+
+// DefaultRetryBackoff is how long collectors wait before the second
+// pass over failed requests. It is deliberately longer than a rate-limit
+// wait: the second pass is meant for transient outages.
+const DefaultRetryBackoff = 30 * time.Second
+
+// FailureKind says what a failed fetch was for, which decides how
+// FetchFailed re-attempts it.
+type FailureKind string
+
+const (
+	// FailureAPIPage is a page of the REST API posts listing.
+	FailureAPIPage FailureKind = "api_page"
+	// FailureHTMLPage is an archive or search listing page in HTML
+	// mode.
+	FailureHTMLPage FailureKind = "html_page"
+	// FailureHTMLDetail is a post detail page in HTML mode.
+	FailureHTMLDetail FailureKind = "html_detail"
+)
+
+// FailedFetch is a request that still failed after the retry pass.
+type FailedFetch struct {
+	Kind FailureKind `json:"kind"`
+	URL  string      `json:"url"`
+	// Title is the raw archive title of an HTML detail page, which the
+	// detail page itself does not repeat.
+	Title string `json:"title,omitempty"`
+	// ID is the post ID read from the archive, if any.
+	ID    int64  `json:"id,omitempty"`
+	Error string `json:"error"`
+}
+
+// Failures collects FailedFetch entries from concurrent workers. A nil
+// *Failures discards them.
+type Failures struct {
+	mu    sync.Mutex
+	items []FailedFetch
+}
+
+func (f *Failures) add(item FailedFetch) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.items = append(f.items, item)
+}
+
+// Items returns a copy of the collected failures.
+func (f *Failures) Items() []FailedFetch {
+	if f == nil {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]FailedFetch(nil), f.items...)
+}
+
+// Discard drops every failure of the given kind, for callers that
+// covered the gap some other way.
+func (f *Failures) Discard(kind FailureKind) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	kept := f.items[:0]
+	for _, item := range f.items {
+		if item.Kind != kind {
+			kept = append(kept, item)
+		}
+	}
+	f.items = kept
+}
+
+// retryQueue holds HTML detail candidates whose first fetch failed until
+// the second pass after the main crawl. A nil queue means there is no
+// second pass and failures are final.
+type retryQueue struct {
+	mu         sync.Mutex
+	candidates []archiveCandidate
+}
+
+func (q *retryQueue) push(c archiveCandidate) bool {
+	if q == nil {
+		return false
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.candidates = append(q.candidates, c)
+
+	return true
+}
+
+func (q *retryQueue) drain() []archiveCandidate {
+	if q == nil {
+		return nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	out := q.candidates
+	q.candidates = nil
+
+	return out
+}
+
+// snapshot lists the queued candidates as failures, for checkpoints.
+func (q *retryQueue) snapshot() []FailedFetch {
+	if q == nil {
+		return nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	out := make([]FailedFetch, 0, len(q.candidates))
+	for _, c := range q.candidates {
+		out = append(out, FailedFetch{Kind: FailureHTMLDetail, URL: c.Link, Title: c.Title, ID: c.ID})
+	}
+
+	return out
+}
+
+// waitRetryBackoff sleeps for opt.RetryBackoff before a second pass,
+// returning early when ctx is cancelled.
+func waitRetryBackoff(ctx context.Context, opt Options) error {
+	if opt.RetryBackoff <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(opt.RetryBackoff)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// retryDetails is the HTML second pass: it waits opt.RetryBackoff and
+// fetches every queued detail page once more. Pages that fail again are
+// reported through opt.Failures.
+func retryDetails(ctx context.Context, cutoff time.Time, opt Options) (model.Posts, []Warning) {
+	queued := opt.retries.drain()
+	if len(queued) == 0 {
+		return nil, nil
+	}
+	opt.logger().Info("HTML retrying failed detail pages", "pages", len(queued), "wait", opt.RetryBackoff)
+	if err := waitRetryBackoff(ctx, opt); err != nil {
+		warnings := make([]Warning, 0, len(queued))
+		for _, candidate := range queued {
+			warnings = append(warnings, warnf(WarningFetchFailed, candidate.Link, "retry cancelled: %v → skipped", err))
+			opt.observe(event.PostSkipped{Source: event.SourceHTML, URL: candidate.Link, Reason: "retry cancelled"})
+			opt.Failures.add(FailedFetch{Kind: FailureHTMLDetail, URL: candidate.Link, Title: candidate.Title, ID: candidate.ID, Error: err.Error()})
+		}
+
+		return nil, warnings
+	}
+
+	opt.retries = nil
+	posts, warnings := enrichCandidates(ctx, opt, queued)
+	var kept model.Posts
+	for _, post := range posts {
+		if post.Date.Before(cutoff) {
+			warnings = append(warnings, warnf(WarningBeforeCutoff, post.Link, "skipped (date %s before cutoff)", post.FormatDate()))
+			opt.observe(event.PostSkipped{Source: event.SourceHTML, URL: post.Link, Reason: "date before cutoff"})
+
+			continue
+		}
+		kept = append(kept, post)
+	}
+
+	return kept, warnings
+}
+
+// FetchFailed re-attempts fetches recorded by an earlier run, once each
+// and without a second pass. API pages are decoded like a normal crawl
+// and filtered by cutoff; archive pages and detail pages are read like
+// HTML mode, the posts of an archive page without paging on. Fetches
+// that fail again are reported through opt.Failures.
+func FetchFailed(ctx context.Context, cutoff time.Time, items []FailedFetch, opt Options) (model.Posts, []Warning, error) {
+	opt, err := apiDefaults(opt)
+	if err != nil {
+		return nil, nil, err
+	}
+	sel, err := opt.Selectors.compile()
+	if err != nil {
+		return nil, nil, err
+	}
+	opt.selectors = sel
+	opt.retries = nil
+
+	var (
+		rawPosts   []apiPost
+		candidates []archiveCandidate
+		direct     model.Posts
+		warnings   []Warning
+	)
+	for _, item := range items {
+		switch item.Kind {
+		case FailureAPIPage:
+			posts, err := fetchAPIPageURL(ctx, opt, item.URL)
+			if err != nil {
+				warnings = append(warnings, warnf(WarningFetchFailed, item.URL, "still failing: %v", err))
+				item.Error = err.Error()
+				opt.Failures.add(item)
+
+				continue
+			}
+			rawPosts = append(rawPosts, posts...)
+		case FailureHTMLPage:
+			doc, err := fetchArchivePage(ctx, opt, item.URL, false)
+			if err != nil {
+				warnings = append(warnings, warnf(WarningFetchFailed, item.URL, "still failing: %v", err))
+				item.Error = err.Error()
+				opt.Failures.add(item)
+
+				continue
+			}
+			for _, candidate := range extractArchiveCandidates(doc, opt.selectors, item.URL) {
+				if candidate.needsDetail(opt) {
+					candidates = append(candidates, candidate)
+
+					continue
+				}
+				post, postWarnings := buildHTMLPost(candidate, candidate.Date, model.DateSourceArchive, candidate.Categories, candidate.Tags)
+				opt.observe(event.PostParsed{Source: event.SourceHTML, Post: post})
+				warnings = append(warnings, postWarnings...)
+				direct = append(direct, post)
+			}
+		case FailureHTMLDetail:
+			candidates = append(candidates, archiveCandidate{Title: item.Title, Link: item.URL, ID: item.ID})
+		default:
+			warnings = append(warnings, warnf(WarningUnknownFailure, item.URL, "unknown failure kind %q → dropped", item.Kind))
+		}
+	}
+
+	var allPosts model.Posts
+	if len(rawPosts) > 0 {
+		posts, apiWarnings, err := buildAPIPosts(ctx, opt, cutoff, rawPosts)
+		if err != nil {
+			return nil, warnings, err
+		}
+		warnings = append(warnings, apiWarnings...)
+		allPosts = append(allPosts, posts...)
+	}
+	if len(candidates) > 0 || len(direct) > 0 {
+		posts, detailWarnings := enrichCandidates(ctx, opt, candidates)
+		warnings = append(warnings, detailWarnings...)
+		for _, post := range append(direct, posts...) {
+			if post.Date.Before(cutoff) {
+				warnings = append(warnings, warnf(WarningBeforeCutoff, post.Link, "skipped (date %s before cutoff)", post.FormatDate()))
+				opt.observe(event.PostSkipped{Source: event.SourceHTML, URL: post.Link, Reason: "date before cutoff"})
+
+				continue
+			}
+			allPosts = append(allPosts, post)
+		}
+	}
+	allPosts.Sort()
+
+	return allPosts, warnings, nil
+}
@@ -0,0 +1,212 @@
+//nolint:gosec
+package collect
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"git.skobk.in/skobkin/jnovel-scrape/internal/httpx"
+)
+
+func TestFetchHTMLRetriesFailedDetailsAfterCrawl(t *testing.T) {
+	var flakyCalls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/", "":
+			fmt.Fprint(w, `<html><body>
+				<article id="post-1"><h2 class="entry-title"><a href="/flaky-volume-1-epub/">Flaky Volume 1 EPUB</a></h2></article>
+				<article id="post-2"><h2 class="entry-title"><a href="/broken-volume-2-epub/">Broken Volume 2 EPUB</a></h2></article>
+				<article id="post-3"><h2 class="entry-title"><a href="/removed-volume-3-epub/">Removed Volume 3 EPUB</a></h2></article>
+			</body></html>`)
+		case "/flaky-volume-1-epub/":
+			if flakyCalls.Add(1) == 1 {
+				w.WriteHeader(http.StatusForbidden)
+
+				return
+			}
+			fmt.Fprint(w, `<html><body><time datetime="2025-10-15T00:00:00Z"></time></body></html>`)
+		case "/broken-volume-2-epub/":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := httpx.NewClient(1*time.Millisecond, 5*time.Millisecond,
+		httpx.WithHTTPClient(server.Client()),
+		httpx.WithJitterFactor(0),
+	)
+
+	cutoff := time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC)
+	failures := &Failures{}
+	opt := Options{BaseURL: server.URL, MaxPages: 1, Concurrency: 1, Client: client, Failures: failures, RetryBackoff: time.Millisecond}
+
+	posts, warnings, err := FetchHTML(context.Background(), cutoff, opt)
+	if err != nil {
+		t.Fatalf("FetchHTML() error: %v", err)
+	}
+	if len(posts) != 1 || posts[0].Title != "Flaky" {
+		t.Fatalf("expected the flaky post to be recovered, got %+v", posts)
+	}
+	items := failures.Items()
+	if len(items) != 1 || items[0].Kind != FailureHTMLDetail || !strings.HasSuffix(items[0].URL, "/broken-volume-2-epub/") {
+		t.Fatalf("expected only the broken post in failures, got %+v", items)
+	}
+	if items[0].Title != "Broken Volume 2 EPUB" || items[0].ID != 2 {
+		t.Fatalf("failure lost archive data: %+v", items[0])
+	}
+	if len(warnings) != 2 || warnings[0].Code != WarningFetchFailed || warnings[1].Code != WarningFetchFailed {
+		t.Fatalf("expected fetch warnings for the broken and removed posts, got %v", warnings)
+	}
+}
+
+func TestFetchHTMLRecordsFailedArchivePage(t *testing.T) {
+	var pageCalls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/", "":
+			fmt.Fprint(w, `<html><body>
+				<article id="post-1"><h2 class="entry-title"><a href="/first-volume-1-epub/">First Volume 1 EPUB</a></h2>
+					<time datetime="2025-10-15T00:00:00Z"></time></article>
+			</body></html>`)
+		case "/page/2/":
+			pageCalls.Add(1)
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := httpx.NewClient(1*time.Millisecond, 5*time.Millisecond,
+		httpx.WithHTTPClient(server.Client()),
+		httpx.WithJitterFactor(0),
+	)
+
+	cutoff := time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC)
+	failures := &Failures{}
+	opt := Options{BaseURL: server.URL, MaxPages: 3, Concurrency: 1, Client: client, Failures: failures, RetryBackoff: time.Millisecond}
+
+	posts, _, err := FetchHTML(context.Background(), cutoff, opt)
+	var partial *PartialError
+	if !errors.As(err, &partial) {
+		t.Fatalf("expected a partial result, got %v", err)
+	}
+	if len(posts) != 1 {
+		t.Fatalf("expected the first page's post, got %+v", posts)
+	}
+	if pageCalls.Load() != 2 {
+		t.Fatalf("expected the archive page to be tried twice, got %d", pageCalls.Load())
+	}
+	items := failures.Items()
+	if len(items) != 1 || items[0].Kind != FailureHTMLPage || items[0].URL != server.URL+"/page/2/" {
+		t.Fatalf("expected the archive page in failures, got %+v", items)
+	}
+}
+
+func TestFetchFailed(t *testing.T) {
+	var brokenCalls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/wp-json/wp/v2/posts":
+			json.NewEncoder(w).Encode([]apiPost{{
+				ID:      7,
+				Date:    "2025-10-12T00:00:00",
+				DateGMT: "2025-10-12T00:00:00",
+				Link:    "https://example.com/api-volume-7-pdf/",
+				Title:   rendered{Text: "API Volume 7 PDF"},
+			}})
+		case "/wp-json/wp/v2/categories", "/wp-json/wp/v2/tags":
+			json.NewEncoder(w).Encode([]taxonomyItem{})
+		case "/detail-volume-8-epub/":
+			fmt.Fprint(w, `<html><body><time datetime="2025-10-13T00:00:00Z"></time></body></html>`)
+		case "/page/2/":
+			fmt.Fprint(w, `<html><body>
+				<article id="post-9"><h2 class="entry-title"><a href="/archive-volume-9-epub/">Archive Volume 9 EPUB</a></h2>
+					<time datetime="2025-10-14T00:00:00Z"></time></article>
+				<article id="post-4"><h2 class="entry-title"><a href="/old-volume-4-epub/">Old Volume 4 EPUB</a></h2>
+					<time datetime="2025-09-01T00:00:00Z"></time></article>
+			</body></html>`)
+		default:
+			brokenCalls.Add(1)
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	client := httpx.NewClient(1*time.Millisecond, 5*time.Millisecond,
+		httpx.WithHTTPClient(server.Client()),
+		httpx.WithJitterFactor(0),
+	)
+
+	cutoff := time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC)
+	items := []FailedFetch{
+		{Kind: FailureAPIPage, URL: server.URL + "/wp-json/wp/v2/posts?page=3&per_page=100"},
+		{Kind: FailureHTMLPage, URL: server.URL + "/page/2/"},
+		{Kind: FailureHTMLDetail, URL: server.URL + "/detail-volume-8-epub/", Title: "Detail Volume 8 EPUB", ID: 8},
+		{Kind: FailureHTMLDetail, URL: server.URL + "/still-broken/", Title: "Still Broken"},
+	}
+	failures := &Failures{}
+	opt := Options{BaseURL: server.URL, Client: client, Failures: failures}
+
+	posts, _, err := FetchFailed(context.Background(), cutoff, items, opt)
+	if err != nil {
+		t.Fatalf("FetchFailed() error: %v", err)
+	}
+	if len(posts) != 3 {
+		t.Fatalf("expected 3 recovered posts, got %+v", posts)
+	}
+	if posts[0].SourceID != 9 || posts[1].SourceID != 8 || posts[2].SourceID != 7 {
+		t.Fatalf("unexpected posts: %+v", posts)
+	}
+	left := failures.Items()
+	if len(left) != 1 || left[0].Title != "Still Broken" {
+		t.Fatalf("unexpected remaining failures: %+v", left)
+	}
+	if brokenCalls.Load() != 1 {
+		t.Fatalf("FetchFailed must try each entry once, got %d calls", brokenCalls.Load())
+	}
+}
+
+func TestFetchFailedKeepsEntriesWhenCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("no request expected after cancellation, got %s", r.URL)
+	}))
+	defer server.Close()
+
+	client := httpx.NewClient(1*time.Millisecond, 5*time.Millisecond,
+		httpx.WithHTTPClient(server.Client()),
+		httpx.WithJitterFactor(0),
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	items := []FailedFetch{
+		{Kind: FailureAPIPage, URL: server.URL + "/wp-json/wp/v2/posts?page=3"},
+		{Kind: FailureHTMLPage, URL: server.URL + "/page/2/"},
+		{Kind: FailureHTMLDetail, URL: server.URL + "/a/", Title: "A"},
+		{Kind: FailureHTMLDetail, URL: server.URL + "/b/", Title: "B"},
+	}
+	failures := &Failures{}
+	opt := Options{BaseURL: server.URL, Client: client, Concurrency: 2, Failures: failures}
+
+	posts, _, err := FetchFailed(ctx, time.Time{}, items, opt)
+	if err != nil {
+		t.Fatalf("FetchFailed() error: %v", err)
+	}
+	if len(posts) != 0 {
+		t.Fatalf("unexpected posts: %+v", posts)
+	}
+	if left := failures.Items(); len(left) != len(items) {
+		t.Fatalf("every entry must stay in failures, got %+v", left)
+	}
+}
@@ -0,0 +1,174 @@
+package collect
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+)
+
+// Selectors holds the CSS selectors the HTML collector uses to read
+// archive and detail pages. Empty fields fall back to
+// DefaultSelectors, so a selectors file only needs to list what a
+// theme changed.
+type Selectors struct {
+	// Article matches one post block on an archive page.
+	Article string `json:"article"`
+	// TitleLink matches the post link inside an article block; its
+	// href is the post URL and its text the raw title.
+	TitleLink string `json:"title_link"`
+	// Date matches the publish date. The datetime or content
+	// attribute is preferred over the element text.
+	Date string `json:"date"`
+	// Category matches category links.
+	Category string `json:"category"`
+	// Tag matches tag links.
+	Tag string `json:"tag"`
+	// NextPage matches the link to the next archive page. When no
+	// element matches, the crawler falls back to /page/N/.
+	NextPage string `json:"next_page"`
+	// Content matches the post body on a detail page.
+	Content string `json:"content"`
+	// Sticky matches a pinned archive block (or an element inside
+	// one). Sticky posts do not count towards the stop rule.
+	Sticky string `json:"sticky"`
+}
+
+// DefaultSelectors matches the stock WordPress theme markup of
+// jnovels.com.
+func DefaultSelectors() Selectors {
+	return Selectors{
+		Article:   "article",
+		TitleLink: "h1.entry-title a[href], h2.entry-title a[href]",
+		Date:      `time[datetime], meta[property="article:published_time"]`,
+		Category:  `a[rel~="category"]`,
+		Tag:       `a[rel~="tag"]:not([rel~="category"])`,
+		NextPage:  `a.next.page-numbers[href], link[rel="next"][href]`,
+		Content:   ".entry-content",
+		Sticky:    ".sticky",
+	}
+}
+
+// LoadSelectors reads a JSON selectors file and fills fields it leaves
+// empty from DefaultSelectors. Every selector is compiled up front so a
+// typo is reported before the crawl starts.
+func LoadSelectors(path string) (Selectors, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Selectors{}, fmt.Errorf("read selectors: %w", err)
+	}
+	var sel Selectors
+	if err := json.Unmarshal(data, &sel); err != nil {
+		return Selectors{}, fmt.Errorf("parse selectors %s: %w", path, err)
+	}
+	sel = sel.withDefaults()
+	if _, err := sel.compile(); err != nil {
+		return Selectors{}, fmt.Errorf("selectors %s: %w", path, err)
+	}
+
+	return sel, nil
+}
+
+func (s Selectors) withDefaults() Selectors {
+	def := DefaultSelectors()
+	fill := func(value *string, fallback string) {
+		if strings.TrimSpace(*value) == "" {
+			*value = fallback
+		}
+	}
+	fill(&s.Article, def.Article)
+	fill(&s.TitleLink, def.TitleLink)
+	fill(&s.Date, def.Date)
+	fill(&s.Category, def.Category)
+	fill(&s.Tag, def.Tag)
+	fill(&s.NextPage, def.NextPage)
+	fill(&s.Content, def.Content)
+	fill(&s.Sticky, def.Sticky)
+
+	return s
+}
+
+type compiledSelectors struct {
+	article   cascadia.Matcher
+	titleLink cascadia.Matcher
+	date      cascadia.Matcher
+	category  cascadia.Matcher
+	tag       cascadia.Matcher
+	nextPage  cascadia.Matcher
+	content   cascadia.Matcher
+	sticky    cascadia.Matcher
+}
+
+func (s Selectors) compile() (*compiledSelectors, error) {
+	s = s.withDefaults()
+	var (
+		out      compiledSelectors
+		firstErr error
+	)
+	parse := func(name, raw string) cascadia.Matcher {
+		sel, err := cascadia.ParseGroup(raw)
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("invalid %s selector %q: %w", name, raw, err)
+		}
+
+		return sel
+	}
+	out.article = parse("article", s.Article)
+	out.titleLink = parse("title_link", s.TitleLink)
+	out.date = parse("date", s.Date)
+	out.category = parse("category", s.Category)
+	out.tag = parse("tag", s.Tag)
+	out.nextPage = parse("next_page", s.NextPage)
+	out.content = parse("content", s.Content)
+	out.sticky = parse("sticky", s.Sticky)
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	return &out, nil
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if strings.EqualFold(a.Key, key) {
+			return a.Val
+		}
+	}
+
+	return ""
+}
+
+// innerHTML renders the children of n back to markup, so the existing
+// string helpers (CleanTitle, ExtractBookMeta) see what they always did.
+func innerHTML(n *html.Node) string {
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		_ = html.Render(&b, c)
+	}
+
+	return b.String()
+}
+
+// textContent concatenates the text nodes below n, skipping scripts and
+// styles.
+func textContent(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.TextNode {
+			b.WriteString(node.Data)
+		}
+		if node.Type == html.ElementNode && (node.Data == "script" || node.Data == "style") {
+			return
+		}
+		for c := node.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+
+	return b.String()
+}
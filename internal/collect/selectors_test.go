@@ -0,0 +1,39 @@
+package collect
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadSelectorsFillsDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "selectors.json")
+	if err := os.WriteFile(path, []byte(`{"article": "div.post", "title_link": "h3 > a"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	sel, err := LoadSelectors(path)
+	if err != nil {
+		t.Fatalf("LoadSelectors() error: %v", err)
+	}
+	def := DefaultSelectors()
+	if sel.Article != "div.post" || sel.TitleLink != "h3 > a" {
+		t.Fatalf("overrides not applied: %+v", sel)
+	}
+	if sel.Date != def.Date || sel.Tag != def.Tag || sel.NextPage != def.NextPage {
+		t.Fatalf("defaults not filled: %+v", sel)
+	}
+}
+
+func TestLoadSelectorsRejectsInvalidSelector(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "selectors.json")
+	if err := os.WriteFile(path, []byte(`{"tag": "a[rel="}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadSelectors(path)
+	if err == nil || !strings.Contains(err.Error(), "tag selector") {
+		t.Fatalf("expected tag selector error, got %v", err)
+	}
+}
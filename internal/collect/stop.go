@@ -0,0 +1,46 @@
+package collect
+
+import "time"
+
+// DefaultStopAfter is the number of consecutive posts older than the
+// cutoff after which a crawl stops paging. A few out-of-order dates on
+// the front archive stay below it; a genuine run of older posts does not.
+const DefaultStopAfter = 3
+
+// stopRule decides when a newest-first crawl has passed the cutoff. It
+// counts consecutive non-sticky posts older than the cutoff, across page
+// boundaries, and trips once the run reaches tolerance. Sticky posts are
+// pinned to the front of the archive regardless of age, so they neither
+// extend nor break a run.
+type stopRule struct {
+	cutoff    time.Time
+	tolerance int
+	streak    int
+}
+
+func newStopRule(cutoff time.Time, tolerance int) *stopRule {
+	if tolerance <= 0 {
+		tolerance = DefaultStopAfter
+	}
+
+	return &stopRule{cutoff: cutoff, tolerance: tolerance}
+}
+
+// observe feeds the next post in archive order and reports whether the
+// crawl should stop after the current page.
+func (r *stopRule) observe(date time.Time, sticky bool) bool {
+	if sticky {
+		return r.tripped()
+	}
+	if date.Before(r.cutoff) {
+		r.streak++
+	} else {
+		r.streak = 0
+	}
+
+	return r.tripped()
+}
+
+func (r *stopRule) tripped() bool {
+	return r.streak >= r.tolerance
+}
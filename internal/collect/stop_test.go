@@ -0,0 +1,36 @@
+package collect
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStopRule(t *testing.T) {
+	cutoff := time.Date(2025, time.October, 10, 0, 0, 0, 0, time.UTC)
+	newer := cutoff.AddDate(0, 0, 1)
+	older := cutoff.AddDate(0, 0, -1)
+
+	rule := newStopRule(cutoff, 2)
+	steps := []struct {
+		date   time.Time
+		sticky bool
+		want   bool
+	}{
+		{older, true, false},  // sticky old post pinned on top
+		{newer, false, false}, // regular posts start
+		{older, false, false}, // one out-of-order post
+		{newer, false, false}, // run broken
+		{older, false, false},
+		{older, true, false}, // sticky does not extend the run
+		{older, false, true},
+	}
+	for i, step := range steps {
+		if got := rule.observe(step.date, step.sticky); got != step.want {
+			t.Fatalf("step %d: observe() = %v, want %v", i, got, step.want)
+		}
+	}
+
+	if newStopRule(cutoff, 0).tolerance != DefaultStopAfter {
+		t.Fatalf("zero tolerance should fall back to DefaultStopAfter")
+	}
+}
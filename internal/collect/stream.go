@@ -0,0 +1,172 @@
+package collect
+
+import (
+	"context"
+	"iter"
+	"slices"
+	"time"
+
+	"git.skobk.in/skobkin/jnovel-scrape/internal/model"
+)
+
+// PostStream yields the posts of a crawl as soon as they are final,
+// instead of collecting and sorting them first. Posts arrive newest
+// first, in the order the site lists them; sticky posts, which the site
+// pins to the front regardless of age, are held back until the crawl
+// reaches their date, so a consumer may stop once it has seen enough
+// recent posts. A failed crawl ends with one element carrying the error
+// FetchAPI or FetchHTML would have returned, and a zero post.
+type PostStream struct {
+	Posts iter.Seq2[model.Post, error]
+	// Warnings is filled in once Posts has been consumed.
+	Warnings []Warning
+}
+
+// StreamAPI is the streaming form of FetchAPI.
+func StreamAPI(ctx context.Context, cutoff time.Time, opt Options) *PostStream {
+	return newPostStream(ctx, cutoff, opt, FetchAPI)
+}
+
+// StreamHTML is the streaming form of FetchHTML.
+func StreamHTML(ctx context.Context, cutoff time.Time, opt Options) *PostStream {
+	return newPostStream(ctx, cutoff, opt, FetchHTML)
+}
+
+type fetchFunc func(ctx context.Context, cutoff time.Time, opt Options) (model.Posts, []Warning, error)
+
+func newPostStream(ctx context.Context, cutoff time.Time, opt Options, fetch fetchFunc) *PostStream {
+	s := &PostStream{}
+	s.Posts = func(yield func(model.Post, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		sink := &postSink{
+			seen:   model.NewIdentitySet(),
+			yield:  func(post model.Post) bool { return yield(post, nil) },
+			cancel: cancel,
+		}
+		// Failures are only passed on when the consumer read to the
+		// end; fetches cut off because it stopped early did not fail.
+		failures := opt.Failures
+		opt.Failures = &Failures{}
+		opt.sink = sink
+
+		_, warnings, err := fetch(ctx, cutoff, opt)
+		sink.flushPinned(time.Time{})
+		s.Warnings = slices.Concat(sink.warnings, warnings)
+		if sink.stopped {
+			return
+		}
+		for _, item := range opt.Failures.Items() {
+			failures.add(item)
+		}
+		if sink.err != nil {
+			err = sink.err
+		}
+		if err != nil {
+			yield(model.Post{}, err)
+		}
+	}
+
+	return s
+}
+
+// postSink takes finished posts from the collectors of a PostStream,
+// which then keep nothing themselves. A nil sink means the collectors
+// return their posts as usual. All methods run on the goroutine that
+// iterates the stream.
+type postSink struct {
+	seen   *model.IdentitySet
+	yield  func(model.Post) bool
+	cancel context.CancelFunc
+	// stopped is set once the consumer stops iterating; the crawl is
+	// cancelled then.
+	stopped bool
+	// oldest is the publish date of the oldest post passed on, which
+	// stands in for the collected posts when a crawl fails part-way.
+	oldest time.Time
+	// pinned holds sticky posts, newest first, until the crawl reaches
+	// their date.
+	pinned   model.Posts
+	warnings []Warning
+	err      error
+	api      *apiBuilder
+}
+
+// emit passes posts on to the consumer, dropping ones already passed.
+// Held-back sticky posts that are newer go first.
+func (s *postSink) emit(posts ...model.Post) {
+	for _, post := range posts {
+		if !s.seen.Add(post) {
+			continue
+		}
+		if s.oldest.IsZero() || post.Date.Before(s.oldest) {
+			s.oldest = post.Date
+		}
+		s.flushPinned(post.Date)
+		s.pass(post)
+	}
+}
+
+// pin holds back a sticky post until a post older than it is emitted,
+// or the crawl ends.
+func (s *postSink) pin(post model.Post) {
+	if !s.seen.Add(post) {
+		return
+	}
+	i, _ := slices.BinarySearchFunc(s.pinned, post.Date, func(p model.Post, date time.Time) int {
+		return date.Compare(p.Date)
+	})
+	s.pinned = slices.Insert(s.pinned, i, post)
+}
+
+// flushPinned passes on the held-back posts dated at or after since; a
+// zero since passes all of them.
+func (s *postSink) flushPinned(since time.Time) {
+	for len(s.pinned) > 0 && !s.pinned[0].Date.Before(since) {
+		post := s.pinned[0]
+		s.pinned = s.pinned[1:]
+		s.pass(post)
+	}
+}
+
+func (s *postSink) pass(post model.Post) {
+	if s.stopped {
+		return
+	}
+	if !s.yield(post) {
+		s.stopped = true
+		s.cancel()
+	}
+}
+
+// emitAPI builds raw API posts and passes them on. The first build
+// error ends the crawl and is reported by the stream.
+func (s *postSink) emitAPI(ctx context.Context, opt Options, cutoff time.Time, raw []apiPost) {
+	if s.stopped || s.err != nil {
+		return
+	}
+	if s.api == nil {
+		s.api = newAPIBuilder(opt, cutoff)
+	}
+	posts, warnings, err := s.api.build(ctx, raw)
+	s.warnings = append(s.warnings, warnings...)
+	if err != nil {
+		s.err = err
+		s.cancel()
+
+		return
+	}
+	sticky := make(map[int64]bool)
+	for _, ap := range raw {
+		if ap.Sticky {
+			sticky[ap.ID] = true
+		}
+	}
+	for _, post := range posts {
+		if sticky[post.SourceID] {
+			s.pin(post)
+		} else {
+			s.emit(post)
+		}
+	}
+}
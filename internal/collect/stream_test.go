@@ -0,0 +1,208 @@
+//nolint:gosec
+package collect
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"git.skobk.in/skobkin/jnovel-scrape/internal/httpx"
+)
+
+func TestStreamAPIYieldsPostsBeforeCrawlEnds(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/wp-json/wp/v2/posts" {
+			json.NewEncoder(w).Encode([]taxonomyItem{})
+
+			return
+		}
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 3 {
+			// Held until the test is done: the first posts must
+			// arrive while this page is still loading.
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+
+			return
+		}
+		w.Header().Set("X-WP-TotalPages", "3")
+		day := 22 - page*2
+		json.NewEncoder(w).Encode([]apiPost{{
+			ID:      int64(page),
+			Date:    fmt.Sprintf("2025-10-%02dT00:00:00", day),
+			DateGMT: fmt.Sprintf("2025-10-%02dT00:00:00", day),
+			Link:    fmt.Sprintf("https://example.com/series-volume-%d-epub/", page),
+			Title:   rendered{Text: fmt.Sprintf("Series Volume %d EPUB", page)},
+		}})
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := httpx.NewClient(1*time.Millisecond, 5*time.Millisecond,
+		httpx.WithHTTPClient(server.Client()),
+		httpx.WithJitterFactor(0),
+	)
+
+	cutoff := time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC)
+	failures := &Failures{}
+	opt := Options{BaseURL: server.URL, Client: client, Concurrency: 2, Failures: failures}
+
+	stream := StreamAPI(context.Background(), cutoff, opt)
+	var ids []int64
+	for post, err := range stream.Posts {
+		if err != nil {
+			t.Fatalf("unexpected stream error: %v", err)
+		}
+		ids = append(ids, post.SourceID)
+		if len(ids) == 2 {
+			break
+		}
+	}
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 2 {
+		t.Fatalf("expected pages 1 and 2 in order, got %v", ids)
+	}
+	if items := failures.Items(); len(items) != 0 {
+		t.Fatalf("pages cut off by stopping early must not be failures, got %+v", items)
+	}
+}
+
+func TestStreamAPIEndsWithPartialError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/wp-json/wp/v2/posts" {
+			json.NewEncoder(w).Encode([]taxonomyItem{})
+
+			return
+		}
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 3 {
+			w.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+		w.Header().Set("X-WP-TotalPages", "4")
+		day := 22 - page*2
+		json.NewEncoder(w).Encode([]apiPost{{
+			ID:      int64(page),
+			Date:    fmt.Sprintf("2025-10-%02dT00:00:00", day),
+			DateGMT: fmt.Sprintf("2025-10-%02dT00:00:00", day),
+			Link:    fmt.Sprintf("https://example.com/series-volume-%d-epub/", page),
+			Title:   rendered{Text: fmt.Sprintf("Series Volume %d EPUB", page)},
+		}})
+	}))
+	defer server.Close()
+
+	client := httpx.NewClient(1*time.Millisecond, 5*time.Millisecond,
+		httpx.WithHTTPClient(server.Client()),
+		httpx.WithJitterFactor(0),
+	)
+
+	cutoff := time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC)
+	failures := &Failures{}
+	opt := Options{BaseURL: server.URL, Client: client, Concurrency: 1, Failures: failures}
+
+	var (
+		ids       []int64
+		streamErr error
+	)
+	for post, err := range StreamAPI(context.Background(), cutoff, opt).Posts {
+		if err != nil {
+			streamErr = err
+
+			continue
+		}
+		ids = append(ids, post.SourceID)
+	}
+	if fmt.Sprint(ids) != "[1 2 4]" {
+		t.Fatalf("expected posts from pages 1, 2 and 4, got %v", ids)
+	}
+	var partial *PartialError
+	if !errors.As(streamErr, &partial) {
+		t.Fatalf("expected PartialError, got %v", streamErr)
+	}
+	if got := partial.Reached.Format("2006-01-02"); got != "2025-10-18" {
+		t.Fatalf("unexpected resume point %s", got)
+	}
+	if items := failures.Items(); len(items) != 1 || items[0].Kind != FailureAPIPage {
+		t.Fatalf("unexpected failures: %+v", items)
+	}
+}
+
+func TestStreamHTMLYieldsArchiveOrder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/", "":
+			fmt.Fprint(w, `<html><body>
+				<article><h2 class="entry-title"><a href="/older-volume-1-epub/">Older Volume 1 EPUB</a></h2><time datetime="2025-10-05T00:00:00Z"></time></article>
+				<article><h2 class="entry-title"><a href="/newer-volume-2-epub/">Newer Volume 2 EPUB</a></h2><time datetime="2025-10-15T00:00:00Z"></time></article>
+			</body></html>`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := httpx.NewClient(1*time.Millisecond, 5*time.Millisecond,
+		httpx.WithHTTPClient(server.Client()),
+		httpx.WithJitterFactor(0),
+	)
+
+	cutoff := time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC)
+	opt := Options{BaseURL: server.URL, MaxPages: 1, Concurrency: 1, Client: client}
+
+	var titles []string
+	for post, err := range StreamHTML(context.Background(), cutoff, opt).Posts {
+		if err != nil {
+			t.Fatalf("unexpected stream error: %v", err)
+		}
+		titles = append(titles, post.Title)
+	}
+	// Unlike FetchHTML, the stream does not sort by date.
+	if fmt.Sprint(titles) != "[Older Newer]" {
+		t.Fatalf("expected posts in archive order, got %v", titles)
+	}
+}
+
+func TestStreamHTMLHoldsBackStickyPosts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/", "":
+			fmt.Fprint(w, `<html><body>
+				<article class="sticky"><h2 class="entry-title"><a href="/pinned-volume-1-epub/">Pinned Volume 1 EPUB</a></h2><time datetime="2025-10-10T00:00:00Z"></time></article>
+				<article><h2 class="entry-title"><a href="/first-volume-1-epub/">First Volume 1 EPUB</a></h2><time datetime="2025-10-20T00:00:00Z"></time></article>
+				<article><h2 class="entry-title"><a href="/second-volume-1-epub/">Second Volume 1 EPUB</a></h2><time datetime="2025-10-15T00:00:00Z"></time></article>
+				<article><h2 class="entry-title"><a href="/third-volume-1-epub/">Third Volume 1 EPUB</a></h2><time datetime="2025-10-05T00:00:00Z"></time></article>
+			</body></html>`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := httpx.NewClient(1*time.Millisecond, 5*time.Millisecond,
+		httpx.WithHTTPClient(server.Client()),
+		httpx.WithJitterFactor(0),
+	)
+
+	cutoff := time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC)
+	opt := Options{BaseURL: server.URL, MaxPages: 1, Concurrency: 1, Client: client}
+
+	var titles []string
+	for post, err := range StreamHTML(context.Background(), cutoff, opt).Posts {
+		if err != nil {
+			t.Fatalf("unexpected stream error: %v", err)
+		}
+		titles = append(titles, post.Title)
+	}
+	if fmt.Sprint(titles) != "[First Second Pinned Third]" {
+		t.Fatalf("expected the sticky post at its date, got %v", titles)
+	}
+}
@@ -0,0 +1,84 @@
+package collect
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WarningCode classifies a Warning, so callers can count, suppress or
+// fail on kinds of problems without parsing the text.
+type WarningCode string
+
+const (
+	// WarningMissingVolume is a post kept without a volume number.
+	WarningMissingVolume WarningCode = "missing_volume"
+	// WarningUnknownType is a post kept with type UNKNOWN.
+	WarningUnknownType WarningCode = "unknown_type"
+	// WarningMissingDate is a post without a usable publish date:
+	// skipped, or dated by its last-modified time instead.
+	WarningMissingDate WarningCode = "missing_date"
+	// WarningMissingTitle is an API post without a title, skipped.
+	WarningMissingTitle WarningCode = "missing_title"
+	// WarningMissingLink is an API post without a link, skipped.
+	WarningMissingLink WarningCode = "missing_link"
+	// WarningFetchFailed is a detail page or dead-letter entry that
+	// could not be loaded.
+	WarningFetchFailed WarningCode = "fetch_failed"
+	// WarningLookupFailed is a taxonomy or cover lookup that failed;
+	// the names or covers are left blank.
+	WarningLookupFailed WarningCode = "lookup_failed"
+	// WarningBeforeCutoff is a post whose detail page dated it before
+	// the cutoff, skipped.
+	WarningBeforeCutoff WarningCode = "before_cutoff"
+	// WarningUnknownFailure is a dead-letter entry of a kind this
+	// version does not know, dropped.
+	WarningUnknownFailure WarningCode = "unknown_failure"
+)
+
+// WarningCodes lists every WarningCode.
+var WarningCodes = []WarningCode{
+	WarningMissingVolume,
+	WarningUnknownType,
+	WarningMissingDate,
+	WarningMissingTitle,
+	WarningMissingLink,
+	WarningFetchFailed,
+	WarningLookupFailed,
+	WarningBeforeCutoff,
+	WarningUnknownFailure,
+}
+
+// Warning is a problem with one post or lookup that did not stop the
+// crawl.
+type Warning struct {
+	Code WarningCode `json:"code"`
+	// Link is the post URL; empty for API posts without one and for
+	// lookups.
+	Link string `json:"link,omitempty"`
+	// Detail says what went wrong and what was done about it.
+	Detail string `json:"detail"`
+}
+
+func warnf(code WarningCode, link, format string, args ...any) Warning {
+	return Warning{Code: code, Link: link, Detail: fmt.Sprintf(format, args...)}
+}
+
+// String returns the link followed by the detail.
+func (w Warning) String() string {
+	if w.Link == "" {
+		return w.Detail
+	}
+
+	return w.Link + " " + w.Detail
+}
+
+// warningDetails joins the details of warnings, for reasons recorded
+// next to the link anyway.
+func warningDetails(warnings []Warning) string {
+	details := make([]string, len(warnings))
+	for i, w := range warnings {
+		details[i] = w.Detail
+	}
+
+	return strings.Join(details, "; ")
+}
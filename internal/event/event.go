@@ -0,0 +1,161 @@
+// Package event defines the typed events a crawl reports while it runs,
+// so progress displays, metrics, structured logs and tests can follow it
+// without parsing log lines.
+package event
+
+import (
+	"time"
+
+	"git.skobk.in/skobkin/jnovel-scrape/internal/model"
+)
+
+// Event is one of the event types in this package. Observers tell them
+// apart with a type switch.
+type Event interface {
+	event()
+}
+
+// Observer receives events. Collectors and the HTTP client call Observe
+// from several goroutines at once, so implementations must be safe for
+// concurrent use and should return quickly.
+type Observer interface {
+	Observe(e Event)
+}
+
+// Func adapts a function to the Observer interface.
+type Func func(e Event)
+
+// Observe calls f(e).
+func (f Func) Observe(e Event) {
+	f(e)
+}
+
+// Multi returns an Observer that passes every event to each of
+// observers in turn. Nil observers are skipped; with none left it
+// returns nil.
+func Multi(observers ...Observer) Observer {
+	var list multi
+	for _, o := range observers {
+		if o != nil {
+			list = append(list, o)
+		}
+	}
+	switch len(list) {
+	case 0:
+		return nil
+	case 1:
+		return list[0]
+	default:
+		return list
+	}
+}
+
+type multi []Observer
+
+func (m multi) Observe(e Event) {
+	for _, o := range m {
+		o.Observe(e)
+	}
+}
+
+// Source names the collector an event comes from.
+type Source string
+
+const (
+	// SourceAPI is the WordPress REST API collector.
+	SourceAPI Source = "api"
+	// SourceHTML is the HTML archive collector.
+	SourceHTML Source = "html"
+)
+
+// PageFetched reports a listing page that was loaded: a page of the API
+// posts listing or of the HTML archive.
+type PageFetched struct {
+	Source Source
+	Page   int
+	URL    string
+	// Posts is the number of posts (API) or archive blocks (HTML) on
+	// the page.
+	Posts int
+	// Total is the number of pages the listing has, as sent in
+	// X-WP-TotalPages; zero when it is not known.
+	Total int
+}
+
+// PostParsed reports a post that was built from the page it was read
+// from. It may still be dropped later, which is reported as PostSkipped.
+type PostParsed struct {
+	Source Source
+	Post   model.Post
+}
+
+// PostSkipped reports a post that was not collected.
+type PostSkipped struct {
+	Source Source
+	// URL is the post link; for API posts without one it is empty.
+	URL    string
+	Reason string
+}
+
+// DetailStarted reports a detail page request about to be made.
+// Every DetailStarted is followed by a DetailFinished for the same URL.
+type DetailStarted struct {
+	URL string
+}
+
+// DetailFinished reports a detail page request that is over, whatever
+// its outcome; PostParsed, PostSkipped or RetryScheduled tells which.
+type DetailFinished struct {
+	URL string
+}
+
+// RetryScheduled reports a failed fetch that will be tried again.
+type RetryScheduled struct {
+	URL string
+	// Attempt is the number of the attempt that failed, from 1.
+	Attempt int
+	Wait    time.Duration
+	Err     error
+	// SecondPass marks a fetch the collector tries again after its
+	// retry backoff, rather than one the HTTP client retries on its
+	// own.
+	SecondPass bool
+}
+
+// RequestDone reports an HTTP round trip that is over: its response
+// body was closed, or it failed without a response. Every attempt of a
+// retried request is reported.
+type RequestDone struct {
+	URL string
+	// Status is zero when no response arrived.
+	Status int
+	// Bytes is how much of the response body was read.
+	Bytes int64
+	Err   error
+}
+
+// RateLimited reports a 429 or 503 answer the HTTP client waits out
+// before trying again.
+type RateLimited struct {
+	URL    string
+	Status int
+	Wait   time.Duration
+}
+
+// FallbackTriggered reports a switch to a fallback strategy: another
+// API request strategy, or the HTML collector after the API failed.
+type FallbackTriggered struct {
+	From string
+	To   string
+	Err  error
+}
+
+func (PageFetched) event()       {}
+func (PostParsed) event()        {}
+func (PostSkipped) event()       {}
+func (DetailStarted) event()     {}
+func (DetailFinished) event()    {}
+func (RetryScheduled) event()    {}
+func (RequestDone) event()       {}
+func (RateLimited) event()       {}
+func (FallbackTriggered) event() {}
@@ -0,0 +1,25 @@
+package event
+
+import "testing"
+
+func TestMulti(t *testing.T) {
+	if Multi() != nil || Multi(nil, nil) != nil {
+		t.Fatal("Multi without observers must return nil")
+	}
+
+	var first, second []Event
+	single := Func(func(e Event) { first = append(first, e) })
+	if got := Multi(nil, single); got == nil {
+		t.Fatal("Multi must keep a single observer")
+	}
+
+	both := Multi(single, nil, Func(func(e Event) { second = append(second, e) }))
+	both.Observe(PageFetched{Page: 1})
+	both.Observe(PostSkipped{Reason: "date before cutoff"})
+	if len(first) != 2 || len(second) != 2 {
+		t.Fatalf("every observer must see every event, got %d and %d", len(first), len(second))
+	}
+	if _, ok := second[1].(PostSkipped); !ok {
+		t.Fatalf("events must arrive in order, got %+v", second)
+	}
+}
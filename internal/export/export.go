@@ -0,0 +1,151 @@
+// Package export writes posts as JSON Lines or CSV, one row at a time,
+// so output can be produced while a crawl is still running.
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"git.skobk.in/skobkin/jnovel-scrape/internal/markdown"
+	"git.skobk.in/skobkin/jnovel-scrape/internal/model"
+	"git.skobk.in/skobkin/jnovel-scrape/internal/util"
+)
+
+// RowWriter writes posts one at a time. Flush must be called after the
+// last post; rows are flushed as they are written so a reader of a pipe
+// sees them straight away.
+type RowWriter interface {
+	Write(post model.Post) error
+	Flush() error
+}
+
+// JSONLWriter writes every post as one JSON object per line, with the
+// same fields as the --state file.
+type JSONLWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+// NewJSONLWriter returns a JSONLWriter writing to w.
+func NewJSONLWriter(w io.Writer) *JSONLWriter {
+	buf := bufio.NewWriter(w)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+
+	return &JSONLWriter{w: buf, enc: enc}
+}
+
+// Write encodes post as a line.
+func (j *JSONLWriter) Write(post model.Post) error {
+	if err := j.enc.Encode(post); err != nil {
+		return err
+	}
+
+	return j.w.Flush()
+}
+
+// Flush writes any buffered data.
+func (j *JSONLWriter) Flush() error {
+	return j.w.Flush()
+}
+
+// CSVWriter writes posts as CSV rows using a column layout. The header
+// row names the columns by their --columns keys; values are plain text,
+// links and covers as bare URLs.
+type CSVWriter struct {
+	w       *csv.Writer
+	columns []markdown.Column
+	header  bool
+}
+
+// NewCSVWriter returns a CSVWriter writing to w. An empty layout falls
+// back to markdown.DefaultColumns.
+func NewCSVWriter(w io.Writer, columns []markdown.Column) (*CSVWriter, error) {
+	if len(columns) == 0 {
+		columns = markdown.DefaultColumns
+	}
+	for _, column := range columns {
+		if _, err := csvValue(column, model.Post{}); err != nil {
+			return nil, err
+		}
+	}
+
+	return &CSVWriter{w: csv.NewWriter(w), columns: columns}, nil
+}
+
+// Write writes post as a row, preceded by the header row on the first
+// call.
+func (c *CSVWriter) Write(post model.Post) error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	row := make([]string, len(c.columns))
+	for i, column := range c.columns {
+		row[i], _ = csvValue(column, post)
+	}
+	if err := c.w.Write(row); err != nil {
+		return err
+	}
+	c.w.Flush()
+
+	return c.w.Error()
+}
+
+// Flush writes the header row if no post was written, and any buffered
+// data.
+func (c *CSVWriter) Flush() error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	c.w.Flush()
+
+	return c.w.Error()
+}
+
+func (c *CSVWriter) writeHeader() error {
+	if c.header {
+		return nil
+	}
+	c.header = true
+	header := make([]string, len(c.columns))
+	for i, column := range c.columns {
+		header[i] = string(column)
+	}
+
+	return c.w.Write(header)
+}
+
+func csvValue(column markdown.Column, post model.Post) (string, error) {
+	switch column {
+	case markdown.ColumnTitle:
+		return post.Title, nil
+	case markdown.ColumnVolume:
+		return util.FormatVolumeWithExtra(post.Volume, post.VolumeExtra), nil
+	case markdown.ColumnType:
+		return string(post.Type), nil
+	case markdown.ColumnDate:
+		return post.FormatDate(), nil
+	case markdown.ColumnDateSource:
+		return string(post.DateSource), nil
+	case markdown.ColumnLink:
+		return post.Link, nil
+	case markdown.ColumnCover:
+		return post.CoverURL, nil
+	case markdown.ColumnAuthor:
+		return post.Author, nil
+	case markdown.ColumnIllustrator:
+		return post.Illustrator, nil
+	case markdown.ColumnPublisher:
+		return post.Publisher, nil
+	case markdown.ColumnAltTitles:
+		return strings.Join(post.AltTitles, "; "), nil
+	case markdown.ColumnSummary:
+		return post.Summary, nil
+	default:
+		return "", fmt.Errorf("unknown column %q", column)
+	}
+}
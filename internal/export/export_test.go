@@ -0,0 +1,96 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"git.skobk.in/skobkin/jnovel-scrape/internal/markdown"
+	"git.skobk.in/skobkin/jnovel-scrape/internal/model"
+)
+
+func TestJSONLWriter(t *testing.T) {
+	var buf bytes.Buffer
+	date := time.Date(2025, time.February, 2, 0, 0, 0, 0, time.UTC)
+	writer := NewJSONLWriter(&buf)
+	for _, post := range []model.Post{
+		{Title: "Mage & Academy", Type: model.TypeEPUB, Date: date, Link: "https://example.com/a"},
+		{Title: "Other", Type: model.TypePDF, Date: date, Link: "https://example.com/b"},
+	} {
+		if err := writer.Write(post); err != nil {
+			t.Fatalf("Write() error: %v", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("Flush() error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", buf.String())
+	}
+	if !strings.Contains(lines[0], `"title":"Mage & Academy"`) {
+		t.Fatalf("HTML characters must not be escaped: %s", lines[0])
+	}
+	var post model.Post
+	if err := json.Unmarshal([]byte(lines[1]), &post); err != nil {
+		t.Fatalf("line is not a post: %v", err)
+	}
+	if post.Title != "Other" || !post.Date.Equal(date) {
+		t.Fatalf("unexpected post: %+v", post)
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	var buf bytes.Buffer
+	v := 4.0
+	posts := []model.Post{{
+		Title:       "Mage, Academy",
+		Volume:      &v,
+		VolumeExtra: "Act 1",
+		Type:        model.TypeEPUB,
+		Date:        time.Date(2025, time.February, 2, 0, 0, 0, 0, time.UTC),
+		Link:        "https://example.com/mage-academy",
+		AltTitles:   []string{"Alt One", "Alt Two"},
+	}}
+
+	writer, err := NewCSVWriter(&buf, []markdown.Column{markdown.ColumnTitle, markdown.ColumnVolume, markdown.ColumnLink, markdown.ColumnAltTitles})
+	if err != nil {
+		t.Fatalf("NewCSVWriter() error: %v", err)
+	}
+	for _, post := range posts {
+		if err := writer.Write(post); err != nil {
+			t.Fatalf("Write() error: %v", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("Flush() error: %v", err)
+	}
+
+	want := "title,volume,link,alt-titles\n\"Mage, Academy\",4 Act 1,https://example.com/mage-academy,Alt One; Alt Two\n"
+	if buf.String() != want {
+		t.Fatalf("unexpected CSV:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestCSVWriterHeaderOnly(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := NewCSVWriter(&buf, nil)
+	if err != nil {
+		t.Fatalf("NewCSVWriter() error: %v", err)
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("Flush() error: %v", err)
+	}
+	if buf.String() != "title,volume,type,date,link\n" {
+		t.Fatalf("unexpected CSV: %q", buf.String())
+	}
+}
+
+func TestCSVWriterRejectsUnknownColumn(t *testing.T) {
+	if _, err := NewCSVWriter(&bytes.Buffer{}, []markdown.Column{"bogus"}); err == nil {
+		t.Fatal("expected an error for an unknown column")
+	}
+}
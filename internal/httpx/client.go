@@ -3,11 +3,14 @@ package httpx
 import (
 	"context"
 	"fmt"
+	"io"
 	mathrand "math/rand"
 	"net/http"
 	"strconv"
 	"sync"
 	"time"
+
+	"git.skobk.in/skobkin/jnovel-scrape/internal/event"
 )
 
 // Client wraps http.Client with global rate limiting and retry logic.
@@ -18,6 +21,7 @@ type Client struct {
 	limitWait    time.Duration
 	maxRetries   int
 	jitterFactor float64
+	observer     event.Observer
 
 	randSrc *mathrand.Rand
 	randMu  sync.Mutex
@@ -53,6 +57,13 @@ func WithJitterFactor(f float64) ClientOption {
 	}
 }
 
+// WithObserver reports requests, retries and rate-limit waits to o.
+func WithObserver(o event.Observer) ClientOption {
+	return func(c *Client) {
+		c.observer = o
+	}
+}
+
 // NewClient builds a Client with sensible defaults.
 func NewClient(reqInterval, limitWait time.Duration, opts ...ClientOption) *Client {
 	if reqInterval <= 0 {
@@ -93,16 +104,18 @@ func (c *Client) Do(ctx context.Context, req *http.Request) (*http.Response, err
 		clone := cloneRequest(ctx, req)
 		resp, err := c.client.Do(clone)
 		if err != nil {
+			c.observe(event.RequestDone{URL: req.URL.String(), Err: err})
 			lastErr = err
 			if attempt == c.maxRetries {
 				return nil, lastErr
 			}
-			if err := c.sleepWithBackoff(ctx, attempt); err != nil {
+			if err := c.retry(ctx, req, attempt, lastErr); err != nil {
 				return nil, err
 			}
 
 			continue
 		}
+		c.countBody(req, resp)
 
 		switch resp.StatusCode {
 		case http.StatusTooManyRequests, http.StatusServiceUnavailable:
@@ -111,7 +124,9 @@ func (c *Client) Do(ctx context.Context, req *http.Request) (*http.Response, err
 			if attempt == c.maxRetries {
 				return nil, fmt.Errorf("retries exhausted after %d attempts (status %s)", attempt+1, resp.Status)
 			}
-			if err := c.sleep(ctx, wait); err != nil {
+			wait = c.jitter(wait)
+			c.observe(event.RateLimited{URL: req.URL.String(), Status: resp.StatusCode, Wait: wait})
+			if err := c.pause(ctx, wait); err != nil {
 				return nil, err
 			}
 
@@ -124,7 +139,7 @@ func (c *Client) Do(ctx context.Context, req *http.Request) (*http.Response, err
 			if attempt == c.maxRetries {
 				return nil, lastErr
 			}
-			if err := c.sleepWithBackoff(ctx, attempt); err != nil {
+			if err := c.retry(ctx, req, attempt, lastErr); err != nil {
 				return nil, err
 			}
 
@@ -140,7 +155,15 @@ func (c *Client) Do(ctx context.Context, req *http.Request) (*http.Response, err
 	return nil, lastErr
 }
 
-func (c *Client) sleepWithBackoff(ctx context.Context, attempt int) error {
+// retry reports a failed attempt and waits out its backoff.
+func (c *Client) retry(ctx context.Context, req *http.Request, attempt int, err error) error {
+	wait := c.jitter(c.backoff(attempt))
+	c.observe(event.RetryScheduled{URL: req.URL.String(), Attempt: attempt + 1, Wait: wait, Err: err})
+
+	return c.pause(ctx, wait)
+}
+
+func (c *Client) backoff(attempt int) time.Duration {
 	if attempt < 0 {
 		attempt = 0
 	}
@@ -156,15 +179,21 @@ func (c *Client) sleepWithBackoff(ctx context.Context, attempt int) error {
 		backoff = c.limitWait
 	}
 
-	return c.sleep(ctx, backoff)
+	return backoff
 }
 
-func (c *Client) sleep(ctx context.Context, base time.Duration) error {
+// jitter applies the jitter factor to a wait, using the request
+// interval for a wait that is not positive.
+func (c *Client) jitter(base time.Duration) time.Duration {
 	if base <= 0 {
 		base = c.reqInterval
 	}
-	jittered := c.applyJitter(base)
-	timer := time.NewTimer(jittered)
+
+	return c.applyJitter(base)
+}
+
+func (c *Client) pause(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
 	defer func() { _ = timer.Stop() }()
 	select {
 	case <-timer.C:
@@ -174,6 +203,42 @@ func (c *Client) sleep(ctx context.Context, base time.Duration) error {
 	}
 }
 
+// countBody makes closing resp.Body report the round trip with the
+// number of bytes read.
+func (c *Client) countBody(req *http.Request, resp *http.Response) {
+	if c.observer == nil {
+		return
+	}
+	resp.Body = &countingBody{ReadCloser: resp.Body, done: event.RequestDone{URL: req.URL.String(), Status: resp.StatusCode}, client: c}
+}
+
+type countingBody struct {
+	io.ReadCloser
+	done   event.RequestDone
+	client *Client
+	once   sync.Once
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.done.Bytes += int64(n)
+
+	return n, err
+}
+
+func (b *countingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() { b.client.observe(b.done) })
+
+	return err
+}
+
+func (c *Client) observe(e event.Event) {
+	if c.observer != nil {
+		c.observer.Observe(e)
+	}
+}
+
 func (c *Client) applyJitter(d time.Duration) time.Duration {
 	if d <= 0 || c.jitterFactor <= 0 {
 		return d
@@ -8,6 +8,8 @@ import (
 	"sync/atomic"
 	"testing"
 	"time"
+
+	"git.skobk.in/skobkin/jnovel-scrape/internal/event"
 )
 
 func TestClientDoRetriesOnServerError(t *testing.T) {
@@ -101,3 +103,97 @@ func TestClientExhaustsRetries(t *testing.T) {
 		t.Fatalf("expected error after retries exhausted")
 	}
 }
+
+func TestClientReportsRetriesAndRateLimits(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&attempts, 1) {
+		case 1:
+			http.Error(w, "temporary", http.StatusBadGateway)
+		case 2:
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+		default:
+			_, _ = io.WriteString(w, "ok")
+		}
+	}))
+	defer server.Close()
+
+	var events []event.Event
+	client := NewClient(2*time.Millisecond, 5*time.Millisecond,
+		WithHTTPClient(server.Client()),
+		WithJitterFactor(0),
+		WithObserver(event.Func(func(e event.Event) {
+			if _, ok := e.(event.RequestDone); !ok {
+				events = append(events, e)
+			}
+		})),
+	)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	resp, err := client.Do(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Do() returned error: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if len(events) != 2 {
+		t.Fatalf("expected a retry and a rate-limit event, got %+v", events)
+	}
+	retry, ok := events[0].(event.RetryScheduled)
+	if !ok || retry.Attempt != 1 || retry.Wait != 2*time.Millisecond || retry.Err == nil || retry.SecondPass {
+		t.Fatalf("unexpected retry event: %+v", events[0])
+	}
+	limited, ok := events[1].(event.RateLimited)
+	if !ok || limited.Status != http.StatusTooManyRequests || limited.Wait != 2*time.Millisecond || limited.URL != server.URL {
+		t.Fatalf("unexpected rate-limit event: %+v", events[1])
+	}
+}
+
+func TestClientReportsRequests(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			http.Error(w, "temporary", http.StatusBadGateway)
+
+			return
+		}
+		_, _ = io.WriteString(w, "hello")
+	}))
+	defer server.Close()
+
+	var done []event.RequestDone
+	client := NewClient(1*time.Millisecond, 5*time.Millisecond,
+		WithHTTPClient(server.Client()),
+		WithJitterFactor(0),
+		WithObserver(event.Func(func(e event.Event) {
+			if e, ok := e.(event.RequestDone); ok {
+				done = append(done, e)
+			}
+		})),
+	)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	resp, err := client.Do(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Do() returned error: %v", err)
+	}
+	if len(done) != 1 || done[0].Status != http.StatusBadGateway {
+		t.Fatalf("expected the failed attempt to be reported before the body is read, got %+v", done)
+	}
+	if _, err := io.ReadAll(resp.Body); err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	_ = resp.Body.Close()
+	_ = resp.Body.Close()
+
+	if len(done) != 2 || done[1].Status != http.StatusOK || done[1].Bytes != 5 || done[1].URL != server.URL {
+		t.Fatalf("expected the successful attempt reported once with its size, got %+v", done)
+	}
+}
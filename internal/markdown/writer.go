@@ -3,31 +3,149 @@ package markdown
 import (
 	"fmt"
 	"io"
+	"strings"
 	"time"
 
 	"git.skobk.in/skobkin/jnovel-scrape/internal/model"
 	"git.skobk.in/skobkin/jnovel-scrape/internal/util"
 )
 
+// Column identifies a table column.
+type Column string
+
+const (
+	// ColumnTitle is the cleaned post title.
+	ColumnTitle Column = "title"
+	// ColumnVolume is the parsed volume with any extra suffix.
+	ColumnVolume Column = "volume"
+	// ColumnType is the inferred post type.
+	ColumnType Column = "type"
+	// ColumnDate is the publish date (YYYY-MM-DD).
+	ColumnDate Column = "date"
+	// ColumnLink links to the post.
+	ColumnLink Column = "link"
+	// ColumnDateSource names where the date was read from.
+	ColumnDateSource Column = "date-source"
+	// ColumnCover embeds the cover image when one is known.
+	ColumnCover Column = "cover"
+	// ColumnAuthor is the author parsed from the post body.
+	ColumnAuthor Column = "author"
+	// ColumnIllustrator is the illustrator parsed from the post body.
+	ColumnIllustrator Column = "illustrator"
+	// ColumnPublisher is the publisher or translation label.
+	ColumnPublisher Column = "publisher"
+	// ColumnAltTitles lists alternative and original titles.
+	ColumnAltTitles Column = "alt-titles"
+	// ColumnSummary is the synopsis parsed from the post body.
+	ColumnSummary Column = "summary"
+)
+
+// DefaultColumns is the historical table layout.
+var DefaultColumns = []Column{ColumnTitle, ColumnVolume, ColumnType, ColumnDate, ColumnLink}
+
+// KnownColumns lists every column WriteTableColumns can render.
+var KnownColumns = []Column{
+	ColumnTitle, ColumnVolume, ColumnType, ColumnDate, ColumnLink, ColumnCover,
+	ColumnAuthor, ColumnIllustrator, ColumnPublisher, ColumnAltTitles, ColumnSummary,
+	ColumnDateSource,
+}
+
+// MetadataColumns are the columns filled from book metadata in the post
+// body.
+var MetadataColumns = []Column{ColumnAuthor, ColumnIllustrator, ColumnPublisher, ColumnAltTitles, ColumnSummary}
+
+type columnSpec struct {
+	header string
+	align  string
+	cell   func(model.Post) string
+}
+
+var columnSpecs = map[Column]columnSpec{
+	ColumnTitle: {"Title", "---", func(p model.Post) string { return util.EscapePipes(p.Title) }},
+	ColumnVolume: {"Volume", "---:", func(p model.Post) string {
+		return util.FormatVolumeWithExtra(p.Volume, p.VolumeExtra)
+	}},
+	ColumnType:       {"Type", "---", func(p model.Post) string { return string(p.Type) }},
+	ColumnDate:       {"Date", "---", func(p model.Post) string { return p.FormatDate() }},
+	ColumnDateSource: {"Date Source", "---", func(p model.Post) string { return string(p.DateSource) }},
+	ColumnLink:       {"Link", "---", func(p model.Post) string { return fmt.Sprintf("[link](%s)", p.Link) }},
+	ColumnCover: {"Cover", "---", func(p model.Post) string {
+		if p.CoverURL == "" {
+			return ""
+		}
+
+		return fmt.Sprintf("![cover](%s)", p.CoverURL)
+	}},
+	ColumnAuthor:      {"Author", "---", func(p model.Post) string { return util.EscapePipes(p.Author) }},
+	ColumnIllustrator: {"Illustrator", "---", func(p model.Post) string { return util.EscapePipes(p.Illustrator) }},
+	ColumnPublisher:   {"Publisher", "---", func(p model.Post) string { return util.EscapePipes(p.Publisher) }},
+	ColumnAltTitles: {"Alt Titles", "---", func(p model.Post) string {
+		return util.EscapePipes(strings.Join(p.AltTitles, "; "))
+	}},
+	ColumnSummary: {"Summary", "---", func(p model.Post) string { return util.EscapePipes(p.Summary) }},
+}
+
 // WriteTable writes the Markdown output to the provided writer.
 func WriteTable(w io.Writer, cutoff time.Time, posts model.Posts) error {
-	if _, err := fmt.Fprintf(w, "Generated from jnovels.com (cutoff: %s)\n\n", cutoff.Format("2006-01-02")); err != nil {
+	return WriteTableColumns(w, cutoff, posts, DefaultColumns)
+}
+
+// WriteTableColumns writes the Markdown output using the given column
+// layout. An empty layout falls back to DefaultColumns.
+func WriteTableColumns(w io.Writer, cutoff time.Time, posts model.Posts, columns []Column) error {
+	return WriteDocument(w, Header{Cutoff: cutoff}, posts, columns)
+}
+
+// Header describes the run a table was generated from.
+type Header struct {
+	Cutoff time.Time
+	// Incomplete marks the output of an interrupted crawl, which may
+	// miss posts.
+	Incomplete bool
+}
+
+// WriteDocument writes the header line and the table using the given
+// column layout. An empty layout falls back to DefaultColumns.
+func WriteDocument(w io.Writer, header Header, posts model.Posts, columns []Column) error {
+	if len(columns) == 0 {
+		columns = DefaultColumns
+	}
+	specs := make([]columnSpec, 0, len(columns))
+	for _, column := range columns {
+		spec, ok := columnSpecs[column]
+		if !ok {
+			return fmt.Errorf("unknown column %q", column)
+		}
+		specs = append(specs, spec)
+	}
+
+	if _, err := fmt.Fprintf(w, "Generated from jnovels.com (cutoff: %s)\n\n", header.Cutoff.Format("2006-01-02")); err != nil {
 		return err
 	}
-	if _, err := fmt.Fprintln(w, "| Title | Volume | Type | Date | Link |"); err != nil {
+	if header.Incomplete {
+		if _, err := fmt.Fprint(w, "**INCOMPLETE:** the crawl was interrupted; posts may be missing.\n\n"); err != nil {
+			return err
+		}
+	}
+	headers := make([]string, len(specs))
+	aligns := make([]string, len(specs))
+	for i, spec := range specs {
+		headers[i] = spec.header
+		aligns[i] = spec.align
+	}
+	if _, err := fmt.Fprintf(w, "| %s |\n", strings.Join(headers, " | ")); err != nil {
 		return err
 	}
-	if _, err := fmt.Fprintln(w, "|---|---:|---|---|---|"); err != nil {
+	if _, err := fmt.Fprintf(w, "|%s|\n", strings.Join(aligns, "|")); err != nil {
 		return err
 	}
 
+	cells := make([]string, len(specs))
 	for _, post := range posts {
-		title := util.EscapePipes(post.Title)
-		volume := util.FormatVolumeWithExtra(post.Volume, post.VolumeExtra)
-		date := post.FormatDate()
-		link := fmt.Sprintf("[link](%s)", post.Link)
-		line := fmt.Sprintf("| %s | %s | %s | %s | %s |", title, volume, post.Type, date, link)
-		if _, err := fmt.Fprintln(w, line); err != nil {
+		for i, spec := range specs {
+			cells[i] = spec.cell(post)
+		}
+		if _, err := fmt.Fprintf(w, "| %s |\n", strings.Join(cells, " | ")); err != nil {
 			return err
 		}
 	}
@@ -40,3 +40,44 @@ func TestWriteTable(t *testing.T) {
 		t.Fatalf("unexpected table row, want substring %s\noutput:\n%s", expectedRow, output)
 	}
 }
+
+func TestWriteTableColumnsWithCover(t *testing.T) {
+	var buf bytes.Buffer
+	cutoff := time.Date(2025, time.February, 2, 0, 0, 0, 0, time.UTC)
+	posts := model.Posts{
+		{Title: "With Cover", Type: model.TypePDF, Date: cutoff, Link: "https://example.com/a", CoverURL: "https://example.com/a.jpg"},
+		{Title: "Without Cover", Type: model.TypePDF, Date: cutoff, Link: "https://example.com/b"},
+	}
+
+	columns := []Column{ColumnCover, ColumnTitle, ColumnLink}
+	if err := WriteTableColumns(&buf, cutoff, posts, columns); err != nil {
+		t.Fatalf("WriteTableColumns error: %v", err)
+	}
+
+	output := buf.String()
+	for _, want := range []string{
+		"| Cover | Title | Link |\n|---|---|---|\n",
+		"| ![cover](https://example.com/a.jpg) | With Cover | [link](https://example.com/a) |",
+		"|  | Without Cover | [link](https://example.com/b) |",
+	} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("output missing %q:\n%s", want, output)
+		}
+	}
+
+	if err := WriteTableColumns(&buf, cutoff, posts, []Column{"rating"}); err == nil {
+		t.Fatalf("expected error for unknown column")
+	}
+}
+
+func TestWriteDocumentIncomplete(t *testing.T) {
+	var buf bytes.Buffer
+	cutoff := time.Date(2025, time.February, 2, 0, 0, 0, 0, time.UTC)
+	if err := WriteDocument(&buf, Header{Cutoff: cutoff, Incomplete: true}, nil, nil); err != nil {
+		t.Fatalf("WriteDocument error: %v", err)
+	}
+	want := "Generated from jnovels.com (cutoff: 2025-02-02)\n\n**INCOMPLETE:** the crawl was interrupted; posts may be missing.\n\n| Title |"
+	if !strings.HasPrefix(buf.String(), want) {
+		t.Fatalf("unexpected header:\n%s", buf.String())
+	}
+}
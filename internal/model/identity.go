@@ -0,0 +1,111 @@
+package model
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// trackingParams are query parameters that never change which post a
+// link points at.
+var trackingParams = map[string]struct{}{
+	"fbclid":  {},
+	"gclid":   {},
+	"mc_cid":  {},
+	"mc_eid":  {},
+	"ref":     {},
+	"amp":     {},
+	"share":   {},
+	"_ga":     {},
+	"msclkid": {},
+}
+
+// CanonicalLink normalises a post URL for identity comparisons: the
+// scheme and host are lower-cased, default ports, fragments, trailing
+// slashes and tracking parameters (utm_*, fbclid, ...) are dropped, and
+// the remaining query parameters are sorted. Links that do not parse are
+// returned trimmed but otherwise unchanged.
+func CanonicalLink(link string) string {
+	link = strings.TrimSpace(link)
+	parsed, err := url.Parse(link)
+	if err != nil || parsed.Host == "" {
+		return link
+	}
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	if parsed.Scheme == "http" {
+		// The site redirects http to https; both name the same post.
+		parsed.Scheme = "https"
+	}
+	host := strings.ToLower(parsed.Hostname())
+	if port := parsed.Port(); port != "" && port != "80" && port != "443" {
+		host += ":" + port
+	}
+	parsed.Host = host
+	parsed.Fragment = ""
+	parsed.RawFragment = ""
+	parsed.Path = strings.TrimRight(parsed.Path, "/")
+	parsed.RawPath = ""
+
+	query := parsed.Query()
+	for key := range query {
+		if _, ok := trackingParams[strings.ToLower(key)]; ok || strings.HasPrefix(strings.ToLower(key), "utm_") {
+			query.Del(key)
+		}
+	}
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		for _, value := range query[key] {
+			parts = append(parts, url.QueryEscape(key)+"="+url.QueryEscape(value))
+		}
+	}
+	parsed.RawQuery = strings.Join(parts, "&")
+
+	return parsed.String()
+}
+
+// IdentitySet remembers posts by SourceID and by canonical link so that
+// the same post is recognised whether or not a collector managed to read
+// its numeric ID.
+type IdentitySet struct {
+	ids   map[int64]struct{}
+	links map[string]struct{}
+}
+
+// NewIdentitySet returns an empty IdentitySet.
+func NewIdentitySet() *IdentitySet {
+	return &IdentitySet{
+		ids:   make(map[int64]struct{}),
+		links: make(map[string]struct{}),
+	}
+}
+
+// Seen reports whether a post with the same SourceID or canonical link
+// was added before.
+func (s *IdentitySet) Seen(p Post) bool {
+	if p.SourceID != 0 {
+		if _, ok := s.ids[p.SourceID]; ok {
+			return true
+		}
+	}
+	_, ok := s.links[CanonicalLink(p.Link)]
+
+	return ok
+}
+
+// Add records p and reports whether it was new.
+func (s *IdentitySet) Add(p Post) bool {
+	if s.Seen(p) {
+		return false
+	}
+	if p.SourceID != 0 {
+		s.ids[p.SourceID] = struct{}{}
+	}
+	s.links[CanonicalLink(p.Link)] = struct{}{}
+
+	return true
+}
@@ -0,0 +1,37 @@
+package model
+
+import "testing"
+
+func TestCanonicalLink(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{"https://JNovels.com/Hero-Volume-2/", "https://jnovels.com/Hero-Volume-2"},
+		{"http://jnovels.com:80/hero/#comments", "https://jnovels.com/hero"},
+		{"https://jnovels.com/hero/?utm_source=x&fbclid=y", "https://jnovels.com/hero"},
+		{"https://jnovels.com/?p=12&b=2&utm_medium=z", "https://jnovels.com?b=2&p=12"},
+		{"not a url", "not a url"},
+	}
+	for _, tc := range cases {
+		if got := CanonicalLink(tc.in); got != tc.want {
+			t.Fatalf("CanonicalLink(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestIdentitySetMatchesByIDOrLink(t *testing.T) {
+	set := NewIdentitySet()
+	if !set.Add(Post{SourceID: 10, Link: "https://jnovels.com/a/"}) {
+		t.Fatal("first post should be new")
+	}
+	if set.Add(Post{SourceID: 10, Link: "https://jnovels.com/a-renamed/"}) {
+		t.Fatal("same ID with a new slug should be a duplicate")
+	}
+	if set.Add(Post{Link: "https://jnovels.com/a?utm_source=feed"}) {
+		t.Fatal("same canonical link without an ID should be a duplicate")
+	}
+	if !set.Add(Post{SourceID: 11, Link: "https://jnovels.com/b/"}) {
+		t.Fatal("different post should be new")
+	}
+}
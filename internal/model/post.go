@@ -47,17 +47,57 @@ func NormalizeType(token string) PostType {
 	}
 }
 
+// DateSource records where a post's publish date was read from.
+type DateSource string
+
+const (
+	// DateSourceAPI is the REST API date_gmt/date field.
+	DateSourceAPI DateSource = "api"
+	// DateSourceArchive is the date selector matched inside the
+	// archive block, when the detail page was not fetched.
+	DateSourceArchive DateSource = "archive"
+	// DateSourceSelector is the HTML date selector (<time datetime>,
+	// article:published_time by default).
+	DateSourceSelector DateSource = "selector"
+	// DateSourceJSONLD is a schema.org datePublished in JSON-LD.
+	DateSourceJSONLD DateSource = "json-ld"
+	// DateSourceMicrodata is an itemprop="datePublished" element.
+	DateSourceMicrodata DateSource = "microdata"
+	// DateSourceText is a date written out in the page text.
+	DateSourceText DateSource = "text"
+	// DateSourceModified is a last-modified timestamp (JSON-LD
+	// dateModified, og:updated_time, article:modified_time) used
+	// because no publish date was found.
+	DateSourceModified DateSource = "modified"
+)
+
 // Post holds the normalized metadata for a jnovels post.
 type Post struct {
-	Title       string
-	Volume      *float64
-	VolumeExtra string
-	Type        PostType
-	Date        time.Time
-	Link        string
-	SourceID    int64
-	Categories  []string
-	Tags        []string
+	Title       string     `json:"title"`
+	Volume      *float64   `json:"volume,omitempty"`
+	VolumeExtra string     `json:"volume_extra,omitempty"`
+	Type        PostType   `json:"type"`
+	Date        time.Time  `json:"date"`
+	DateSource  DateSource `json:"date_source,omitempty"`
+	// Modified is when the post was last edited; zero when the source
+	// does not say.
+	Modified   time.Time `json:"modified,omitzero"`
+	Link       string    `json:"link"`
+	SourceID   int64     `json:"id,omitempty"`
+	Categories []string  `json:"categories,omitempty"`
+	Tags       []string  `json:"tags,omitempty"`
+	// CoverURL points at the post's cover image; CoverWidth and
+	// CoverHeight are zero when the source did not report them.
+	CoverURL    string `json:"cover_url,omitempty"`
+	CoverWidth  int    `json:"cover_width,omitempty"`
+	CoverHeight int    `json:"cover_height,omitempty"`
+	// Book metadata parsed from the post body; empty when the post
+	// does not list it.
+	Author      string   `json:"author,omitempty"`
+	Illustrator string   `json:"illustrator,omitempty"`
+	Publisher   string   `json:"publisher,omitempty"`
+	AltTitles   []string `json:"alt_titles,omitempty"`
+	Summary     string   `json:"summary,omitempty"`
 }
 
 // HasVolume returns true when the post has a parsed volume number.
@@ -0,0 +1,212 @@
+package util
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// BookMeta holds the release details commonly listed in a post body.
+type BookMeta struct {
+	Author      string
+	Illustrator string
+	Publisher   string
+	AltTitles   []string
+	Summary     string
+}
+
+type metaField int
+
+const (
+	metaAuthor metaField = iota + 1
+	metaIllustrator
+	metaPublisher
+	metaTranslator
+	metaAltTitle
+	metaSummary
+)
+
+// maxSummaryLen caps the synopsis so a missing terminator cannot pull a
+// whole post body into one table cell.
+const maxSummaryLen = 1500
+
+var (
+	blockBreakPattern = regexp.MustCompile(`(?i)<br\s*/?>|</?(?:p|div|li|ul|ol|h[1-6]|tr|blockquote)\b[^>]*>`)
+	metaLabelPattern  = regexp.MustCompile(`(?i)^(?:[*_\s]*)(author|writer|written by|story by|illustrator|illustrated by|illustrations?|artist|art by|publisher|published by|label|translator|translated by|translation group|translation|japanese title|original title|native title|alternative titles?|alternate titles?|alt titles?|associated names|also known as|synopsis|summary|description|plot)(?:[*_\s]*)(?:[:：]|\s[-–—]\s)[*_\s]*(.*)$`)
+	summaryOnlyLabels = map[string]struct{}{"synopsis": {}, "summary": {}, "description": {}, "plot": {}}
+	altTitleSplitter  = regexp.MustCompile(`\s*(?:;|\s\|\s|\s/\s)\s*`)
+)
+
+var metaLabels = map[string]metaField{
+	"author":             metaAuthor,
+	"writer":             metaAuthor,
+	"written by":         metaAuthor,
+	"story by":           metaAuthor,
+	"illustrator":        metaIllustrator,
+	"illustrated by":     metaIllustrator,
+	"illustration":       metaIllustrator,
+	"illustrations":      metaIllustrator,
+	"artist":             metaIllustrator,
+	"art by":             metaIllustrator,
+	"publisher":          metaPublisher,
+	"published by":       metaPublisher,
+	"label":              metaPublisher,
+	"translator":         metaTranslator,
+	"translated by":      metaTranslator,
+	"translation group":  metaTranslator,
+	"translation":        metaTranslator,
+	"japanese title":     metaAltTitle,
+	"original title":     metaAltTitle,
+	"native title":       metaAltTitle,
+	"alternative title":  metaAltTitle,
+	"alternative titles": metaAltTitle,
+	"alternate title":    metaAltTitle,
+	"alternate titles":   metaAltTitle,
+	"alt title":          metaAltTitle,
+	"alt titles":         metaAltTitle,
+	"associated names":   metaAltTitle,
+	"also known as":      metaAltTitle,
+	"synopsis":           metaSummary,
+	"summary":            metaSummary,
+	"description":        metaSummary,
+	"plot":               metaSummary,
+}
+
+// ExtractBookMeta parses "Label: value" lines from a rendered post body.
+// The body is split into lines on block-level tags and <br>, so both
+// paragraph-per-field and <br>-separated layouts work. The synopsis may
+// follow its label on the same line or in the paragraphs after it, up
+// to the next recognised label.
+//
+// When a post names a translator but no publisher, the translator is
+// used as the publisher: the translation label is what readers track.
+func ExtractBookMeta(content string) BookMeta {
+	var (
+		meta       BookMeta
+		translator string
+		summary    []string
+		inSummary  bool
+	)
+	if strings.TrimSpace(content) == "" {
+		return meta
+	}
+
+	for _, line := range bodyLines(content) {
+		field, value, ok := parseMetaLine(line)
+		if !ok {
+			if inSummary {
+				if isSummaryTerminator(line) {
+					inSummary = false
+
+					continue
+				}
+				summary = append(summary, line)
+			}
+
+			continue
+		}
+		inSummary = false
+		switch field {
+		case metaAuthor:
+			meta.Author = firstNonEmpty(meta.Author, value)
+		case metaIllustrator:
+			meta.Illustrator = firstNonEmpty(meta.Illustrator, value)
+		case metaPublisher:
+			meta.Publisher = firstNonEmpty(meta.Publisher, value)
+		case metaTranslator:
+			translator = firstNonEmpty(translator, value)
+		case metaAltTitle:
+			meta.AltTitles = appendAltTitles(meta.AltTitles, value)
+		case metaSummary:
+			if len(summary) > 0 {
+				continue
+			}
+			inSummary = true
+			if value != "" {
+				summary = append(summary, value)
+			}
+		}
+	}
+
+	meta.Publisher = firstNonEmpty(meta.Publisher, translator)
+	meta.Summary = truncateSummary(strings.Join(summary, " "))
+
+	return meta
+}
+
+func bodyLines(content string) []string {
+	text := blockBreakPattern.ReplaceAllString(content, "\n")
+	text = html.UnescapeString(StripTags(text))
+	text = strings.ReplaceAll(text, "\u00a0", " ")
+
+	var lines []string
+	for _, raw := range strings.Split(text, "\n") {
+		line := whitespacePattern.ReplaceAllString(strings.TrimSpace(raw), " ")
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+func parseMetaLine(line string) (metaField, string, bool) {
+	if match := metaLabelPattern.FindStringSubmatch(line); match != nil {
+		field, ok := metaLabels[strings.ToLower(match[1])]
+		if ok {
+			return field, strings.TrimSpace(strings.Trim(match[2], "*_ ")), true
+		}
+	}
+	// A bare "Synopsis" heading introduces the paragraphs below it.
+	bare := strings.ToLower(strings.Trim(line, "*_:： "))
+	if _, ok := summaryOnlyLabels[bare]; ok {
+		return metaSummary, "", true
+	}
+
+	return 0, "", false
+}
+
+func isSummaryTerminator(line string) bool {
+	lower := strings.ToLower(line)
+
+	return strings.HasPrefix(lower, "download") || strings.HasPrefix(lower, "read online")
+}
+
+func appendAltTitles(titles []string, value string) []string {
+	for _, part := range altTitleSplitter.Split(value, -1) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		duplicate := false
+		for _, existing := range titles {
+			if FoldedEqual(existing, part) {
+				duplicate = true
+
+				break
+			}
+		}
+		if !duplicate {
+			titles = append(titles, part)
+		}
+	}
+
+	return titles
+}
+
+func truncateSummary(s string) string {
+	runes := []rune(strings.TrimSpace(s))
+	if len(runes) <= maxSummaryLen {
+		return string(runes)
+	}
+
+	return strings.TrimSpace(string(runes[:maxSummaryLen])) + "…"
+}
+
+func firstNonEmpty(current, candidate string) string {
+	if current != "" {
+		return current
+	}
+
+	return candidate
+}
@@ -0,0 +1,51 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractBookMetaParagraphs(t *testing.T) {
+	content := `<p><strong>Author:</strong> Kisetsu Morita</p>
+<p><strong>Illustrator:</strong> Benio</p>
+<p>Publisher: Yen On</p>
+<p>Japanese Title: スライム倒して300年 / Slime Taoshite 300-nen</p>
+<p><strong>Synopsis</strong></p>
+<p>Azusa spends three hundred years slaying slimes &amp; maxes out.</p>
+<p>Then things get busy.</p>
+<p>Download EPUB</p>`
+
+	got := ExtractBookMeta(content)
+	want := BookMeta{
+		Author:      "Kisetsu Morita",
+		Illustrator: "Benio",
+		Publisher:   "Yen On",
+		AltTitles:   []string{"スライム倒して300年", "Slime Taoshite 300-nen"},
+		Summary:     "Azusa spends three hundred years slaying slimes & maxes out. Then things get busy.",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ExtractBookMeta() = %+v, want %+v", got, want)
+	}
+}
+
+func TestExtractBookMetaLineBreaksAndTranslator(t *testing.T) {
+	content := `<p>Written by: Someone<br/>Art by – Another<br>Translated by: J-Novel Club<br>Summary: A short blurb.</p>`
+
+	got := ExtractBookMeta(content)
+	if got.Author != "Someone" || got.Illustrator != "Another" {
+		t.Fatalf("unexpected credits: %+v", got)
+	}
+	if got.Publisher != "J-Novel Club" {
+		t.Fatalf("translator should fill publisher, got %q", got.Publisher)
+	}
+	if got.Summary != "A short blurb." {
+		t.Fatalf("unexpected summary: %q", got.Summary)
+	}
+}
+
+func TestExtractBookMetaEmpty(t *testing.T) {
+	got := ExtractBookMeta("<p>Download links below.</p>")
+	if !reflect.DeepEqual(got, BookMeta{}) {
+		t.Fatalf("expected empty metadata, got %+v", got)
+	}
+}
@@ -0,0 +1,136 @@
+package util
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// monthNames maps folded month names and common abbreviations to months
+// for the locales WordPress themes ship with. Keys are compared after
+// FoldForSearch, so "février" and "fevrier" are the same entry, and
+// genitive forms ("января") are listed where the language uses them in
+// dates.
+var monthNames = buildMonthNames(map[time.Month][]string{
+	time.January: {
+		"january", "jan", "janvier", "janv", "januar", "jänner", "enero", "ene", "gennaio", "gen",
+		"janeiro", "januari", "styczeń", "stycznia", "январь", "января", "янв",
+	},
+	time.February: {
+		"february", "feb", "février", "févr", "februar", "febrero", "febbraio", "fevereiro", "fev",
+		"februari", "luty", "lutego", "февраль", "февраля", "фев",
+	},
+	time.March: {
+		"march", "mar", "mars", "märz", "marzo", "março", "maart", "marzec", "marca", "март", "марта",
+	},
+	time.April: {
+		"april", "apr", "avril", "avr", "abril", "abr", "aprile", "kwiecień", "kwietnia", "апрель", "апреля", "апр",
+	},
+	time.May: {
+		"may", "mai", "mayo", "maggio", "mag", "maio", "mei", "maj", "maja", "май", "мая",
+	},
+	time.June: {
+		"june", "jun", "juin", "juni", "junio", "giugno", "giu", "junho", "czerwiec", "czerwca", "июнь", "июня",
+	},
+	time.July: {
+		"july", "jul", "juillet", "juil", "juli", "julio", "luglio", "lug", "julho", "lipiec", "lipca", "июль", "июля",
+	},
+	time.August: {
+		"august", "aug", "août", "agosto", "ago", "augustus", "sierpień", "sierpnia", "август", "августа", "авг",
+	},
+	time.September: {
+		"september", "sep", "sept", "septembre", "septiembre", "settembre", "set", "setembro",
+		"wrzesień", "września", "сентябрь", "сентября", "сен",
+	},
+	time.October: {
+		"october", "oct", "octobre", "oktober", "okt", "octubre", "ottobre", "ott", "outubro", "out",
+		"październik", "października", "октябрь", "октября", "окт",
+	},
+	time.November: {
+		"november", "nov", "novembre", "noviembre", "novembro", "listopad", "listopada", "ноябрь", "ноября", "ноя",
+	},
+	time.December: {
+		"december", "dec", "décembre", "déc", "dezember", "dez", "diciembre", "dic", "dicembre", "dezembro",
+		"grudzień", "grudnia", "декабрь", "декабря", "дек",
+	},
+})
+
+// wordMonthNames are month names that are also everyday words in one of
+// the supported languages ("set", "out", "gen", "may"). Written month
+// first, they only count as a month when a period or the comma before
+// the year marks the date ("May 2, 2025", "Set. 2 2024"); day first
+// ("2 set 2024") is how these locales write dates anyway.
+var wordMonthNames = map[string]struct{}{
+	"may": {}, "mar": {}, "dec": {}, "set": {}, "out": {}, "gen": {}, "mag": {}, "ago": {},
+}
+
+var (
+	// "January 2, 2006", "Okt. 2 2006"
+	monthFirstPattern = regexp.MustCompile(`(\p{L}+)(\.?)\s+(\d{1,2})(?:st|nd|rd|th)?(,?)\s+(\d{4})(?:\D|$)`)
+	// "2 janvier 2006", "2. Oktober 2006", "2 de enero de 2006", "1er mai 2006"
+	dayFirstPattern = regexp.MustCompile(`(?:^|\D)(\d{1,2})(?:\.|º|er|st|nd|rd|th)?\s+(?:de\s+)?(\p{L}+)\.?,?\s+(?:de\s+)?(\d{4})(?:\D|$)`)
+	// "2006年1月2日", "2006년 1월 2일"
+	cjkDatePattern = regexp.MustCompile(`(\d{4})\s*[年년]\s*(\d{1,2})\s*[月월]\s*(\d{1,2})\s*[日일]`)
+)
+
+func buildMonthNames(byMonth map[time.Month][]string) map[string]time.Month {
+	out := make(map[string]time.Month)
+	for month, names := range byMonth {
+		for _, name := range names {
+			out[FoldForSearch(name)] = month
+		}
+	}
+
+	return out
+}
+
+// FindLocalizedDate returns the first calendar date written out in text,
+// in English or one of the common European locales ("October 10, 2025",
+// "10 octobre 2025", "10. Oktober 2025", "10 октября 2025") or in
+// Japanese/Korean numeric form. The result is midnight UTC.
+func FindLocalizedDate(text string) (time.Time, bool) {
+	type candidate struct {
+		at    int
+		year  string
+		month time.Month
+		day   string
+	}
+	var found []candidate
+
+	for _, m := range monthFirstPattern.FindAllStringSubmatchIndex(text, -1) {
+		name := FoldForSearch(text[m[2]:m[3]])
+		month, ok := monthNames[name]
+		if !ok {
+			continue
+		}
+		if _, word := wordMonthNames[name]; word && m[4] == m[5] && m[8] == m[9] {
+			continue
+		}
+		found = append(found, candidate{at: m[0], year: text[m[10]:m[11]], month: month, day: text[m[6]:m[7]]})
+	}
+	for _, m := range dayFirstPattern.FindAllStringSubmatchIndex(text, -1) {
+		if month, ok := monthNames[FoldForSearch(text[m[4]:m[5]])]; ok {
+			found = append(found, candidate{at: m[0], year: text[m[6]:m[7]], month: month, day: text[m[2]:m[3]]})
+		}
+	}
+	for _, m := range cjkDatePattern.FindAllStringSubmatchIndex(text, -1) {
+		number, _ := strconv.Atoi(text[m[4]:m[5]])
+		if number >= 1 && number <= 12 {
+			found = append(found, candidate{at: m[0], year: text[m[2]:m[3]], month: time.Month(number), day: text[m[6]:m[7]]})
+		}
+	}
+
+	sort.SliceStable(found, func(i, j int) bool { return found[i].at < found[j].at })
+	for _, c := range found {
+		year, _ := strconv.Atoi(c.year)
+		day, _ := strconv.Atoi(c.day)
+		date := time.Date(year, c.month, day, 0, 0, 0, 0, time.UTC)
+		// time.Date normalises 31 February into March; reject it.
+		if day >= 1 && date.Day() == day && date.Month() == c.month {
+			return date, true
+		}
+	}
+
+	return time.Time{}, false
+}
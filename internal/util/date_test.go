@@ -0,0 +1,55 @@
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFindLocalizedDate(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"english", "Published on October 10, 2025 by admin", "2025-10-10"},
+		{"english ordinal", "Posted Oct 3rd, 2025", "2025-10-03"},
+		{"english day first", "Posted 5 May 2024", "2024-05-05"},
+		{"french", "Publié le 1er février 2025", "2025-02-01"},
+		{"german", "Veröffentlicht am 2. Oktober 2025", "2025-10-02"},
+		{"spanish", "Publicado el 14 de diciembre de 2024", "2024-12-14"},
+		{"russian genitive", "Опубликовано 7 ноября 2025", "2025-11-07"},
+		{"japanese", "投稿日 2025年9月30日", "2025-09-30"},
+		{"first wins", "March 1, 2025 updated April 2, 2025", "2025-03-01"},
+		{"word month with comma", "Posted May 2, 2025", "2025-05-02"},
+		{"word month with period", "Pubblicato Set. 9 2024", "2024-09-09"},
+		{"word month day first", "Pubblicato il 9 set 2024", "2024-09-09"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := FindLocalizedDate(tc.in)
+			if !ok {
+				t.Fatalf("FindLocalizedDate(%q) found nothing", tc.in)
+			}
+			if got.Format("2006-01-02") != tc.want || got.Location() != time.UTC {
+				t.Fatalf("FindLocalizedDate(%q) = %v, want %s", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFindLocalizedDateRejectsNonDates(t *testing.T) {
+	for _, in := range []string{
+		"Volume 12 of 2025",
+		"February 30, 2025",
+		"Chapter 3 2025",
+		"you may 2 volumes",
+		"readers may 2 2025 releases",
+		"sold out 3 2024 copies",
+		"Vol 123 out 2024",
+		"October 10, 20251",
+	} {
+		if got, ok := FindLocalizedDate(in); ok {
+			t.Fatalf("FindLocalizedDate(%q) = %v, want no match", in, got)
+		}
+	}
+}
@@ -112,6 +112,14 @@ func FoldedContains(haystack, needle string) bool {
 	)
 }
 
+// FoldedEqual reports whether a and b are the same string under the
+// fold and whitespace normalisation used by FoldedContains. It suits
+// discrete labels such as tag and category names, where "Isekai"
+// should match "isekai" but not "Reverse Isekai".
+func FoldedEqual(a, b string) bool {
+	return FoldForSearch(NormalizeForSearch(a)) == FoldForSearch(NormalizeForSearch(b))
+}
+
 // NormalizeForSearch prepares a string for case-insensitive
 // substring matching: every Unicode whitespace rune (including
 // non-breaking space U+00A0) becomes a regular ASCII space,
@@ -339,3 +339,21 @@ func TestFoldedWordContains_RejectsSubstringNoise(t *testing.T) {
 		})
 	}
 }
+
+func TestFoldedEqual(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want bool
+	}{
+		{"Isekai", "isekai", true},
+		{"Shōnen", "SHONEN", true},
+		{"Slice of  Life", "slice of life", true},
+		{"Reverse Isekai", "isekai", false},
+		{"", "", true},
+	}
+	for _, tc := range cases {
+		if got := FoldedEqual(tc.a, tc.b); got != tc.want {
+			t.Fatalf("FoldedEqual(%q, %q) = %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+	}
+}